@@ -16,6 +16,9 @@ server {
   read_timeout  = "30s"
   write_timeout = "30s"
   idle_timeout  = "120s"
+
+  # Проксі (ingress), чиєму X-Forwarded-For довіряється при визначенні IP клієнта
+  trusted_proxies = ["10.0.0.0/8"]
 }
 
 # Налаштування бази даних
@@ -113,13 +116,68 @@ security {
   # Session
   session {
     secret = "dev-session-secret-change-in-production"
-    max_age = 3600
+    max_age = 3600          # абсолютний термін життя сесії (секунди)
+    idle_timeout = "30m"    # сесія завершується після такого часу без активності; "0" вимикає
     secure = true
     http_only = true
+    store = "memory" # memory або postgres (потрібен при кількох репліках)
+    require_active_session = false # true: access токени завершеної сесії відхиляються одразу
+
+    # Cookie сесії для SPA: HttpOnly cookie + CSRF токен у заголовку X-CSRF-Token
+    cookie_enabled = false
+    cookie_name    = "session_id"
+    same_site      = "lax" # lax, strict або none (none потребує secure = true)
+  }
+
+  # Підтвердження email
+  email_verification {
+    required_for_login = false
+    token_ttl          = "24h"
+    verify_url         = "https://api.example.com/auth/verify-email"
+  }
+
+  # Скидання пароля (посилання з листа)
+  password_reset {
+    token_ttl = "1h"
+    reset_url = "https://api.example.com/auth/password/reset"
+  }
+
+  # Захист від перебору паролів
+  brute_force {
+    max_account_failures = 5
+    max_ip_failures      = 20
+    base_delay           = "1s"
+    max_delay            = "30s"
+    lockout_duration     = "15m"
+    failure_window       = "15m"
+  }
+
+  # Парольна політика
+  password_policy {
+    min_length          = 8
+    max_length          = 128
+    require_uppercase   = false
+    require_lowercase   = false
+    require_digit       = false
+    require_symbol      = false
+    allow_personal_info = false
+
+    # Локальна база зламаних паролів (файли <PREFIX>.txt з рядками SUFFIX:COUNT)
+    breached_passwords_dir = ""
+  }
+
+  # Хешування паролів (argon2id за замовчуванням, bcrypt підтримується для перевірки старих хешів)
+  password_hashing {
+    algorithm          = "argon2id"
+    argon2_memory      = 19456
+    argon2_iterations  = 2
+    argon2_parallelism = 1
+    bcrypt_cost        = 10
   }
 }
 
 # Налаштування Redis (для сесій та кешування)
+# enabled = true переводить сесії, OIDC state та відкликання токенів у Redis замість security.session.store
 redis {
   enabled = false
   host    = "localhost"
@@ -131,3 +189,21 @@ redis {
   max_retries = 3
   pool_size   = 10
 }
+
+# Налаштування пошти (driver: log або smtp)
+mail {
+  driver = "log"
+  from   = "no-reply@example.com"
+}
+
+# Журнал аудиту подій безпеки
+audit {
+  retention      = "2160h" # 90 днів
+  purge_interval = "24h"
+}
+
+# Сповіщення, особисті повідомлення та присутність друзів у реальному часі
+notifications {
+  bridge     = "memory" # memory або postgres (LISTEN/NOTIFY, потрібен при кількох репліках)
+  keep_alive = "30s"    # інтервал keep-alive коментарів у потоці та ping кадрів WebSocket
+}
@@ -37,6 +37,60 @@ func migrateAction(c *cli.Context) error {
 	return nil
 }
 
+// seedAction створює вбудовані ролі та призначає адміністраторів
+func seedAction(c *cli.Context) error {
+	configPath := c.String("config")
+	fmt.Println("🌱 Seeding roles and permissions...")
+
+	// Перевіряємо що конфіг існує
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		return fmt.Errorf("config file does not exist: %s. Run 'configure' command first", configPath)
+	}
+
+	// Завантажуємо конфігурацію
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if err := config.SeedRoles(cfg, c.StringSlice("admin-email")); err != nil {
+		return fmt.Errorf("failed to seed roles: %w", err)
+	}
+
+	fmt.Println("✅ Roles seeded successfully")
+	return nil
+}
+
+// auditPurgeAction видаляє застарілі записи журналу аудиту
+func auditPurgeAction(c *cli.Context) error {
+	configPath := c.String("config")
+	fmt.Println("🧹 Purging audit log...")
+
+	// Перевіряємо що конфіг існує
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		return fmt.Errorf("config file does not exist: %s. Run 'configure' command first", configPath)
+	}
+
+	// Завантажуємо конфігурацію
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	retention := cfg.Audit.Retention
+	if olderThan := c.String("older-than"); olderThan != "" {
+		retention = olderThan
+	}
+
+	purged, err := config.PurgeAuditLog(cfg, retention)
+	if err != nil {
+		return fmt.Errorf("failed to purge audit log: %w", err)
+	}
+
+	fmt.Printf("✅ Purged %d audit log entries older than %s\n", purged, retention)
+	return nil
+}
+
 // configureAction генерує конфігурацію з шаблону
 func configureAction(c *cli.Context) error {
 	templatePath := c.String("template")
@@ -65,6 +65,40 @@ func NewApp() *cli.App {
 				},
 				Action: migrateAction,
 			},
+			{
+				Name:  "seed",
+				Usage: "Seed built-in roles and assign the admin role",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "config",
+						Aliases: []string{"c"},
+						Usage:   "Configuration file path",
+						Value:   "_local.hcl",
+					},
+					&cli.StringSliceFlag{
+						Name:  "admin-email",
+						Usage: "Email of an existing user to grant the admin role (repeatable)",
+					},
+				},
+				Action: seedAction,
+			},
+			{
+				Name:  "audit-purge",
+				Usage: "Delete audit log entries older than the retention period",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "config",
+						Aliases: []string{"c"},
+						Usage:   "Configuration file path",
+						Value:   "_local.hcl",
+					},
+					&cli.StringFlag{
+						Name:  "older-than",
+						Usage: "Override the configured retention (e.g. 720h)",
+					},
+				},
+				Action: auditPurgeAction,
+			},
 			{
 				Name:   "version",
 				Usage:  "Show version information",
@@ -3,6 +3,7 @@ package config
 import (
 	"context"
 	"fmt"
+	"math"
 	"net/http"
 	"os"
 	"os/signal"
@@ -18,6 +19,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/hashicorp/hcl/v2/hclsimple"
+	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -33,6 +35,10 @@ type Config struct {
 	OIDC     OIDCConfig     `hcl:"oidc,block"`
 	Security SecurityConfig `hcl:"security,block"`
 	Redis    RedisConfig    `hcl:"redis,block"`
+	Mail     *MailConfig    `hcl:"mail,block"`
+	Audit    *AuditConfig   `hcl:"audit,block"`
+
+	Notifications *NotificationsConfig `hcl:"notifications,block"`
 }
 
 // ServerConfig містить налаштування HTTP сервера
@@ -45,6 +51,9 @@ type ServerConfig struct {
 	ReadTimeout  string `hcl:"read_timeout"`
 	WriteTimeout string `hcl:"write_timeout"`
 	IdleTimeout  string `hcl:"idle_timeout"`
+	// TrustedProxies адреси або CIDR проксі, чиєму X-Forwarded-For довіряється при визначенні IP клієнта.
+	// Порожній список — IP клієнта береться з TCP з'єднання, заголовки ігноруються.
+	TrustedProxies []string `hcl:"trusted_proxies,optional"`
 }
 
 // DatabaseConfig містить налаштування бази даних
@@ -95,6 +104,12 @@ type SecurityConfig struct {
 	CORS      CORSConfig      `hcl:"cors,block"`
 	RateLimit RateLimitConfig `hcl:"rate_limit,block"`
 	Session   SessionConfig   `hcl:"session,block"`
+
+	EmailVerification *EmailVerificationConfig `hcl:"email_verification,block"`
+	BruteForce        *BruteForceConfig        `hcl:"brute_force,block"`
+	PasswordPolicy    *PasswordPolicyConfig    `hcl:"password_policy,block"`
+	PasswordHashing   *PasswordHashingConfig   `hcl:"password_hashing,block"`
+	PasswordReset     *PasswordResetConfig     `hcl:"password_reset,block"`
 }
 
 // CORSConfig містить налаштування CORS
@@ -115,10 +130,88 @@ type RateLimitConfig struct {
 
 // SessionConfig містить налаштування сесій
 type SessionConfig struct {
-	Secret   string `hcl:"secret"`
-	MaxAge   int    `hcl:"max_age"`
-	Secure   bool   `hcl:"secure"`
-	HTTPOnly bool   `hcl:"http_only"`
+	Secret      string `hcl:"secret"`
+	MaxAge      int    `hcl:"max_age"`               // абсолютний термін життя сесії в секундах
+	IdleTimeout string `hcl:"idle_timeout,optional"` // завершення сесії без активності; "0" вимикає
+	Secure      bool   `hcl:"secure"`
+	HTTPOnly    bool   `hcl:"http_only"`
+	Store       string `hcl:"store,optional"` // memory або postgres; memory — лише для однієї репліки; ігнорується при redis.enabled
+
+	// Відхиляти access токени, сесію яких завершено (інакше токен діє до закінчення терміну дії)
+	RequireActiveSession bool `hcl:"require_active_session,optional"`
+
+	// Cookie сесії для браузера: підписана cookie замість bearer токенів у JavaScript
+	CookieEnabled bool   `hcl:"cookie_enabled,optional"`
+	CookieName    string `hcl:"cookie_name,optional"`
+	SameSite      string `hcl:"same_site,optional"` // lax, strict або none (лише з secure = true)
+}
+
+// EmailVerificationConfig містить налаштування підтвердження email
+type EmailVerificationConfig struct {
+	RequiredForLogin bool   `hcl:"required_for_login,optional"`
+	TokenTTL         string `hcl:"token_ttl,optional"`
+	VerifyURL        string `hcl:"verify_url,optional"`
+}
+
+// PasswordResetConfig містить налаштування скидання пароля
+type PasswordResetConfig struct {
+	TokenTTL string `hcl:"token_ttl,optional"`
+	ResetURL string `hcl:"reset_url,optional"`
+}
+
+// BruteForceConfig містить налаштування захисту від перебору паролів
+type BruteForceConfig struct {
+	MaxAccountFailures int    `hcl:"max_account_failures,optional"`
+	MaxIPFailures      int    `hcl:"max_ip_failures,optional"`
+	BaseDelay          string `hcl:"base_delay,optional"`
+	MaxDelay           string `hcl:"max_delay,optional"`
+	LockoutDuration    string `hcl:"lockout_duration,optional"`
+	FailureWindow      string `hcl:"failure_window,optional"`
+}
+
+// PasswordPolicyConfig містить налаштування парольної політики
+type PasswordPolicyConfig struct {
+	MinLength         int  `hcl:"min_length,optional"`
+	MaxLength         int  `hcl:"max_length,optional"`
+	RequireUppercase  bool `hcl:"require_uppercase,optional"`
+	RequireLowercase  bool `hcl:"require_lowercase,optional"`
+	RequireDigit      bool `hcl:"require_digit,optional"`
+	RequireSymbol     bool `hcl:"require_symbol,optional"`
+	AllowPersonalInfo bool `hcl:"allow_personal_info,optional"`
+
+	// Директорія з файлами діапазонів SHA-1 хешів зламаних паролів (<PREFIX>.txt)
+	BreachedPasswordsDir string `hcl:"breached_passwords_dir,optional"`
+}
+
+// PasswordHashingConfig містить налаштування хешування паролів
+type PasswordHashingConfig struct {
+	Algorithm         string `hcl:"algorithm,optional"`     // argon2id або bcrypt
+	Argon2Memory      int    `hcl:"argon2_memory,optional"` // KiB
+	Argon2Iterations  int    `hcl:"argon2_iterations,optional"`
+	Argon2Parallelism int    `hcl:"argon2_parallelism,optional"`
+	BcryptCost        int    `hcl:"bcrypt_cost,optional"`
+}
+
+// MailConfig містить налаштування відправки email
+type MailConfig struct {
+	Driver   string `hcl:"driver,optional"` // log або smtp
+	Host     string `hcl:"host,optional"`
+	Port     int    `hcl:"port,optional"`
+	Username string `hcl:"username,optional"`
+	Password string `hcl:"password,optional"`
+	From     string `hcl:"from,optional"`
+}
+
+// AuditConfig містить налаштування журналу аудиту
+type AuditConfig struct {
+	Retention     string `hcl:"retention,optional"`      // скільки зберігати записи
+	PurgeInterval string `hcl:"purge_interval,optional"` // як часто видаляти застарілі записи
+}
+
+// NotificationsConfig містить налаштування доставки сповіщень, особистих повідомлень та присутності в реальному часі
+type NotificationsConfig struct {
+	Bridge    string `hcl:"bridge,optional"`     // memory або postgres (LISTEN/NOTIFY, потрібен при кількох репліках)
+	KeepAlive string `hcl:"keep_alive,optional"` // інтервал keep-alive повідомлень у потоці та ping кадрів WebSocket
 }
 
 // RedisConfig містить налаштування Redis
@@ -144,6 +237,9 @@ func LoadConfig(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to decode config file: %w", err)
 	}
 
+	// Значення за замовчуванням для необов'язкових блоків
+	config.applyDefaults()
+
 	// Валідація конфігурації
 	if err := config.Validate(); err != nil {
 		return nil, fmt.Errorf("config validation failed: %w", err)
@@ -152,6 +248,123 @@ func LoadConfig(configPath string) (*Config, error) {
 	return &config, nil
 }
 
+// applyDefaults заповнює необов'язкові блоки значеннями за замовчуванням
+func (c *Config) applyDefaults() {
+	if c.Mail == nil {
+		c.Mail = &MailConfig{}
+	}
+	if c.Mail.Driver == "" {
+		c.Mail.Driver = "log"
+	}
+	if c.Mail.From == "" {
+		c.Mail.From = "no-reply@example.com"
+	}
+
+	if c.Audit == nil {
+		c.Audit = &AuditConfig{}
+	}
+	if c.Audit.Retention == "" {
+		c.Audit.Retention = "2160h"
+	}
+	if c.Audit.PurgeInterval == "" {
+		c.Audit.PurgeInterval = "24h"
+	}
+
+	if c.Notifications == nil {
+		c.Notifications = &NotificationsConfig{}
+	}
+	if c.Notifications.Bridge == "" {
+		c.Notifications.Bridge = "memory"
+	}
+	if c.Notifications.KeepAlive == "" {
+		c.Notifications.KeepAlive = "30s"
+	}
+
+	if c.Security.EmailVerification == nil {
+		c.Security.EmailVerification = &EmailVerificationConfig{}
+	}
+	if c.Security.EmailVerification.TokenTTL == "" {
+		c.Security.EmailVerification.TokenTTL = "24h"
+	}
+	if c.Security.EmailVerification.VerifyURL == "" {
+		c.Security.EmailVerification.VerifyURL = "https://api.example.com/auth/verify-email"
+	}
+
+	if c.Security.PasswordReset == nil {
+		c.Security.PasswordReset = &PasswordResetConfig{}
+	}
+	if c.Security.PasswordReset.TokenTTL == "" {
+		c.Security.PasswordReset.TokenTTL = "1h"
+	}
+	if c.Security.PasswordReset.ResetURL == "" {
+		c.Security.PasswordReset.ResetURL = "https://api.example.com/auth/password/reset"
+	}
+
+	if c.Security.Session.Store == "" {
+		c.Security.Session.Store = "memory"
+	}
+	if c.Security.Session.IdleTimeout == "" {
+		c.Security.Session.IdleTimeout = "0"
+	}
+	if c.Security.Session.CookieName == "" {
+		c.Security.Session.CookieName = "session_id"
+	}
+	if c.Security.Session.SameSite == "" {
+		c.Security.Session.SameSite = "lax"
+	}
+
+	if c.Security.BruteForce == nil {
+		c.Security.BruteForce = &BruteForceConfig{}
+	}
+	if c.Security.BruteForce.MaxAccountFailures == 0 {
+		c.Security.BruteForce.MaxAccountFailures = 5
+	}
+	if c.Security.BruteForce.MaxIPFailures == 0 {
+		c.Security.BruteForce.MaxIPFailures = 20
+	}
+	if c.Security.BruteForce.BaseDelay == "" {
+		c.Security.BruteForce.BaseDelay = "1s"
+	}
+	if c.Security.BruteForce.MaxDelay == "" {
+		c.Security.BruteForce.MaxDelay = "30s"
+	}
+	if c.Security.BruteForce.LockoutDuration == "" {
+		c.Security.BruteForce.LockoutDuration = "15m"
+	}
+	if c.Security.BruteForce.FailureWindow == "" {
+		c.Security.BruteForce.FailureWindow = "15m"
+	}
+
+	if c.Security.PasswordPolicy == nil {
+		c.Security.PasswordPolicy = &PasswordPolicyConfig{}
+	}
+	if c.Security.PasswordPolicy.MinLength == 0 {
+		c.Security.PasswordPolicy.MinLength = 8
+	}
+	if c.Security.PasswordPolicy.MaxLength == 0 {
+		c.Security.PasswordPolicy.MaxLength = 128
+	}
+
+	if c.Security.PasswordHashing == nil {
+		c.Security.PasswordHashing = &PasswordHashingConfig{}
+	}
+	if c.Security.PasswordHashing.Algorithm == "" {
+		c.Security.PasswordHashing.Algorithm = "argon2id"
+	}
+	if c.Security.PasswordHashing.Argon2Memory == 0 {
+		c.Security.PasswordHashing.Argon2Memory = 19456
+	}
+	if c.Security.PasswordHashing.Argon2Iterations == 0 {
+		c.Security.PasswordHashing.Argon2Iterations = 2
+	}
+	if c.Security.PasswordHashing.Argon2Parallelism == 0 {
+		c.Security.PasswordHashing.Argon2Parallelism = 1
+	}
+	if c.Security.PasswordHashing.BcryptCost == 0 {
+		c.Security.PasswordHashing.BcryptCost = 10
+	}
+}
+
 // Validate перевіряє валідність конфігурації
 func (c *Config) Validate() error {
 	// Перевірка обов'язкових полів сервера
@@ -185,6 +398,78 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("session secret is required")
 	}
 
+	switch c.Security.Session.Store {
+	case "memory", "postgres":
+	default:
+		return fmt.Errorf("unsupported session store: %s", c.Security.Session.Store)
+	}
+
+	if c.Security.Session.MaxAge <= 0 {
+		return fmt.Errorf("session max_age must be positive")
+	}
+
+	switch c.Security.Session.SameSite {
+	case "lax", "strict":
+	case "none":
+		if !c.Security.Session.Secure {
+			return fmt.Errorf("session same_site = \"none\" requires secure = true")
+		}
+	default:
+		return fmt.Errorf("unsupported session same_site: %s", c.Security.Session.SameSite)
+	}
+
+	// Перевірка Redis
+	if c.Redis.Enabled && (c.Redis.Host == "" || c.Redis.Port <= 0) {
+		return fmt.Errorf("redis host and port are required when redis is enabled")
+	}
+
+	// Перевірка парольної політики
+	if c.Security.PasswordPolicy.MaxLength < c.Security.PasswordPolicy.MinLength {
+		return fmt.Errorf("password policy max_length must not be less than min_length")
+	}
+
+	// Перевірка хешування паролів
+	switch c.Security.PasswordHashing.Algorithm {
+	case "argon2id":
+		// Від'ємні значення інакше перетворились би на величезні uint32 або обрізались у uint8
+		if c.Security.PasswordHashing.Argon2Memory < 1 {
+			return fmt.Errorf("argon2_memory must be at least 1")
+		}
+		if c.Security.PasswordHashing.Argon2Iterations < 1 {
+			return fmt.Errorf("argon2_iterations must be at least 1")
+		}
+		if c.Security.PasswordHashing.Argon2Parallelism < 1 || c.Security.PasswordHashing.Argon2Parallelism > 255 {
+			return fmt.Errorf("argon2_parallelism must be between 1 and 255")
+		}
+		if int64(c.Security.PasswordHashing.Argon2Memory) > math.MaxUint32 {
+			return fmt.Errorf("argon2_memory must not exceed %d", uint32(math.MaxUint32))
+		}
+		if int64(c.Security.PasswordHashing.Argon2Iterations) > math.MaxUint32 {
+			return fmt.Errorf("argon2_iterations must not exceed %d", uint32(math.MaxUint32))
+		}
+	case "bcrypt":
+		// Довші за 72 байти паролі відхиляє парольна політика (max_length рахує символи, а не байти)
+	default:
+		return fmt.Errorf("unsupported password hashing algorithm: %s", c.Security.PasswordHashing.Algorithm)
+	}
+
+	switch c.Notifications.Bridge {
+	case "memory", "postgres":
+	default:
+		return fmt.Errorf("unsupported notifications bridge: %s", c.Notifications.Bridge)
+	}
+
+	// Перевірка налаштувань пошти
+	switch c.Mail.Driver {
+	case "log":
+	case "smtp":
+		if c.Mail.Host == "" || c.Mail.Port <= 0 {
+			return fmt.Errorf("mail host and port are required for smtp driver")
+		}
+	default:
+		return fmt.Errorf("unsupported mail driver: %s", c.Mail.Driver)
+	}
+
 	return nil
 }
 
@@ -231,6 +516,15 @@ func StartServer(cfg *Config) error {
 		return fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	// Підключення до Redis (якщо увімкнено) для сесій, OIDC state та відкликань
+	var redisClient *redis.Client
+	if cfg.Redis.Enabled {
+		redisClient, err = connectToRedis(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to connect to redis: %w", err)
+		}
+	}
+
 	// Налаштування Gin режиму
 	if cfg.IsProduction() {
 		gin.SetMode(gin.ReleaseMode)
@@ -241,17 +535,23 @@ func StartServer(cfg *Config) error {
 	// Створення Gin роутера
 	r := gin.New()
 
+	// Без явного списку gin довіряє X-Forwarded-For від будь-кого, і клієнт підміняє свій IP
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return fmt.Errorf("invalid server trusted_proxies: %w", err)
+	}
+
 	// Swagger UI route
 	// Імпорти: _ "go-practice/docs", ginSwagger "github.com/swaggo/gin-swagger", swaggerFiles "github.com/swaggo/files"
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// Додавання middleware
-	r.Use(gin.Logger())
+	r.Use(middleware.RequestID())
+	r.Use(middleware.AccessLogger())
 	r.Use(gin.Recovery())
 	r.Use(corsMiddleware(cfg))
 
 	// Реєстрація routes (передаємо db для використання в handlers)
-	setupRoutes(r, cfg, db)
+	setupRoutes(r, cfg, db, redisClient)
 
 	// Парсинг таймаутів
 	readTimeout, err := time.ParseDuration(cfg.Server.ReadTimeout)
@@ -344,6 +644,7 @@ func corsMiddleware(cfg *Config) gin.HandlerFunc {
 
 		c.Header("Access-Control-Allow-Methods", joinStrings(cfg.Security.CORS.AllowedMethods, ", "))
 		c.Header("Access-Control-Allow-Headers", joinStrings(cfg.Security.CORS.AllowedHeaders, ", "))
+		c.Header("Access-Control-Expose-Headers", joinStrings([]string{middleware.RequestIDHeader, middleware.SessionExpiresInHeader}, ", "))
 
 		if cfg.Security.CORS.AllowCredentials {
 			c.Header("Access-Control-Allow-Credentials", "true")
@@ -364,9 +665,31 @@ func corsMiddleware(cfg *Config) gin.HandlerFunc {
 }
 
 // setupRoutes налаштовує маршрути
-func setupRoutes(r *gin.Engine, cfg *Config, db *gorm.DB) {
+func setupRoutes(r *gin.Engine, cfg *Config, db *gorm.DB, redisClient *redis.Client) {
 	// Ініціалізуємо сервіси
-	userService := services.NewUserService(db)
+	passwordPolicy := services.NewPasswordPolicy(services.PasswordPolicyConfig{
+		MinLength:            cfg.Security.PasswordPolicy.MinLength,
+		MaxLength:            cfg.Security.PasswordPolicy.MaxLength,
+		MaxBytes:             passwordMaxBytes(cfg),
+		RequireUppercase:     cfg.Security.PasswordPolicy.RequireUppercase,
+		RequireLowercase:     cfg.Security.PasswordPolicy.RequireLowercase,
+		RequireDigit:         cfg.Security.PasswordPolicy.RequireDigit,
+		RequireSymbol:        cfg.Security.PasswordPolicy.RequireSymbol,
+		AllowPersonalInfo:    cfg.Security.PasswordPolicy.AllowPersonalInfo,
+		BreachedPasswordsDir: cfg.Security.PasswordPolicy.BreachedPasswordsDir,
+	})
+	passwordHasher := services.NewPasswordHasher(services.PasswordHasherConfig{
+		Algorithm: cfg.Security.PasswordHashing.Algorithm,
+		Argon2: services.Argon2Params{
+			Memory:      uint32(cfg.Security.PasswordHashing.Argon2Memory),
+			Iterations:  uint32(cfg.Security.PasswordHashing.Argon2Iterations),
+			Parallelism: uint8(cfg.Security.PasswordHashing.Argon2Parallelism),
+			SaltLength:  16,
+			KeyLength:   32,
+		},
+		BcryptCost: cfg.Security.PasswordHashing.BcryptCost,
+	})
+	userService := services.NewUserService(db, passwordPolicy, passwordHasher)
 
 	// Створюємо JWT сервіс з секретами з конфігурації
 	jwtService := services.NewJWTService(
@@ -375,8 +698,8 @@ func setupRoutes(r *gin.Engine, cfg *Config, db *gorm.DB) {
 		cfg.OIDC.Tokens.SigningKey+"_refresh",
 	)
 
-	// Створюємо State сервіс для CSRF захисту (TTL 10 хвилин)
-	stateService := services.NewStateService(10 * time.Minute)
+	// Сесії, OIDC state (TTL 10 хвилин) та відкликання зберігаються у сховищі з конфігурації
+	sessionManager, stateService, revocationStore := newSessionStores(cfg, db, redisClient)
 
 	// Створюємо OIDC Provider сервіс для роботи з зовнішнім провайдером
 	oidcProviderService := services.NewOIDCProviderService(
@@ -387,15 +710,102 @@ func setupRoutes(r *gin.Engine, cfg *Config, db *gorm.DB) {
 		cfg.OIDC.Provider.Issuer,
 	)
 
-	// Створюємо Session Manager для відстеження сесій (TTL 1 година)
-	sessionManager := services.NewSessionManager(1 * time.Hour)
+	// Створюємо сервіс підтвердження email
+	emailVerificationService := services.NewEmailVerificationService(
+		db,
+		userService,
+		newMailer(cfg),
+		parseDuration(cfg.Security.EmailVerification.TokenTTL, 24*time.Hour, "email verification token ttl"),
+		cfg.Security.EmailVerification.VerifyURL,
+	)
+
+	// Створюємо сервіс захисту від перебору паролів
+	loginAttemptService := newLoginAttemptService(cfg, db, redisClient, services.LoginAttemptConfig{
+		MaxAccountFailures: cfg.Security.BruteForce.MaxAccountFailures,
+		MaxIPFailures:      cfg.Security.BruteForce.MaxIPFailures,
+		BaseDelay:          parseDuration(cfg.Security.BruteForce.BaseDelay, time.Second, "brute force base delay"),
+		MaxDelay:           parseDuration(cfg.Security.BruteForce.MaxDelay, 30*time.Second, "brute force max delay"),
+		LockoutDuration:    parseDuration(cfg.Security.BruteForce.LockoutDuration, 15*time.Minute, "brute force lockout duration"),
+		FailureWindow:      parseDuration(cfg.Security.BruteForce.FailureWindow, 15*time.Minute, "brute force failure window"),
+	})
+
+	// Створюємо сервіс personal access tokens
+	patService := services.NewPersonalAccessTokenService(db)
+
+	// Створюємо сервіс ролей та дозволів
+	rbacService := services.NewRBACService(db)
+
+	// Створюємо сервіс скидання паролів
+	passwordResetService := services.NewPasswordResetService(
+		db,
+		userService,
+		newMailer(cfg),
+		parseDuration(cfg.Security.PasswordReset.TokenTTL, time.Hour, "password reset token ttl"),
+		cfg.Security.PasswordReset.ResetURL,
+	)
+
+	// Створюємо журнал аудиту з періодичним видаленням застарілих записів
+	auditService := services.NewAuditService(
+		db,
+		parseDuration(cfg.Audit.Retention, 2160*time.Hour, "audit retention"),
+		parseDuration(cfg.Audit.PurgeInterval, 24*time.Hour, "audit purge interval"),
+	)
 
 	// Створюємо Auth сервіс який об'єднує всі інші сервіси
-	authService := services.NewAuthService(userService, jwtService, stateService, oidcProviderService, sessionManager)
+	authService := services.NewAuthService(
+		userService,
+		jwtService,
+		stateService,
+		oidcProviderService,
+		sessionManager,
+		emailVerificationService,
+		loginAttemptService,
+		rbacService,
+		passwordResetService,
+		auditService,
+		revocationStore,
+		cfg.Security.EmailVerification.RequiredForLogin,
+	)
+
+	// Створюємо сервіс імперсонації користувачів адміністраторами
+	impersonationService := services.NewImpersonationService(db, userService, jwtService, rbacService)
+
+	// Створюємо сервіс адміністрування користувачів
+	userAdminService := services.NewUserAdminService(db, authService, passwordResetService)
+
+	// Створюємо сервіси запитів у друзі, блокувань та груп друзів
+	friendService := services.NewFriendService(db, userService)
+	friendGroupService := services.NewFriendGroupService(db, userService)
+
+	// Події реального часу між репліками: одне з'єднання LISTEN на репліку для всіх видів подій
+	realtimeBridge := newRealtimeBridge(cfg, db)
+
+	// Створюємо сервіс сповіщень; потоки отримують нові сповіщення через hub цієї репліки
+	notificationHub := services.NewNotificationHub()
+	notificationService := services.NewNotificationService(db, notificationHub, newNotificationPublisher(realtimeBridge, db, notificationHub))
+
+	// Створюємо сервіс особистих повідомлень; WebSocket з'єднання отримують події через hub цієї репліки
+	messageHub := services.NewMessageHub()
+	messageService := services.NewMessageService(db, userService, messageHub, newMessagePublisher(realtimeBridge, db, messageHub))
+
+	// Створюємо сервіс присутності; потоки друзів отримують зміни через hub цієї репліки
+	presenceHub := services.NewPresenceHub()
+	presenceService := services.NewPresenceService(db, presenceHub, newPresencePublisher(realtimeBridge, db, presenceHub))
 
 	// Ініціалізуємо handlers з усіма сервісами
-	authHandler := handlers.NewAuthHandler(authService, cfg.OIDC.Provider.PostLogoutRedirectURL) // Передаємо postLogoutRedirectURL з конфігурації
-	apiHandler := handlers.NewAPIHandler(userService)                                            // Health endpoint з інформацією про базу даних
+	sessionCookies := newSessionCookies(cfg)
+	authHandler := handlers.NewAuthHandler(authService, cfg.OIDC.Provider.PostLogoutRedirectURL, sessionCookies) // Передаємо postLogoutRedirectURL з конфігурації
+	apiHandler := handlers.NewAPIHandler(userService, rbacService, friendGroupService, presenceService, auditService)
+	adminHandler := handlers.NewAdminHandler(userService, loginAttemptService, rbacService, userAdminService, impersonationService, auditService)
+	accountHandler := handlers.NewAccountHandler(authService, patService, impersonationService, auditService, sessionCookies)
+	friendHandler := handlers.NewFriendHandler(friendService, friendGroupService, notificationService, auditService)
+	followHandler := handlers.NewFollowHandler(userService, notificationService, presenceService, auditService)
+	streamKeepAlive := parseDuration(cfg.Notifications.KeepAlive, 30*time.Second, "notifications keep alive")
+	notificationHandler := handlers.NewNotificationHandler(notificationService, streamKeepAlive)
+	messageHandler := handlers.NewMessageHandler(messageService, streamKeepAlive, cfg.Security.CORS.AllowedOrigins)
+	presenceHandler := handlers.NewPresenceHandler(presenceService, userService, streamKeepAlive)
+
+	// Health endpoint з інформацією про базу даних
 	r.GET("/health", func(c *gin.Context) {
 		// Перевірка підключення до БД
 		sqlDB, err := db.DB()
@@ -444,18 +854,135 @@ func setupRoutes(r *gin.Engine, cfg *Config, db *gorm.DB) {
 		})
 
 		// Protected endpoints з middleware аутентифікації
+		authMiddleware := middleware.CookieAuthMiddleware(sessionCookies, authService,
+			middleware.AuthMiddleware(jwtService, userService, patService, impersonationService, revocationStore,
+				authService, cfg.Security.Session.RequireActiveSession))
+
+		// WebSocket особистих повідомлень: браузер не може передати заголовок Authorization, тому JWT приймається і з query
+		api.GET("/messages/ws", middleware.WebSocketToken(), authMiddleware,
+			middleware.RequireScopes(services.ScopeMessagesRead), middleware.RealtimeConnection(presenceService), messageHandler.Stream)
+
 		protected := api.Group("/")
-		protected.Use(middleware.AuthMiddleware(jwtService, userService))
+		protected.Use(authMiddleware, middleware.TrackPresence(presenceService))
 		{
-			protected.GET("/protected", apiHandler.ProtectedData)
-			protected.GET("/profile", apiHandler.UserProfile)
-			protected.PUT("/profile", apiHandler.UpdateProfile)
-			protected.GET("/user-data", apiHandler.UserData)
-			protected.GET("/users", apiHandler.Users)
-			protected.GET("/users/:id", apiHandler.GetUserByID)
-			protected.POST("/users/search", apiHandler.SearchUsers)
-			protected.POST("/friends/add", apiHandler.AddFriend)
-			protected.GET("/friends", apiHandler.GetFriends)
+			protected.GET("/protected", middleware.RequireScopes(services.ScopeProfileRead), apiHandler.ProtectedData)
+			protected.GET("/profile", middleware.RequireScopes(services.ScopeProfileRead), apiHandler.UserProfile)
+			protected.PUT("/profile", middleware.RequireScopes(services.ScopeProfileWrite), apiHandler.UpdateProfile)
+			protected.PUT("/profile/privacy", middleware.RequireScopes(services.ScopeProfileWrite), followHandler.UpdatePrivacy)
+			protected.GET("/user-data", middleware.RequireScopes(services.ScopeProfileRead), apiHandler.UserData)
+			protected.GET("/users", middleware.RequireScopes(services.ScopeUsersRead), middleware.RequirePermission(rbacService, services.PermissionUsersList), apiHandler.Users)
+			protected.GET("/users/:id", middleware.RequireScopes(services.ScopeUsersRead), apiHandler.GetUserByID)
+			protected.POST("/users/search", middleware.RequireScopes(services.ScopeUsersRead), apiHandler.SearchUsers)
+			protected.POST("/users/:id/follow", middleware.RequireScopes(services.ScopeFriendsWrite), followHandler.Follow)
+			protected.DELETE("/users/:id/follow", middleware.RequireScopes(services.ScopeFriendsWrite), followHandler.Unfollow)
+			protected.GET("/friends", middleware.RequireScopes(services.ScopeFriendsRead), apiHandler.GetFriends)
+			// Старий маршрут додавання в друзі тепер надсилає запит
+			protected.POST("/friends/add", middleware.RequireScopes(services.ScopeFriendsWrite), friendHandler.SendRequest)
+			protected.DELETE("/friends/:id", middleware.RequireScopes(services.ScopeFriendsWrite), friendHandler.RemoveFriend)
+			protected.GET("/friends/mutual/:id", middleware.RequireScopes(services.ScopeFriendsRead), friendHandler.MutualFriends)
+			protected.GET("/friends/suggestions", middleware.RequireScopes(services.ScopeFriendsRead), friendHandler.Suggestions)
+			protected.GET("/friends/presence/stream", middleware.RequireScopes(services.ScopeFriendsRead), middleware.RealtimeConnection(presenceService), presenceHandler.Stream)
+
+			// Запити в друзі: дружба створюється лише після згоди отримувача
+			friendRequests := protected.Group("/friends/requests")
+			{
+				friendRequests.POST("", middleware.RequireScopes(services.ScopeFriendsWrite), friendHandler.SendRequest)
+				friendRequests.GET("/incoming", middleware.RequireScopes(services.ScopeFriendsRead), friendHandler.ListIncoming)
+				friendRequests.GET("/outgoing", middleware.RequireScopes(services.ScopeFriendsRead), friendHandler.ListOutgoing)
+				friendRequests.POST("/:id/accept", middleware.RequireScopes(services.ScopeFriendsWrite), friendHandler.AcceptRequest)
+				friendRequests.POST("/:id/decline", middleware.RequireScopes(services.ScopeFriendsWrite), friendHandler.DeclineRequest)
+				friendRequests.POST("/:id/cancel", middleware.RequireScopes(services.ScopeFriendsWrite), friendHandler.CancelRequest)
+			}
+
+			// Групи друзів: учасниками можуть бути лише друзі власника
+			friendGroups := protected.Group("/friends/groups")
+			{
+				friendGroups.GET("", middleware.RequireScopes(services.ScopeFriendsRead), friendHandler.ListGroups)
+				friendGroups.POST("", middleware.RequireScopes(services.ScopeFriendsWrite), friendHandler.CreateGroup)
+				friendGroups.GET("/:id", middleware.RequireScopes(services.ScopeFriendsRead), friendHandler.GetGroup)
+				friendGroups.PATCH("/:id", middleware.RequireScopes(services.ScopeFriendsWrite), friendHandler.RenameGroup)
+				friendGroups.DELETE("/:id", middleware.RequireScopes(services.ScopeFriendsWrite), friendHandler.DeleteGroup)
+				friendGroups.POST("/:id/members", middleware.RequireScopes(services.ScopeFriendsWrite), friendHandler.AddGroupMember)
+				friendGroups.DELETE("/:id/members/:friend_id", middleware.RequireScopes(services.ScopeFriendsWrite), friendHandler.RemoveGroupMember)
+			}
+
+			// Підписки: односторонні, на відміну від дружби; приватні профілі схвалюють підписників вручну
+			follows := protected.Group("/follows")
+			{
+				follows.GET("/followers", middleware.RequireScopes(services.ScopeFriendsRead), followHandler.ListFollowers)
+				follows.GET("/following", middleware.RequireScopes(services.ScopeFriendsRead), followHandler.ListFollowing)
+				follows.GET("/requests", middleware.RequireScopes(services.ScopeFriendsRead), followHandler.ListRequests)
+				follows.POST("/requests/:id/approve", middleware.RequireScopes(services.ScopeFriendsWrite), followHandler.ApproveRequest)
+				follows.POST("/requests/:id/decline", middleware.RequireScopes(services.ScopeFriendsWrite), followHandler.DeclineRequest)
+			}
+
+			// Сповіщення: список, позначення прочитаними та потік нових сповіщень (SSE)
+			notifications := protected.Group("/notifications")
+			{
+				notifications.GET("", middleware.RequireScopes(services.ScopeNotificationsRead), notificationHandler.List)
+				notifications.GET("/stream", middleware.RequireScopes(services.ScopeNotificationsRead), middleware.RealtimeConnection(presenceService), notificationHandler.Stream)
+				notifications.POST("/read", middleware.RequireScopes(services.ScopeNotificationsWrite), notificationHandler.MarkAllRead)
+				notifications.POST("/:id/read", middleware.RequireScopes(services.ScopeNotificationsWrite), notificationHandler.MarkRead)
+			}
+
+			// Особисті повідомлення між друзями; доставка в реальному часі через /messages/ws
+			conversations := protected.Group("/conversations")
+			{
+				conversations.GET("", middleware.RequireScopes(services.ScopeMessagesRead), messageHandler.ListConversations)
+				conversations.POST("", middleware.RequireScopes(services.ScopeMessagesWrite), messageHandler.StartConversation)
+				conversations.GET("/:id", middleware.RequireScopes(services.ScopeMessagesRead), messageHandler.GetConversation)
+				conversations.GET("/:id/messages", middleware.RequireScopes(services.ScopeMessagesRead), messageHandler.History)
+				conversations.POST("/:id/messages", middleware.RequireScopes(services.ScopeMessagesWrite), messageHandler.SendMessage)
+				conversations.POST("/:id/read", middleware.RequireScopes(services.ScopeMessagesWrite), messageHandler.MarkRead)
+				conversations.DELETE("/:id/messages/:message_id", middleware.RequireScopes(services.ScopeMessagesWrite), messageHandler.DeleteMessage)
+			}
+
+			// Блокування: заблоковані користувачі не знаходяться в пошуку і не можуть надсилати запити в друзі
+			blocks := protected.Group("/blocks")
+			{
+				blocks.GET("", middleware.RequireScopes(services.ScopeFriendsRead), friendHandler.ListBlocked)
+				blocks.POST("", middleware.RequireScopes(services.ScopeFriendsWrite), friendHandler.BlockUser)
+				blocks.DELETE("/:id", middleware.RequireScopes(services.ScopeFriendsWrite), friendHandler.UnblockUser)
+			}
+
+			// Керування обліковими даними (недоступне для personal access tokens)
+			account := protected.Group("/account")
+			account.Use(middleware.RequireScopes(services.ScopeAccountManage))
+			{
+				account.PUT("/password", accountHandler.ChangePassword)
+				account.PUT("/email", accountHandler.ChangeEmail)
+				account.POST("/tokens", accountHandler.CreateToken)
+				account.GET("/tokens", accountHandler.ListTokens)
+				account.DELETE("/tokens/:id", accountHandler.RevokeToken)
+				account.GET("/impersonations", accountHandler.ListImpersonations)
+				account.DELETE("/impersonations/:id", accountHandler.RevokeImpersonation)
+				account.GET("/sessions", accountHandler.ListSessions)
+				account.DELETE("/sessions", accountHandler.RevokeOtherSessions)
+				account.DELETE("/sessions/:id", accountHandler.RevokeSession)
+				account.GET("/security-events", accountHandler.SecurityHistory)
+			}
+
+			// Admin endpoints
+			admin := protected.Group("/admin")
+			admin.Use(middleware.RequireScopes(services.ScopeAdmin))
+			admin.Use(middleware.RequireRole(rbacService, services.RoleAdmin))
+			{
+				admin.GET("/users", middleware.RequirePermission(rbacService, services.PermissionUsersList), adminHandler.ListUsers)
+				admin.GET("/users/:id", middleware.RequirePermission(rbacService, services.PermissionUsersList), adminHandler.GetUser)
+				admin.PATCH("/users/:id", middleware.RequirePermission(rbacService, services.PermissionUsersManage), adminHandler.UpdateUser)
+				admin.DELETE("/users/:id", middleware.RequirePermission(rbacService, services.PermissionUsersManage), adminHandler.DeleteUser)
+				admin.POST("/users/:id/deactivate", middleware.RequirePermission(rbacService, services.PermissionUsersManage), adminHandler.DeactivateUser)
+				admin.POST("/users/:id/reactivate", middleware.RequirePermission(rbacService, services.PermissionUsersManage), adminHandler.ReactivateUser)
+				admin.POST("/users/:id/logout", middleware.RequirePermission(rbacService, services.PermissionUsersManage), adminHandler.ForceLogout)
+				admin.POST("/users/:id/password-reset", middleware.RequirePermission(rbacService, services.PermissionUsersManage), adminHandler.ForcePasswordReset)
+				admin.POST("/users/:id/impersonate", middleware.RequirePermission(rbacService, services.PermissionImpersonate), adminHandler.Impersonate)
+				admin.GET("/impersonations", middleware.RequirePermission(rbacService, services.PermissionImpersonate), adminHandler.ListImpersonations)
+				admin.DELETE("/impersonations/:id", middleware.RequirePermission(rbacService, services.PermissionImpersonate), adminHandler.RevokeImpersonation)
+				admin.GET("/audit", middleware.RequirePermission(rbacService, services.PermissionAuditRead), adminHandler.ListAuditLogs)
+				admin.POST("/users/:id/unlock", middleware.RequirePermission(rbacService, services.PermissionUsersManage), adminHandler.UnlockUser)
+				admin.POST("/users/:id/roles", middleware.RequirePermission(rbacService, services.PermissionRolesManage), adminHandler.AssignRole)
+				admin.DELETE("/users/:id/roles/:role", middleware.RequirePermission(rbacService, services.PermissionRolesManage), adminHandler.RemoveRole)
+			}
 		}
 
 		// Database test endpoint
@@ -492,7 +1019,156 @@ func setupRoutes(r *gin.Engine, cfg *Config, db *gorm.DB) {
 		oidc.POST("/refresh", authHandler.Refresh)   // Token Refresh
 		oidc.GET("/userinfo", authHandler.UserInfo)  // UserInfo endpoint
 		oidc.POST("/register", authHandler.Register) // User Registration
+
+		oidc.GET("/verify-email", authHandler.VerifyEmail)                // Email verification link
+		oidc.POST("/verify-email/resend", authHandler.ResendVerification) // Resend verification email
+		oidc.POST("/password/reset", authHandler.ResetPassword)           // Password reset by emailed token
+	}
+}
+
+// passwordMaxBytes повертає обмеження довжини пароля в байтах для налаштованого алгоритму хешування:
+// bcrypt мовчки ігнорує все після 72 байтів, а max_length рахує символи, яких у UTF-8 може бути вчетверо менше
+func passwordMaxBytes(cfg *Config) int {
+	if cfg.Security.PasswordHashing.Algorithm == services.PasswordAlgorithmBcrypt {
+		return services.BcryptMaxPasswordBytes
+	}
+	return 0
+}
+
+// newMailer створює Mailer відповідно до конфігурації
+func newMailer(cfg *Config) services.Mailer {
+	if cfg.Mail.Driver == "smtp" {
+		return services.NewSMTPMailer(cfg.Mail.Host, cfg.Mail.Port, cfg.Mail.Username, cfg.Mail.Password, cfg.Mail.From)
+	}
+	return services.NewLogMailer(cfg.Mail.From)
+}
+
+// newSessionStores створює сховища сесій, OIDC state та відкликаних сесій відповідно до конфігурації.
+// Сесія живе max_age з моменту входу і завершується раніше, якщо не використовується довше за idle_timeout;
+// токени відкликаної сесії відхиляються до закінчення терміну дії. Увімкнений Redis має пріоритет над security.session.store.
+func newSessionStores(cfg *Config, db *gorm.DB, redisClient *redis.Client) (services.SessionManager, services.StateService, services.RevocationStore) {
+	const stateTTL = 10 * time.Minute
+
+	ttl := time.Duration(cfg.Security.Session.MaxAge) * time.Second
+	idleTimeout := parseDuration(cfg.Security.Session.IdleTimeout, 0, "session idle timeout")
+
+	if redisClient != nil {
+		return services.NewRedisSessionManager(redisClient, ttl, idleTimeout),
+			services.NewRedisStateService(redisClient, stateTTL),
+			services.NewRedisRevocationStore(redisClient)
+	}
+	if cfg.Security.Session.Store == "postgres" {
+		return services.NewPostgresSessionManager(db, ttl, idleTimeout),
+			services.NewPostgresStateService(db, stateTTL),
+			services.NewPostgresRevocationStore(db)
+	}
+	return services.NewSessionManager(ttl, idleTimeout),
+		services.NewStateService(stateTTL),
+		services.NewRevocationStore()
+}
+
+// newLoginAttemptService створює захист від перебору паролів з лічильниками в тому ж сховищі, що й сесії,
+// щоб ліміти діяли спільно для всіх реплік. Увімкнений Redis має пріоритет над security.session.store.
+func newLoginAttemptService(cfg *Config, db *gorm.DB, redisClient *redis.Client, attemptConfig services.LoginAttemptConfig) services.LoginAttemptService {
+	if redisClient != nil {
+		return services.NewRedisLoginAttemptService(redisClient, attemptConfig)
+	}
+	if cfg.Security.Session.Store == "postgres" {
+		return services.NewPostgresLoginAttemptService(db, attemptConfig)
+	}
+	return services.NewLoginAttemptService(attemptConfig)
+}
+
+// newRealtimeBridge створює спільний bridge подій реального часу між репліками (PostgreSQL LISTEN/NOTIFY)
+// або nil, якщо notifications.bridge = "memory" і події доставляються лише потокам цієї репліки
+func newRealtimeBridge(cfg *Config, db *gorm.DB) *services.PostgresEventBridge {
+	if cfg.Notifications.Bridge == "postgres" {
+		return services.NewPostgresEventBridge(db, cfg.GetDatabaseDSN())
+	}
+	return nil
+}
+
+// newNotificationPublisher створює доставку сповіщень: без bridge — лише потоки цієї репліки, з ним — усіх реплік
+func newNotificationPublisher(bridge *services.PostgresEventBridge, db *gorm.DB, hub *services.NotificationHub) services.NotificationPublisher {
+	if bridge != nil {
+		return services.NewPostgresNotificationBridge(db, bridge, hub)
 	}
+	return hub
+}
+
+// newMessagePublisher створює доставку подій розмов через той самий bridge, що й сповіщення
+func newMessagePublisher(bridge *services.PostgresEventBridge, db *gorm.DB, hub *services.MessageHub) services.MessagePublisher {
+	if bridge != nil {
+		return services.NewPostgresMessageBridge(db, bridge, hub)
+	}
+	return hub
+}
+
+// newPresencePublisher створює доставку змін присутності через той самий bridge, що й сповіщення
+func newPresencePublisher(bridge *services.PostgresEventBridge, db *gorm.DB, hub *services.PresenceHub) services.PresencePublisher {
+	if bridge != nil {
+		return services.NewPostgresPresenceBridge(db, bridge, hub)
+	}
+	return services.NewLocalPresencePublisher(db, hub)
+}
+
+// newSessionCookies створює налаштування cookie сесій або nil, якщо cookie режим вимкнений
+func newSessionCookies(cfg *Config) *middleware.SessionCookies {
+	session := cfg.Security.Session
+	if !session.CookieEnabled {
+		return nil
+	}
+
+	sameSite := http.SameSiteLaxMode
+	switch session.SameSite {
+	case "strict":
+		sameSite = http.SameSiteStrictMode
+	case "none":
+		sameSite = http.SameSiteNoneMode
+	}
+
+	return middleware.NewSessionCookies(
+		services.NewSessionCookieCodec(session.Secret),
+		session.CookieName,
+		session.MaxAge,
+		session.Secure,
+		session.HTTPOnly,
+		sameSite,
+	)
+}
+
+// connectToRedis створює клієнт Redis з пулом з'єднань згідно RedisConfig і перевіряє підключення
+func connectToRedis(cfg *Config) (*redis.Client, error) {
+	addr := fmt.Sprintf("%s:%d", cfg.Redis.Host, cfg.Redis.Port)
+	logrus.Infof("🔌 Connecting to Redis: %s/%d", addr, cfg.Redis.Database)
+
+	client := redis.NewClient(&redis.Options{
+		Addr:       addr,
+		Password:   cfg.Redis.Password,
+		DB:         cfg.Redis.Database,
+		MaxRetries: cfg.Redis.MaxRetries,
+		PoolSize:   cfg.Redis.PoolSize,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to ping redis: %w", err)
+	}
+
+	logrus.Info("✅ Successfully connected to Redis")
+	return client, nil
+}
+
+// parseDuration парсить тривалість з конфігурації або повертає значення за замовчуванням
+func parseDuration(value string, defaultValue time.Duration, name string) time.Duration {
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		logrus.Warnf("Invalid %s '%s', using default %v: %v", name, value, defaultValue, err)
+		return defaultValue
+	}
+	return parsed
 }
 
 // Helper functions
@@ -565,10 +1241,30 @@ func connectToDatabase(cfg *Config) (*gorm.DB, error) {
 		cfg.Database.MaxOpenConnections, cfg.Database.MaxIdleConnections, connectionMaxLifetime)
 
 	// Автоматична міграція тільки для моделей, які мають GORM-структури
-	logrus.Info("🛠️  Running AutoMigrate for User and Friendship...")
+	logrus.Info("🛠️  Running AutoMigrate for application models...")
 	if err := db.AutoMigrate(
 		&services.User{},
 		&migrations.Friendship{},
+		&services.FriendRequest{},
+		&services.UserBlock{},
+		&services.FriendGroup{},
+		&services.FriendGroupMember{},
+		&services.Follow{},
+		&services.Notification{},
+		&services.Conversation{},
+		&services.Message{},
+		&services.EmailVerificationToken{},
+		&services.PersonalAccessToken{},
+		&services.Role{},
+		&services.Permission{},
+		&services.UserRole{},
+		&services.PasswordResetToken{},
+		&services.ImpersonationSession{},
+		&services.AuditLog{},
+		&services.UserSession{},
+		&services.OIDCState{},
+		&services.RevokedToken{},
+		&services.LoginAttempt{},
 	); err != nil {
 		return nil, fmt.Errorf("failed to migrate database: %w", err)
 	}
@@ -647,6 +1343,148 @@ func RunMigrations(cfg *Config) error {
 		logrus.Info("Unique constraint already exists, skipping...")
 	}
 
+	// Запити в друзі: односторонні дружби стають запитами, що очікують відповіді
+	if err := migrateTableIfNotExists(db, "friend_requests", &services.FriendRequest{}); err != nil {
+		return err
+	}
+	if err := migrations.AddFriendRequestsPendingPairIndex(db); err != nil {
+		return fmt.Errorf("failed to add friend requests pending pair index: %w", err)
+	}
+	if err := migrations.AddFriendRequestsListIndexes(db); err != nil {
+		return fmt.Errorf("failed to add friend requests list indexes: %w", err)
+	}
+	// Перетворення даних виконується один раз: повторний запуск видаляв би односторонні дружби, створені пізніше
+	converted, err := migrations.RunOnce(db, "20261018_convert_friendships_to_requests", migrations.ConvertFriendshipsToRequests)
+	if err != nil {
+		return fmt.Errorf("failed to convert friendships to requests: %w", err)
+	}
+	if converted {
+		logrus.Info("✅ One-way friendships converted to friend requests")
+	} else {
+		logrus.Info("Friendships already converted to friend requests, skipping...")
+	}
+	if err := migrateTableIfNotExists(db, "user_blocks", &services.UserBlock{}); err != nil {
+		return err
+	}
+	if err := migrateTableIfNotExists(db, "friend_groups", &services.FriendGroup{}); err != nil {
+		return err
+	}
+	if err := migrateTableIfNotExists(db, "friend_group_members", &services.FriendGroupMember{}); err != nil {
+		return err
+	}
+
+	// Підписки: таблиця follows та налаштування схвалення підписок у users
+	if err := migrateTableIfNotExists(db, "follows", &services.Follow{}); err != nil {
+		return err
+	}
+	if err := migrations.AddUsersFollowApprovalRequired(db); err != nil {
+		return fmt.Errorf("failed to add follow_approval_required column: %w", err)
+	}
+
+	// Присутність: last_seen_at та налаштування приховування статусу в users
+	if err := migrations.AddUsersPresence(db); err != nil {
+		return fmt.Errorf("failed to add presence columns: %w", err)
+	}
+
+	if err := migrateTableIfNotExists(db, "notifications", &services.Notification{}); err != nil {
+		return err
+	}
+	if err := migrations.AddNotificationsUnreadIndex(db); err != nil {
+		return fmt.Errorf("failed to add notifications unread index: %w", err)
+	}
+
+	// Особисті повідомлення: розмови, повідомлення та індекс непрочитаних
+	if err := migrateTableIfNotExists(db, "conversations", &services.Conversation{}); err != nil {
+		return err
+	}
+	if err := migrateTableIfNotExists(db, "messages", &services.Message{}); err != nil {
+		return err
+	}
+	if err := migrations.AddMessagesUnreadIndex(db); err != nil {
+		return fmt.Errorf("failed to add messages unread index: %w", err)
+	}
+
+	if err := migrateTableIfNotExists(db, "email_verification_tokens", &services.EmailVerificationToken{}); err != nil {
+		return err
+	}
+
+	// Додаємо колонку is_email_verified до users (існуючі користувачі вважаються підтвердженими).
+	// Колонку може вже створити AutoMigrate при старті сервера, тому заповнення виконується за маркером, а не за наявністю колонки
+	applied, err := migrations.RunOnce(db, "20261018_add_users_email_verified", migrations.AddUsersEmailVerified)
+	if err != nil {
+		return fmt.Errorf("failed to add is_email_verified column: %w", err)
+	}
+	if applied {
+		logrus.Info("✅ is_email_verified column added and existing users marked as verified")
+	} else {
+		logrus.Info("is_email_verified backfill already applied, skipping...")
+	}
+
+	if err := migrateTableIfNotExists(db, "personal_access_tokens", &services.PersonalAccessToken{}); err != nil {
+		return err
+	}
+
+	// Скидання пароля адміністратором: прапорець у users та таблиця токенів
+	if err := migrations.AddUsersPasswordResetRequired(db); err != nil {
+		return fmt.Errorf("failed to add password_reset_required column: %w", err)
+	}
+	if err := migrateTableIfNotExists(db, "password_reset_tokens", &services.PasswordResetToken{}); err != nil {
+		return err
+	}
+
+	if err := migrateTableIfNotExists(db, "impersonation_sessions", &services.ImpersonationSession{}); err != nil {
+		return err
+	}
+
+	// Сховища сесій для security.session.store = "postgres"
+	if err := migrateTableIfNotExists(db, "user_sessions", &services.UserSession{}); err != nil {
+		return err
+	}
+	if err := migrations.AdaptUserSessionsTable(db); err != nil {
+		return fmt.Errorf("failed to adapt user_sessions table: %w", err)
+	}
+	if err := migrateTableIfNotExists(db, "oidc_states", &services.OIDCState{}); err != nil {
+		return err
+	}
+	if err := migrateTableIfNotExists(db, "revoked_tokens", &services.RevokedToken{}); err != nil {
+		return err
+	}
+	if err := migrateTableIfNotExists(db, "login_attempts", &services.LoginAttempt{}); err != nil {
+		return err
+	}
+
+	// Журнал аудиту: записи лише додаються
+	if err := migrateTableIfNotExists(db, "audit_logs", &services.AuditLog{}); err != nil {
+		return err
+	}
+	if err := migrations.AddAuditLogsAppendOnly(db); err != nil {
+		return fmt.Errorf("failed to make audit_logs append-only: %w", err)
+	}
+
+	// Таблиці RBAC (roles, permissions, role_permissions, user_roles) та вбудовані ролі
+	if err := migrateTableIfNotExists(db, "permissions", &services.Permission{}); err != nil {
+		return err
+	}
+	if err := migrateTableIfNotExists(db, "roles", &services.Role{}); err != nil {
+		return err
+	}
+	if err := migrateTableIfNotExists(db, "user_roles", &services.UserRole{}); err != nil {
+		return err
+	}
+	if err := services.NewRBACService(db).SeedDefaultRoles(); err != nil {
+		return fmt.Errorf("failed to seed default roles: %w", err)
+	}
+
+	// Додаємо колонку tokens_valid_after для відкликання токенів після зміни облікових даних
+	if err := migrations.AddUsersTokensValidAfter(db); err != nil {
+		return fmt.Errorf("failed to add tokens_valid_after column: %w", err)
+	}
+
+	// Додаємо колонку privileges_changed_at для ротації cookie сесій після зміни ролей
+	if err := migrations.AddUsersPrivilegesChangedAt(db); err != nil {
+		return fmt.Errorf("failed to add privileges_changed_at column: %w", err)
+	}
+
 	logrus.Info("✅ Database migrations completed successfully")
 
 	// Закриваємо з'єднання
@@ -654,3 +1492,77 @@ func RunMigrations(cfg *Config) error {
 
 	return nil
 }
+
+// SeedRoles створює вбудовані ролі та призначає роль admin користувачам з вказаними email
+func SeedRoles(cfg *Config, adminEmails []string) error {
+	db, err := connectToDatabase(cfg)
+	if err != nil {
+		return err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get underlying sql.DB: %w", err)
+	}
+	defer sqlDB.Close()
+
+	rbacService := services.NewRBACService(db)
+	if err := rbacService.SeedDefaultRoles(); err != nil {
+		return err
+	}
+
+	for _, email := range adminEmails {
+		var user services.User
+		if err := db.Where("email = ?", email).First(&user).Error; err != nil {
+			return fmt.Errorf("failed to find user %s: %w", email, err)
+		}
+		if err := rbacService.AssignRole(user.ID, services.RoleAdmin); err != nil {
+			return err
+		}
+		logrus.WithField("email", email).Info("✅ Admin role assigned")
+	}
+
+	return nil
+}
+
+// PurgeAuditLog видаляє записи журналу аудиту, старші за retention
+func PurgeAuditLog(cfg *Config, retention string) (int64, error) {
+	period, err := time.ParseDuration(retention)
+	if err != nil || period <= 0 {
+		return 0, fmt.Errorf("invalid retention %q", retention)
+	}
+
+	db, err := connectToDatabase(cfg)
+	if err != nil {
+		return 0, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get underlying sql.DB: %w", err)
+	}
+	defer sqlDB.Close()
+
+	return services.NewAuditService(db, period, 0).PurgeOlderThan(time.Now().Add(-period))
+}
+
+// migrateTableIfNotExists створює таблицю для моделі, якщо вона ще не існує
+func migrateTableIfNotExists(db *gorm.DB, table string, model interface{}) error {
+	var exists bool
+	err := db.Raw("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_schema = 'public' AND table_name = ?)", table).Scan(&exists).Error
+	if err != nil {
+		return fmt.Errorf("failed to check if %s table exists: %w", table, err)
+	}
+
+	if exists {
+		logrus.Infof("%s table already exists, skipping...", table)
+		return nil
+	}
+
+	logrus.Infof("Creating %s table...", table)
+	if err := db.AutoMigrate(model); err != nil {
+		return fmt.Errorf("failed to create %s table: %w", table, err)
+	}
+	logrus.Infof("✅ %s table created successfully", table)
+	return nil
+}
@@ -0,0 +1,575 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-practice/internal/middleware"
+	"go-practice/internal/models"
+	"go-practice/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccountHandler містить handlers для керування обліковими даними поточного користувача
+type AccountHandler struct {
+	authService   services.AuthService
+	patService    services.PersonalAccessTokenService
+	impersonation services.ImpersonationService
+	audit         services.AuditService
+	cookies       *middleware.SessionCookies // nil, якщо cookie сесії вимкнені
+}
+
+// NewAccountHandler створює новий AccountHandler
+func NewAccountHandler(authService services.AuthService, patService services.PersonalAccessTokenService, impersonation services.ImpersonationService, audit services.AuditService, cookies *middleware.SessionCookies) *AccountHandler {
+	return &AccountHandler{
+		authService:   authService,
+		patService:    patService,
+		impersonation: impersonation,
+		audit:         audit,
+		cookies:       cookies,
+	}
+}
+
+// ChangePassword змінює пароль поточного користувача
+// @Summary Change Password
+// @Description Змінює пароль (або встановлює локальний пароль для OIDC акаунта). Всі інші сесії завершуються.
+// @Tags account
+// @Accept json
+// @Produce json
+// @Param changePasswordRequest body models.ChangePasswordRequest true "Passwords"
+// @Security BearerAuth
+// @Success 200 {object} models.Token
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /api/v1/account/password [put]
+func (h *AccountHandler) ChangePassword(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	var req models.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             "invalid_request",
+			"error_description": "Missing new password",
+		})
+		return
+	}
+
+	// Нова сесія отримує ті самі scopes, що й поточні облікові дані
+	scopes, _ := middleware.GetTokenScopes(c)
+	tokens, err := h.authService.ChangePassword(userID, req.CurrentPassword, req.NewPassword, scopes, clientInfo(c))
+	if err != nil {
+		if respondPasswordPolicyError(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrInvalidCurrentPassword) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":             "invalid_current_password",
+				"error_description": "Current password is incorrect",
+			})
+			return
+		}
+		middleware.Logger(c).WithError(err).Error("Failed to change password")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":             "server_error",
+			"error_description": "Failed to change password",
+		})
+		return
+	}
+
+	// Зміна пароля завершує всі сесії; браузер переходить на нову сесію
+	if h.cookies != nil && c.GetString("auth_method") == middleware.AuthMethodSessionCookie {
+		h.cookies.Set(c, tokens.SessionID)
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// ChangeEmail запускає зміну email поточного користувача
+// @Summary Change Email
+// @Description Відправляє лист підтвердження на нову адресу. Email змінюється після підтвердження, після чого всі сесії завершуються.
+// @Tags account
+// @Accept json
+// @Produce json
+// @Param changeEmailRequest body models.ChangeEmailRequest true "New email"
+// @Security BearerAuth
+// @Success 202 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/account/email [put]
+func (h *AccountHandler) ChangeEmail(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	var req models.ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             "invalid_request",
+			"error_description": "Missing or invalid email",
+		})
+		return
+	}
+
+	err := h.authService.ChangeEmail(userID, req.CurrentPassword, req.NewEmail, clientInfo(c))
+	switch {
+	case errors.Is(err, services.ErrInvalidCurrentPassword):
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":             "invalid_current_password",
+			"error_description": "Current password is incorrect",
+		})
+		return
+	case errors.Is(err, services.ErrEmailAlreadyInUse):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":             "email_in_use",
+			"error_description": "Email address is already used by another account",
+		})
+		return
+	case err != nil:
+		middleware.Logger(c).WithError(err).Error("Failed to change email")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":             "server_error",
+			"error_description": "Failed to start email change",
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Verification email sent to the new address",
+	})
+}
+
+// CreateToken створює personal access token
+// @Summary Create Personal Access Token
+// @Description Створює personal access token для скриптів. Значення токена показується лише один раз.
+// @Tags account
+// @Accept json
+// @Produce json
+// @Param createTokenRequest body models.CreatePersonalAccessTokenRequest true "Token parameters"
+// @Security BearerAuth
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/v1/account/tokens [post]
+func (h *AccountHandler) CreateToken(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	var req models.CreatePersonalAccessTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             "invalid_request",
+			"error_description": "Invalid token parameters",
+		})
+		return
+	}
+
+	ttl := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	pat, token, err := h.patService.CreateToken(userID, req.Name, req.Scopes, ttl)
+	metadata := map[string]interface{}{"name": req.Name, "scopes": req.Scopes}
+	if pat != nil {
+		metadata["token_id"] = pat.ID
+	}
+	recordAudit(c, h.audit, services.AuditActionTokenCreate, userID, err, metadata)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidScope) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":             "invalid_scope",
+				"error_description": err.Error(),
+				"allowed_scopes":    services.PersonalAccessTokenScopes,
+			})
+			return
+		}
+		middleware.Logger(c).WithError(err).Error("Failed to create personal access token")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":             "server_error",
+			"error_description": "Failed to create token",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"token":                 token,
+		"personal_access_token": pat,
+		"message":               "Store this token now, it will not be shown again",
+	})
+}
+
+// ListTokens повертає personal access tokens поточного користувача
+// @Summary List Personal Access Tokens
+// @Description Повертає активні токени з часом та IP останнього використання
+// @Tags account
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/account/tokens [get]
+func (h *AccountHandler) ListTokens(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	tokens, err := h.patService.ListTokens(userID)
+	if err != nil {
+		middleware.Logger(c).WithError(err).Error("Failed to list personal access tokens")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":             "server_error",
+			"error_description": "Failed to list tokens",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tokens": tokens,
+		"count":  len(tokens),
+	})
+}
+
+// RevokeToken відкликає personal access token
+// @Summary Revoke Personal Access Token
+// @Description Відкликає personal access token поточного користувача
+// @Tags account
+// @Produce json
+// @Param id path string true "Token ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/account/tokens/{id} [delete]
+func (h *AccountHandler) RevokeToken(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	tokenID := c.Param("id")
+	err := h.patService.RevokeToken(userID, tokenID)
+	recordAudit(c, h.audit, services.AuditActionTokenRevoke, userID, err, map[string]interface{}{"token_id": tokenID})
+	if err != nil {
+		if errors.Is(err, services.ErrPersonalAccessTokenNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":             "not_found",
+				"error_description": "Token not found",
+			})
+			return
+		}
+		middleware.Logger(c).WithError(err).Error("Failed to revoke personal access token")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":             "server_error",
+			"error_description": "Failed to revoke token",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Token revoked successfully",
+		"token_id": tokenID,
+	})
+}
+
+// ListImpersonations повертає історію імперсонацій акаунта поточного користувача
+// @Summary List Account Impersonations
+// @Description Показує, які адміністратори, коли і чому діяли від імені користувача
+// @Tags account
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/account/impersonations [get]
+func (h *AccountHandler) ListImpersonations(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	sessions, err := h.impersonation.ListForUser(userID)
+	if err != nil {
+		middleware.Logger(c).WithError(err).Error("Failed to list impersonations")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":             "server_error",
+			"error_description": "Failed to list impersonations",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"impersonations": sessions,
+		"count":          len(sessions),
+	})
+}
+
+// RevokeImpersonation дозволяє користувачу завершити імперсонацію свого акаунта
+// @Summary Revoke Account Impersonation
+// @Description Завершує активну імперсонацію акаунта поточного користувача
+// @Tags account
+// @Produce json
+// @Param id path string true "Impersonation ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/account/impersonations/{id} [delete]
+func (h *AccountHandler) RevokeImpersonation(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	err := h.impersonation.RevokeForUser(userID, c.Param("id"))
+	recordAudit(c, h.audit, services.AuditActionImpersonationRevoke, userID, err, map[string]interface{}{"impersonation_id": c.Param("id")})
+	if err != nil {
+		if errors.Is(err, services.ErrImpersonationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":             "not_found",
+				"error_description": "Impersonation not found",
+			})
+			return
+		}
+		middleware.Logger(c).WithError(err).Error("Failed to revoke impersonation")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":             "server_error",
+			"error_description": "Failed to revoke impersonation",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "Impersonation revoked",
+		"impersonation_id": c.Param("id"),
+	})
+}
+
+// ListSessions повертає активні сесії поточного користувача
+// @Summary List Sessions
+// @Description Повертає активні сесії з часом створення та останнього використання, IP, пристроєм і позначкою поточної сесії
+// @Tags account
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/account/sessions [get]
+func (h *AccountHandler) ListSessions(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	sessions, err := h.authService.ListSessions(userID)
+	if err != nil {
+		middleware.Logger(c).WithError(err).Error("Failed to list sessions")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":             "server_error",
+			"error_description": "Failed to list sessions",
+		})
+		return
+	}
+
+	currentSessionID, _ := middleware.GetSessionID(c)
+	result := make([]models.SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		info := models.SessionInfo{
+			ID:         session.SessionID,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			ExpiresAt:  session.ExpiresAt,
+			ExpiresIn:  int64(time.Until(session.Deadline()).Seconds()),
+			IPAddress:  session.IPAddress,
+			UserAgent:  session.UserAgent,
+			Device:     services.ParseUserAgent(session.UserAgent),
+			Current:    session.SessionID == currentSessionID,
+		}
+		if !session.IdleExpiresAt.IsZero() {
+			idleExpiresAt := session.IdleExpiresAt
+			info.IdleExpiresAt = &idleExpiresAt
+		}
+		result = append(result, info)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sessions": result,
+		"count":    len(result),
+	})
+}
+
+// RevokeSession завершує одну сесію поточного користувача
+// @Summary Revoke Session
+// @Description Завершує сесію; access та refresh токени, видані в ній, одразу стають недійсними
+// @Tags account
+// @Produce json
+// @Param id path string true "Session ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/account/sessions/{id} [delete]
+func (h *AccountHandler) RevokeSession(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	sessionID := c.Param("id")
+	if err := h.authService.RevokeSession(userID, sessionID, clientInfo(c)); err != nil {
+		if errors.Is(err, services.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":             "not_found",
+				"error_description": "Session not found",
+			})
+			return
+		}
+		middleware.Logger(c).WithError(err).Error("Failed to revoke session")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":             "server_error",
+			"error_description": "Failed to revoke session",
+		})
+		return
+	}
+
+	currentSessionID, _ := middleware.GetSessionID(c)
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Session revoked",
+		"session_id": sessionID,
+		"current":    sessionID == currentSessionID,
+	})
+}
+
+// RevokeOtherSessions завершує всі сесії поточного користувача, крім поточної
+// @Summary Revoke Other Sessions
+// @Description Завершує всі інші сесії; сесія, з якої виконано запит, залишається активною
+// @Tags account
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/account/sessions [delete]
+func (h *AccountHandler) RevokeOtherSessions(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	currentSessionID, _ := middleware.GetSessionID(c)
+	revoked, err := h.authService.RevokeOtherSessions(userID, currentSessionID, clientInfo(c))
+	if err != nil {
+		middleware.Logger(c).WithError(err).Error("Failed to revoke sessions")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":             "server_error",
+			"error_description": "Failed to revoke sessions",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "Other sessions revoked",
+		"revoked_sessions": revoked,
+	})
+}
+
+// SecurityHistory повертає події безпеки акаунта поточного користувача
+// @Summary Security History
+// @Description Повертає входи, зміни облікових даних та інші події безпеки акаунта від новіших до старіших
+// @Tags account
+// @Produce json
+// @Param cursor query int false "Курсор з попередньої відповіді"
+// @Param limit query int false "Кількість (за замовчуванням 50, максимум 200)"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/v1/account/security-events [get]
+func (h *AccountHandler) SecurityHistory(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	filter := services.AuditFilter{SubjectID: userID}
+	if value := c.Query("cursor"); value != "" {
+		cursor, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":             "invalid_request",
+				"error_description": "Invalid cursor",
+			})
+			return
+		}
+		filter.Cursor = cursor
+	}
+	filter.Limit, _ = strconv.Atoi(c.Query("limit"))
+
+	logs, nextCursor, err := h.audit.Query(filter)
+	if err != nil {
+		middleware.Logger(c).WithError(err).Error("Failed to query security history")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":             "server_error",
+			"error_description": "Failed to load security history",
+		})
+		return
+	}
+
+	// Користувачу показуємо лише що, коли і звідки сталося, без внутрішніх метаданих
+	events := make([]gin.H, 0, len(logs))
+	for _, entry := range logs {
+		events = append(events, gin.H{
+			"action":     entry.Action,
+			"outcome":    entry.Outcome,
+			"ip_address": entry.IPAddress,
+			"device":     services.ParseUserAgent(entry.UserAgent),
+			"created_at": entry.CreatedAt,
+		})
+	}
+
+	response := gin.H{
+		"events": events,
+		"count":  len(events),
+	}
+	if nextCursor > 0 {
+		response["next_cursor"] = strconv.FormatUint(nextCursor, 10)
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,635 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"go-practice/internal/middleware"
+	"go-practice/internal/models"
+	"go-practice/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler містить handlers для адміністративних endpoints
+type AdminHandler struct {
+	userService         services.UserService
+	loginAttemptService services.LoginAttemptService
+	rbacService         services.RBACService
+	userAdminService    services.UserAdminService
+	impersonation       services.ImpersonationService
+	audit               services.AuditService
+}
+
+// NewAdminHandler створює новий AdminHandler
+func NewAdminHandler(userService services.UserService, loginAttemptService services.LoginAttemptService, rbacService services.RBACService, userAdminService services.UserAdminService, impersonation services.ImpersonationService, audit services.AuditService) *AdminHandler {
+	return &AdminHandler{
+		userService:         userService,
+		loginAttemptService: loginAttemptService,
+		rbacService:         rbacService,
+		userAdminService:    userAdminService,
+		impersonation:       impersonation,
+		audit:               audit,
+	}
+}
+
+// ListUsers повертає список користувачів з фільтрами
+// @Summary List Users (admin)
+// @Description Повертає користувачів, включно з неактивними, з фільтрами та пагінацією
+// @Tags admin
+// @Produce json
+// @Param active query bool false "Фільтр за активністю"
+// @Param created_after query string false "RFC3339 дата, не раніше"
+// @Param created_before query string false "RFC3339 дата, раніше ніж"
+// @Param email_domain query string false "Домен email, наприклад example.com"
+// @Param limit query int false "Кількість (за замовчуванням 50, максимум 200)"
+// @Param offset query int false "Зсув"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/v1/admin/users [get]
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	var filter services.UserListFilter
+
+	if value := c.Query("active"); value != "" {
+		active, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid active filter"})
+			return
+		}
+		filter.IsActive = &active
+	}
+	for param, target := range map[string]**time.Time{
+		"created_after":  &filter.CreatedAfter,
+		"created_before": &filter.CreatedBefore,
+	} {
+		if value := c.Query(param); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param + ", expected RFC3339"})
+				return
+			}
+			*target = &parsed
+		}
+	}
+	filter.EmailDomain = c.Query("email_domain")
+	filter.Limit, _ = strconv.Atoi(c.Query("limit"))
+	filter.Offset, _ = strconv.Atoi(c.Query("offset"))
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	users, total, err := h.userAdminService.ListUsers(filter)
+	if err != nil {
+		middleware.Logger(c).WithError(err).Error("Failed to list users")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users":  users,
+		"count":  len(users),
+		"total":  total,
+		"offset": filter.Offset,
+	})
+}
+
+// GetUser повертає користувача за ID
+// @Summary Get User (admin)
+// @Description Повертає користувача (включно з неактивними) та його ролі
+// @Tags admin
+// @Produce json
+// @Param id path string true "User ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/admin/users/{id} [get]
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	user, err := h.userAdminService.GetUser(c.Param("id"))
+	if err != nil {
+		h.respondUserAdminError(c, err)
+		return
+	}
+
+	roles, err := h.rbacService.GetUserRoles(user.ID)
+	if err != nil {
+		middleware.Logger(c).WithError(err).Error("Failed to get user roles")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user roles"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":  user,
+		"roles": roles,
+	})
+}
+
+// UpdateUser змінює поля користувача
+// @Summary Update User (admin)
+// @Description Змінює name, email, picture або is_email_verified користувача
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param updateRequest body models.AdminUpdateUserRequest true "Fields to update"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/admin/users/{id} [patch]
+func (h *AdminHandler) UpdateUser(c *gin.Context) {
+	var req models.AdminUpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.Email != nil {
+		updates["email"] = *req.Email
+	}
+	if req.Picture != nil {
+		updates["picture"] = *req.Picture
+	}
+	if req.IsEmailVerified != nil {
+		updates["is_email_verified"] = *req.IsEmailVerified
+	}
+
+	adminID, _ := middleware.GetCurrentUserID(c)
+	user, err := h.userAdminService.UpdateUser(adminID, c.Param("id"), updates)
+	fields := make([]string, 0, len(updates))
+	for field := range updates {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	recordAudit(c, h.audit, services.AuditActionUserUpdate, c.Param("id"), err, map[string]interface{}{"fields": fields})
+	if err != nil {
+		h.respondUserAdminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User updated successfully",
+		"user":    user,
+	})
+}
+
+// DeactivateUser деактивує користувача
+// @Summary Deactivate User (admin)
+// @Description Деактивує користувача та завершує всі його сесії
+// @Tags admin
+// @Produce json
+// @Param id path string true "User ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/admin/users/{id}/deactivate [post]
+func (h *AdminHandler) DeactivateUser(c *gin.Context) {
+	adminID, _ := middleware.GetCurrentUserID(c)
+	err := h.userAdminService.DeactivateUser(adminID, c.Param("id"))
+	recordAudit(c, h.audit, services.AuditActionUserDeactivate, c.Param("id"), err, nil)
+	if err != nil {
+		h.respondUserAdminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User deactivated",
+		"user_id": c.Param("id"),
+	})
+}
+
+// ReactivateUser повторно активує користувача
+// @Summary Reactivate User (admin)
+// @Description Повторно активує деактивованого користувача
+// @Tags admin
+// @Produce json
+// @Param id path string true "User ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/admin/users/{id}/reactivate [post]
+func (h *AdminHandler) ReactivateUser(c *gin.Context) {
+	adminID, _ := middleware.GetCurrentUserID(c)
+	err := h.userAdminService.ReactivateUser(adminID, c.Param("id"))
+	recordAudit(c, h.audit, services.AuditActionUserReactivate, c.Param("id"), err, nil)
+	if err != nil {
+		h.respondUserAdminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User reactivated",
+		"user_id": c.Param("id"),
+	})
+}
+
+// ForceLogout завершує всі сесії користувача
+// @Summary Force Logout (admin)
+// @Description Завершує всі сесії користувача та відкликає видані токени
+// @Tags admin
+// @Produce json
+// @Param id path string true "User ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/admin/users/{id}/logout [post]
+func (h *AdminHandler) ForceLogout(c *gin.Context) {
+	adminID, _ := middleware.GetCurrentUserID(c)
+	err := h.userAdminService.ForceLogout(adminID, c.Param("id"))
+	recordAudit(c, h.audit, services.AuditActionUserLogout, c.Param("id"), err, nil)
+	if err != nil {
+		h.respondUserAdminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User sessions revoked",
+		"user_id": c.Param("id"),
+	})
+}
+
+// ForcePasswordReset вимагає від користувача скинути пароль
+// @Summary Force Password Reset (admin)
+// @Description Блокує вхід зі старим паролем, завершує сесії і відправляє посилання для скидання
+// @Tags admin
+// @Produce json
+// @Param id path string true "User ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/admin/users/{id}/password-reset [post]
+func (h *AdminHandler) ForcePasswordReset(c *gin.Context) {
+	adminID, _ := middleware.GetCurrentUserID(c)
+	err := h.userAdminService.ForcePasswordReset(adminID, c.Param("id"))
+	recordAudit(c, h.audit, services.AuditActionUserPasswordReset, c.Param("id"), err, nil)
+	if err != nil {
+		h.respondUserAdminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Password reset required, reset link sent",
+		"user_id": c.Param("id"),
+	})
+}
+
+// DeleteUser остаточно видаляє користувача
+// @Summary Delete User (admin)
+// @Description Остаточно видаляє користувача разом з дружбами, ролями та токенами
+// @Tags admin
+// @Produce json
+// @Param id path string true "User ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/admin/users/{id} [delete]
+func (h *AdminHandler) DeleteUser(c *gin.Context) {
+	adminID, _ := middleware.GetCurrentUserID(c)
+	err := h.userAdminService.HardDeleteUser(adminID, c.Param("id"))
+	recordAudit(c, h.audit, services.AuditActionUserDelete, c.Param("id"), err, nil)
+	if err != nil {
+		h.respondUserAdminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User deleted permanently",
+		"user_id": c.Param("id"),
+	})
+}
+
+// UnlockUser знімає блокування входу з акаунта користувача
+// @Summary Unlock User
+// @Description Знімає тимчасове блокування входу після невдалих спроб
+// @Tags admin
+// @Produce json
+// @Param id path string true "User ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/admin/users/{id}/unlock [post]
+func (h *AdminHandler) UnlockUser(c *gin.Context) {
+	id := c.Param("id")
+
+	user, err := h.userService.GetUserByID(id)
+	if err != nil {
+		middleware.Logger(c).WithError(err).WithField("user_id", id).Error("Failed to get user for unlock")
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "User not found",
+		})
+		return
+	}
+
+	h.loginAttemptService.Unlock(user.Email)
+	recordAudit(c, h.audit, services.AuditActionUserUnlock, user.ID, nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User account unlocked",
+		"user_id": user.ID,
+	})
+}
+
+// AssignRole призначає роль користувачу
+// @Summary Assign Role
+// @Description Призначає роль користувачу
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param assignRoleRequest body models.AssignRoleRequest true "Role"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/admin/users/{id}/roles [post]
+func (h *AdminHandler) AssignRole(c *gin.Context) {
+	id := c.Param("id")
+
+	var req models.AssignRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Missing role",
+		})
+		return
+	}
+
+	user, err := h.userService.GetUserByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "User not found",
+		})
+		return
+	}
+
+	err = h.rbacService.AssignRole(user.ID, req.Role)
+	recordAudit(c, h.audit, services.AuditActionRoleAssign, user.ID, err, map[string]interface{}{"role": req.Role})
+	if err != nil {
+		h.respondRoleError(c, err)
+		return
+	}
+
+	h.respondUserRoles(c, user.ID)
+}
+
+// RemoveRole забирає роль у користувача
+// @Summary Remove Role
+// @Description Забирає роль у користувача (неявну роль user забрати неможливо)
+// @Tags admin
+// @Produce json
+// @Param id path string true "User ID"
+// @Param role path string true "Role name"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/admin/users/{id}/roles/{role} [delete]
+func (h *AdminHandler) RemoveRole(c *gin.Context) {
+	id := c.Param("id")
+	role := c.Param("role")
+
+	user, err := h.userService.GetUserByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "User not found",
+		})
+		return
+	}
+
+	err = h.rbacService.RemoveRole(user.ID, role)
+	recordAudit(c, h.audit, services.AuditActionRoleRemove, user.ID, err, map[string]interface{}{"role": role})
+	if err != nil {
+		h.respondRoleError(c, err)
+		return
+	}
+
+	h.respondUserRoles(c, user.ID)
+}
+
+// respondRoleError відповідає на помилку зміни ролей
+func (h *AdminHandler) respondRoleError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrRoleNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Role not found",
+		})
+		return
+	}
+
+	middleware.Logger(c).WithError(err).Error("Failed to update user roles")
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error": "Failed to update user roles",
+	})
+}
+
+// respondUserRoles повертає актуальні ролі користувача
+func (h *AdminHandler) respondUserRoles(c *gin.Context, userID string) {
+	roles, err := h.rbacService.GetUserRoles(userID)
+	if err != nil {
+		middleware.Logger(c).WithError(err).Error("Failed to get user roles")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user roles",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user_id": userID,
+		"roles":   roles,
+	})
+}
+
+// Impersonate видає адміністратору короткоживучий токен від імені користувача
+// @Summary Impersonate User (admin)
+// @Description Видає access token користувача з claim act (адміністратор), дійсний 15 хвилин. Адміністраторів імперсонувати заборонено.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param impersonateRequest body models.ImpersonateRequest true "Reason"
+// @Security BearerAuth
+// @Success 201 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/admin/users/{id}/impersonate [post]
+func (h *AdminHandler) Impersonate(c *gin.Context) {
+	var req models.ImpersonateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A reason (5-500 characters) is required"})
+		return
+	}
+
+	admin, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	session, token, err := h.impersonation.Start(admin, c.Param("id"), req.Reason, clientInfo(c))
+	metadata := map[string]interface{}{"reason": req.Reason}
+	if session != nil {
+		metadata["impersonation_id"] = session.ID
+	}
+	recordAudit(c, h.audit, services.AuditActionImpersonationStart, c.Param("id"), err, metadata)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrCannotImpersonateAdmin):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		default:
+			middleware.Logger(c).WithError(err).Error("Failed to start impersonation")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start impersonation"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"impersonation": session,
+		"token":         token,
+	})
+}
+
+// ListImpersonations повертає сесії імперсонації
+// @Summary List Impersonations (admin)
+// @Description Повертає сесії імперсонації (active=true — лише діючі)
+// @Tags admin
+// @Produce json
+// @Param active query bool false "Лише діючі сесії"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/admin/impersonations [get]
+func (h *AdminHandler) ListImpersonations(c *gin.Context) {
+	activeOnly, _ := strconv.ParseBool(c.Query("active"))
+
+	sessions, err := h.impersonation.ListSessions(activeOnly)
+	if err != nil {
+		middleware.Logger(c).WithError(err).Error("Failed to list impersonations")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list impersonations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"impersonations": sessions,
+		"count":          len(sessions),
+	})
+}
+
+// RevokeImpersonation завершує сесію імперсонації
+// @Summary Revoke Impersonation (admin)
+// @Description Відкликає сесію імперсонації; виданий токен перестає діяти
+// @Tags admin
+// @Produce json
+// @Param id path string true "Impersonation ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/admin/impersonations/{id} [delete]
+func (h *AdminHandler) RevokeImpersonation(c *gin.Context) {
+	adminID, _ := middleware.GetCurrentUserID(c)
+	err := h.impersonation.Revoke(c.Param("id"), adminID)
+	recordAudit(c, h.audit, services.AuditActionImpersonationRevoke, "", err, map[string]interface{}{"impersonation_id": c.Param("id")})
+	if err != nil {
+		if errors.Is(err, services.ErrImpersonationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Impersonation not found"})
+			return
+		}
+		middleware.Logger(c).WithError(err).Error("Failed to revoke impersonation")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke impersonation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "Impersonation revoked",
+		"impersonation_id": c.Param("id"),
+	})
+}
+
+// ListAuditLogs повертає журнал аудиту з фільтрами та курсорною пагінацією
+// @Summary List Audit Logs (admin)
+// @Description Повертає записи журналу аудиту від новіших до старіших. Для наступної сторінки передайте next_cursor як cursor.
+// @Tags admin
+// @Produce json
+// @Param action query string false "Дія, наприклад auth.login"
+// @Param actor_id query string false "Хто виконав дію"
+// @Param subject_id query string false "Над ким виконано дію"
+// @Param outcome query string false "success або failure"
+// @Param since query string false "RFC3339 дата, не раніше"
+// @Param until query string false "RFC3339 дата, раніше ніж"
+// @Param cursor query int false "Курсор з попередньої відповіді"
+// @Param limit query int false "Кількість (за замовчуванням 50, максимум 200)"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/v1/admin/audit [get]
+func (h *AdminHandler) ListAuditLogs(c *gin.Context) {
+	filter := services.AuditFilter{
+		Action:    c.Query("action"),
+		ActorID:   c.Query("actor_id"),
+		SubjectID: c.Query("subject_id"),
+		Outcome:   c.Query("outcome"),
+	}
+
+	for param, target := range map[string]**time.Time{
+		"since": &filter.Since,
+		"until": &filter.Until,
+	} {
+		if value := c.Query(param); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param + ", expected RFC3339"})
+				return
+			}
+			*target = &parsed
+		}
+	}
+	if value := c.Query("cursor"); value != "" {
+		cursor, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		filter.Cursor = cursor
+	}
+	filter.Limit, _ = strconv.Atoi(c.Query("limit"))
+
+	logs, nextCursor, err := h.audit.Query(filter)
+	if err != nil {
+		middleware.Logger(c).WithError(err).Error("Failed to query audit log")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query audit log"})
+		return
+	}
+
+	response := gin.H{
+		"entries": logs,
+		"count":   len(logs),
+	}
+	if nextCursor > 0 {
+		response["next_cursor"] = strconv.FormatUint(nextCursor, 10)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// respondUserAdminError відповідає на помилку адміністрування користувача
+func (h *AdminHandler) respondUserAdminError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case errors.Is(err, services.ErrFieldNotEditable):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrCannotModifySelf):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrEmailAlreadyInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": "Email address is already in use"})
+	default:
+		middleware.Logger(c).WithError(err).Error("Admin user operation failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Admin operation failed"})
+	}
+}
@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
-	"strings"
+	"sort"
+	"strconv"
+	"time"
 
 	"go-practice/internal/middleware"
 	"go-practice/internal/services"
@@ -13,145 +16,82 @@ import (
 
 // APIHandler містить handlers для API endpoints
 type APIHandler struct {
-	userService services.UserService
+	userService  services.UserService
+	rbacService  services.RBACService
+	groupService services.FriendGroupService
+	presence     services.PresenceService
+	audit        services.AuditService
 }
 
 // NewAPIHandler створює новий APIHandler
-func NewAPIHandler(userService services.UserService) *APIHandler {
+func NewAPIHandler(userService services.UserService, rbacService services.RBACService, groupService services.FriendGroupService, presence services.PresenceService, audit services.AuditService) *APIHandler {
 	return &APIHandler{
-		userService: userService,
+		userService:  userService,
+		rbacService:  rbacService,
+		groupService: groupService,
+		presence:     presence,
+		audit:        audit,
 	}
 }
 
-// AddFriend додає користувача в друзі
-// @Summary Add Friend
-// @Description Додає користувача в друзі
-// @Tags api
-// @Accept json
-// @Produce json
-// @Param friend_id body string true "ID користувача, якого додаємо в друзі"
-// @Security BearerAuth
-// @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 409 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
-// @Router /api/v1/friends/add [post]
-func (h *APIHandler) AddFriend(c *gin.Context) {
-	logrus.Info("AddFriend handler called - маршрут працює!")
-
-	userID, ok := middleware.GetCurrentUserID(c)
-	if !ok {
-		logrus.Error("Failed to get user ID from context in AddFriend")
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get user ID from context",
-		})
-		return
-	}
-
-	currentUserID, err := h.userService.GetIDByUserID(userID)
-	if err != nil {
-		logrus.WithError(err).Error("Failed to get user ID from UserService")
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get user ID from UserService",
-		})
-		return
-	}
-
-	var req struct {
-		FriendID string `json:"friend_id"`
-	}
-	if err := c.ShouldBindJSON(&req); err != nil || req.FriendID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid or missing friend_id",
-		})
-		return
-	}
-
-	trimmedCurrentUserID := strings.TrimSpace(currentUserID)
-
-	if after, ok := strings.CutPrefix(trimmedCurrentUserID, "usr_"); ok {
-		trimmedCurrentUserID = after
-	}
-
-	rawID := strings.TrimSpace(req.FriendID)
-
-	// Якщо є префікс "usr_", видаляємо його
-	if after, ok := strings.CutPrefix(rawID, "usr_"); ok {
-		rawID = after
-	}
-
-	// Не можна додати себе в друзі
-	if rawID == trimmedCurrentUserID {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Cannot add yourself as a friend",
-		})
-		return
-	}
-
-	// Перевіряємо чи вже є в друзях
-	isFriend, err := h.userService.AreFriends(trimmedCurrentUserID, rawID)
-	if err != nil {
-		logrus.WithError(err).Error("Failed to check friendship")
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to check friendship",
-		})
-		return
-	}
-	if isFriend {
-		c.JSON(http.StatusConflict, gin.H{
-			"error": "User is already your friend",
-		})
-		return
-	}
-
-	// Додаємо в друзі
-	err = h.userService.AddFriend(trimmedCurrentUserID, rawID)
-	if err != nil {
-		logrus.WithError(err).Error("Failed to add friend")
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to add friend",
-		})
-		return
-	}
-
-	logrus.WithFields(logrus.Fields{
-		"user_id":   trimmedCurrentUserID,
-		"friend_id": rawID,
-	}).Info("Friend added successfully")
-
-	c.JSON(http.StatusOK, gin.H{
-		"message":   "Friend added successfully",
-		"friend_id": rawID,
-	})
+// friendWithPresence друг з його присутністю; online та last_seen_at порожні, якщо друг приховав статус
+type friendWithPresence struct {
+	services.User
+	Online     *bool      `json:"online"`
+	LastSeenAt *time.Time `json:"last_seen_at"`
 }
 
 // GetFriends повертає список друзів поточного користувача
 // @Summary Get Friends
-// @Description Повертає список друзів поточного користувача
+// @Description Повертає список друзів поточного користувача з їх присутністю (online, last_seen_at);
+// @Description group_id або group (назва) обмежують список учасниками групи
 // @Tags api
 // @Produce json
+// @Param group_id query int false "ID групи друзів"
+// @Param group query string false "Назва групи друзів"
 // @Security BearerAuth
 // @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /api/v1/friends [get]
 func (h *APIHandler) GetFriends(c *gin.Context) {
 	userID, ok := middleware.GetCurrentUserID(c)
 	if !ok {
-		logrus.Error("Failed to get user ID from context in GetFriends")
+		middleware.Logger(c).Error("Failed to get user ID from context in GetFriends")
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to get user ID from context",
 		})
 		return
 	}
 
-	trimmedUserID := strings.TrimSpace(userID)
-	if after, ok := strings.CutPrefix(trimmedUserID, "usr_"); ok {
-		trimmedUserID = after
+	// ID і назва групи передаються окремими параметрами: назва групи може складатися з цифр
+	var friends []services.User
+	var err error
+	if rawGroupID := c.Query("group_id"); rawGroupID != "" {
+		groupID, parseErr := strconv.ParseUint(rawGroupID, 10, 64)
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid group_id",
+			})
+			return
+		}
+		friends, err = h.groupService.GetMembers(userID, groupID)
+	} else if name := c.Query("group"); name != "" {
+		var group *services.FriendGroup
+		if group, err = h.groupService.GetGroupByName(userID, name); err == nil {
+			friends, err = h.groupService.GetMembers(userID, group.ID)
+		}
+	} else {
+		friends, err = h.userService.GetFriends(userID)
+	}
+	if errors.Is(err, services.ErrFriendGroupNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Friend group not found",
+		})
+		return
 	}
-
-	friends, err := h.userService.GetFriends(trimmedUserID)
 	if err != nil {
-		logrus.WithError(err).WithField("user_id", trimmedUserID).Error("Failed to get friends")
+		middleware.Logger(c).WithError(err).WithField("user_id", userID).Error("Failed to get friends")
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to retrieve friends",
 			"details": err.Error(),
@@ -159,9 +99,19 @@ func (h *APIHandler) GetFriends(c *gin.Context) {
 		return
 	}
 
+	presence := h.presence.Presence(friends)
+	data := make([]friendWithPresence, len(friends))
+	for i, friend := range friends {
+		data[i] = friendWithPresence{User: friend}
+		if p := presence[friend.ID]; !p.Hidden {
+			data[i].Online = &p.Online
+			data[i].LastSeenAt = p.LastSeenAt
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Friends retrieved successfully",
-		"data":    friends,
+		"data":    data,
 	})
 }
 
@@ -192,14 +142,14 @@ func (h *APIHandler) ProtectedData(c *gin.Context) {
 	// Отримуємо поточного користувача з контексту (додається middleware)
 	user, exists := middleware.GetCurrentUser(c)
 	if !exists {
-		logrus.Error("Failed to get user from context in ProtectedData")
+		middleware.Logger(c).Error("Failed to get user from context in ProtectedData")
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to get user context",
 		})
 		return
 	}
 
-	logrus.WithFields(logrus.Fields{
+	middleware.Logger(c).WithFields(logrus.Fields{
 		"user_id": user.ID,
 		"email":   user.Email,
 	}).Info("User accessed protected data")
@@ -229,7 +179,7 @@ func (h *APIHandler) UserProfile(c *gin.Context) {
 	// Отримуємо user ID з контексту
 	userID, exists := middleware.GetCurrentUserID(c)
 	if !exists {
-		logrus.Error("Failed to get user ID from context in UserProfile")
+		middleware.Logger(c).Error("Failed to get user ID from context in UserProfile")
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to get user ID from context",
 		})
@@ -239,7 +189,7 @@ func (h *APIHandler) UserProfile(c *gin.Context) {
 	// Отримуємо повний профіль користувача через UserService
 	profile, err := h.userService.GetProfile(userID)
 	if err != nil {
-		logrus.WithError(err).WithField("user_id", userID).Error("Failed to get user profile")
+		middleware.Logger(c).WithError(err).WithField("user_id", userID).Error("Failed to get user profile")
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to retrieve user profile",
 			"details": err.Error(),
@@ -247,7 +197,7 @@ func (h *APIHandler) UserProfile(c *gin.Context) {
 		return
 	}
 
-	logrus.WithField("user_id", userID).Info("User profile retrieved successfully")
+	middleware.Logger(c).WithField("user_id", userID).Info("User profile retrieved successfully")
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "User profile data",
@@ -279,7 +229,7 @@ func (h *APIHandler) UpdateProfile(c *gin.Context) {
 
 	var updateData map[string]interface{}
 	if err := c.ShouldBindJSON(&updateData); err != nil {
-		logrus.WithError(err).Error("Invalid update profile request")
+		middleware.Logger(c).WithError(err).Error("Invalid update profile request")
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Invalid request data",
 			"details": err.Error(),
@@ -309,8 +259,14 @@ func (h *APIHandler) UpdateProfile(c *gin.Context) {
 	}
 
 	err := h.userService.UpdateUser(userID, filteredUpdates)
+	fields := make([]string, 0, len(filteredUpdates))
+	for field := range filteredUpdates {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	recordAudit(c, h.audit, services.AuditActionProfileUpdate, userID, err, map[string]interface{}{"fields": fields})
 	if err != nil {
-		logrus.WithError(err).WithField("user_id", userID).Error("Failed to update user profile")
+		middleware.Logger(c).WithError(err).WithField("user_id", userID).Error("Failed to update user profile")
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to update profile",
 			"details": err.Error(),
@@ -318,7 +274,7 @@ func (h *APIHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	logrus.WithFields(logrus.Fields{
+	middleware.Logger(c).WithFields(logrus.Fields{
 		"user_id": userID,
 		"updates": filteredUpdates,
 	}).Info("User profile updated successfully")
@@ -360,9 +316,10 @@ func (h *APIHandler) SearchUsers(c *gin.Context) {
 		return
 	}
 
-	users, err := h.userService.SearchUsers(query)
+	viewerID, _ := middleware.GetCurrentUserID(c)
+	users, err := h.userService.SearchUsers(viewerID, query)
 	if err != nil {
-		logrus.WithError(err).WithField("query", query).Error("Failed to search users")
+		middleware.Logger(c).WithError(err).WithField("query", query).Error("Failed to search users")
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to search users",
 			"details": err.Error(),
@@ -396,9 +353,11 @@ func (h *APIHandler) GetUserByID(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.GetUserByID(id)
+	// Користувачі, заблоковані поточним, для нього не існують
+	viewerID, _ := middleware.GetCurrentUserID(c)
+	user, err := h.userService.GetVisibleUser(viewerID, id)
 	if err != nil {
-		logrus.WithError(err).WithField("user_id", id).Error("Failed to get user by ID")
+		middleware.Logger(c).WithError(err).WithField("user_id", id).Error("Failed to get user by ID")
 		c.JSON(http.StatusNotFound, gin.H{
 			"error":   "User not found",
 			"details": err.Error(),
@@ -416,7 +375,7 @@ func (h *APIHandler) GetUserByID(c *gin.Context) {
 func (h *APIHandler) Users(c *gin.Context) {
 	users, err := h.userService.GetAllUsers()
 	if err != nil {
-		logrus.WithError(err).Error("Failed to get users")
+		middleware.Logger(c).WithError(err).Error("Failed to get users")
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to retrieve users",
 			"details": err.Error(),
@@ -448,6 +407,24 @@ func (h *APIHandler) UserData(c *gin.Context) {
 		return
 	}
 
+	roles, err := h.rbacService.GetUserRoles(user.ID)
+	if err != nil {
+		middleware.Logger(c).WithError(err).Error("Failed to get user roles")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user roles",
+		})
+		return
+	}
+
+	permissions, err := h.rbacService.GetUserPermissions(user.ID)
+	if err != nil {
+		middleware.Logger(c).WithError(err).Error("Failed to get user permissions")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user permissions",
+		})
+		return
+	}
+
 	// Приклад розширених даних (можна додати статистику, налаштування тощо)
 	userData := gin.H{
 		"user": gin.H{
@@ -463,7 +440,8 @@ func (h *APIHandler) UserData(c *gin.Context) {
 			"login_count": 0, // TODO: implement login tracking
 			"last_login":  nil,
 			"preferences": gin.H{},
-			"permissions": []string{"read", "write"}, // TODO: implement RBAC
+			"roles":       roles,
+			"permissions": permissions,
 		},
 	}
 
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"go-practice/internal/middleware"
+	"go-practice/internal/models"
+	"go-practice/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// clientInfo збирає дані про клієнта поточного запиту
+func clientInfo(c *gin.Context) models.ClientInfo {
+	impersonatorID, _ := middleware.GetImpersonatorID(c)
+	return models.ClientInfo{
+		IPAddress:      c.ClientIP(),
+		UserAgent:      c.Request.UserAgent(),
+		RequestID:      middleware.GetRequestID(c),
+		ImpersonatorID: impersonatorID,
+	}
+}
+
+// recordAudit записує дію автентифікованого користувача в журнал аудиту.
+// Результат визначається за err; при імперсонації автором дії фіксується адміністратор (див. AuditService.Record).
+func recordAudit(c *gin.Context, audit services.AuditService, action, subjectID string, err error, metadata map[string]interface{}) {
+	actorID, _ := middleware.GetCurrentUserID(c)
+
+	outcome := services.AuditOutcomeSuccess
+	if err != nil {
+		outcome = services.AuditOutcomeFailure
+		if metadata == nil {
+			metadata = map[string]interface{}{}
+		}
+		metadata["error"] = err.Error()
+	}
+
+	audit.Record(services.AuditEntry{
+		Action:    action,
+		ActorID:   actorID,
+		SubjectID: subjectID,
+		Outcome:   outcome,
+		Client:    clientInfo(c),
+		Metadata:  metadata,
+	})
+}
@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"go-practice/internal/middleware"
 	"go-practice/internal/models"
 	"go-practice/internal/services"
 	"net/http"
@@ -14,13 +16,15 @@ import (
 type AuthHandler struct {
 	authService        services.AuthService
 	postLogoutRedirect string
+	cookies            *middleware.SessionCookies // nil, якщо cookie сесії вимкнені
 }
 
 // NewAuthHandler створює новий AuthHandler
-func NewAuthHandler(authService services.AuthService, postLogoutRedirect string) *AuthHandler {
+func NewAuthHandler(authService services.AuthService, postLogoutRedirect string, cookies *middleware.SessionCookies) *AuthHandler {
 	return &AuthHandler{
 		authService:        authService,
 		postLogoutRedirect: postLogoutRedirect,
+		cookies:            cookies,
 	}
 }
 
@@ -38,7 +42,25 @@ func (h *AuthHandler) DefaultLogin(c *gin.Context) {
 		return
 	}
 
-	response, err := h.authService.DefaultLogin(&req)
+	response, err := h.authService.DefaultLogin(&req, clientInfo(c))
+	if errors.Is(err, services.ErrInvalidScope) {
+		respondInvalidScope(c, err)
+		return
+	}
+	if errors.Is(err, services.ErrEmailNotVerified) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":             "email_not_verified",
+			"error_description": "Email address must be verified before login",
+		})
+		return
+	}
+	if errors.Is(err, services.ErrPasswordResetRequired) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":             "password_reset_required",
+			"error_description": "A password reset is required, use the link sent to your email",
+		})
+		return
+	}
 	if err != nil {
 		logrus.WithError(err).Error("Failed to login user")
 		c.JSON(http.StatusUnauthorized, gin.H{
@@ -48,6 +70,12 @@ func (h *AuthHandler) DefaultLogin(c *gin.Context) {
 		return
 	}
 
+	// У режимі cookie сесій токени не потрапляють у JavaScript; браузер автентифікується cookie
+	if h.cookies != nil {
+		h.cookies.Set(c, response.SessionID)
+		response.AccessToken = ""
+	}
+
 	logrus.WithField("user_id", response.UserID).Info("User logged in successfully")
 	c.JSON(http.StatusOK, response)
 }
@@ -59,6 +87,7 @@ func (h *AuthHandler) DefaultLogin(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param redirect_uri query string false "Redirect URI"
+// @Param scope query string false "API scopes через пробіл"
 // @Success 200 {object} models.OIDCLoginResponse
 // @Failure 500 {object} map[string]interface{}
 // @Router /auth/login [post]
@@ -70,7 +99,11 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		redirectURI = "http://localhost:8080/auth/callback"
 	}
 
-	response, err := h.authService.Login(redirectURI)
+	response, err := h.authService.Login(redirectURI, c.Query("scope"))
+	if errors.Is(err, services.ErrInvalidScope) {
+		respondInvalidScope(c, err)
+		return
+	}
 	if err != nil {
 		logrus.WithError(err).Error("Failed to initiate OIDC login")
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -135,7 +168,14 @@ func (h *AuthHandler) Callback(c *gin.Context) {
 	}
 
 	// Використовуємо AuthService для обробки callback
-	tokens, user, err := h.authService.HandleCallback(code, state)
+	tokens, user, err := h.authService.HandleCallback(code, state, clientInfo(c))
+	if errors.Is(err, services.ErrEmailNotVerified) || errors.Is(err, services.ErrProviderEmailNotVerified) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":             "email_not_verified",
+			"error_description": "Email address must be verified by the identity provider before login",
+		})
+		return
+	}
 	if err != nil {
 		logrus.WithError(err).Error("Failed to handle OIDC callback")
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -151,6 +191,13 @@ func (h *AuthHandler) Callback(c *gin.Context) {
 		"user_id": user.ID,
 	}).Info("OIDC callback processed successfully")
 
+	// У режимі cookie сесій токени не потрапляють в URL; браузер автентифікується cookie
+	if h.cookies != nil {
+		h.cookies.Set(c, tokens.SessionID)
+		c.Redirect(http.StatusSeeOther, h.postLogoutRedirect)
+		return
+	}
+
 	// Редіректимо клієнта у React додаток з обома токенами
 	redirectURL := fmt.Sprintf("%s?access_token=%s&refresh_token=%s",
 		h.postLogoutRedirect, tokens.AccessToken, tokens.RefreshToken)
@@ -175,20 +222,33 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 	idTokenHint := c.Query("id_token_hint")
 	postLogoutRedirectURI := c.Query("post_logout_redirect_uri")
 
-	var userID string
-	if authHeader != "" && len(authHeader) > 7 && authHeader[:7] == "Bearer " {
-		token := authHeader[7:]
-		// Можна додати метод в AuthService для отримання userID з токена, якщо потрібно
-		user, err := h.authService.GetUserInfo(token)
-		if err == nil {
-			userID = user.ID
+	var userID, sessionID string
+	if h.cookies != nil && authHeader == "" {
+		// Браузерна сесія: вихід змінює стан, тому вимагає CSRF токен
+		if id, present, err := h.cookies.SessionID(c); present && err == nil {
+			if !h.cookies.ValidCSRF(c, id) {
+				c.JSON(http.StatusForbidden, gin.H{
+					"error":             "csrf_failed",
+					"error_description": "Missing or invalid " + middleware.CSRFHeader + " header",
+				})
+				return
+			}
+			if session, user, err := h.authService.AuthenticateSession(id, clientInfo(c)); err == nil {
+				userID, sessionID = user.ID, session.SessionID
+			}
+		}
+		h.cookies.Clear(c)
+	} else if authHeader != "" && len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+		// Сесія визначається за claim sid токена і завершується разом з усіма її токенами
+		if err := h.authService.LogoutByToken(authHeader[7:], clientInfo(c)); err != nil {
+			logrus.WithError(err).Warn("Logout with invalid access token")
 		}
 	} else if idTokenHint != "" {
 		// Якщо потрібно, додати метод для парсингу id_token_hint
 	}
 
 	if userID != "" {
-		_ = h.authService.Logout(userID)
+		_ = h.authService.Logout(userID, sessionID, clientInfo(c))
 		logrus.WithField("user_id", userID).Info("User logged out successfully")
 	}
 
@@ -225,7 +285,11 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 		return
 	}
 
-	tokens, err := h.authService.RefreshToken(req.RefreshToken)
+	tokens, err := h.authService.RefreshToken(req.RefreshToken, req.Scope, clientInfo(c))
+	if errors.Is(err, services.ErrInvalidScope) {
+		respondInvalidScope(c, err)
+		return
+	}
 	if err != nil {
 		logrus.WithError(err).Error("Failed to refresh token")
 		c.JSON(http.StatusUnauthorized, gin.H{
@@ -277,7 +341,7 @@ func (h *AuthHandler) UserInfo(c *gin.Context) {
 		"email":          user.Email,
 		"name":           user.Name,
 		"picture":        user.Picture,
-		"email_verified": true,
+		"email_verified": user.EmailVerified,
 	}
 
 	c.JSON(http.StatusOK, userInfo)
@@ -291,7 +355,7 @@ func (h *AuthHandler) UserInfo(c *gin.Context) {
 // @Produce json
 // @Param registerRequest body models.RegisterRequest true "Registration Data"
 // @Success 201 {object} models.RegisterResponse
-// @Failure 400 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{} "Invalid request or password policy violation"
 // @Failure 409 {object} map[string]interface{}
 // @Router /auth/register [post]
 func (h *AuthHandler) Register(c *gin.Context) {
@@ -312,7 +376,10 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		"name":  req.Name,
 	}).Info("Processing user registration")
 
-	response, err := h.authService.Register(&req)
+	response, err := h.authService.Register(&req, clientInfo(c))
+	if respondPasswordPolicyError(c, err) {
+		return
+	}
 	if err != nil {
 		logrus.WithError(err).Error("Failed to register user")
 		c.JSON(http.StatusConflict, gin.H{
@@ -325,3 +392,159 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	logrus.WithField("user_id", response.UserID).Info("User registered successfully")
 	c.JSON(http.StatusCreated, response)
 }
+
+// VerifyEmail підтверджує email користувача за токеном з листа
+// @Summary Verify Email
+// @Description Підтверджує email адресу користувача за токеном з листа
+// @Tags auth
+// @Produce json
+// @Param token query string true "Verification Token"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /auth/verify-email [get]
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	logrus.Info("✉️ Email verification request")
+
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             "invalid_request",
+			"error_description": "Missing verification token",
+		})
+		return
+	}
+
+	user, err := h.authService.VerifyEmail(token, clientInfo(c))
+	if errors.Is(err, services.ErrEmailAlreadyInUse) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":             "email_in_use",
+			"error_description": "Email address is already used by another account",
+		})
+		return
+	}
+	if errors.Is(err, services.ErrVerificationTokenExpired) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             "expired_token",
+			"error_description": "Verification link has expired, request a new one",
+		})
+		return
+	}
+	if err != nil {
+		logrus.WithError(err).Error("Failed to verify email")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             "invalid_token",
+			"error_description": "Invalid or already used verification link",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Email verified successfully",
+		"user_id":        user.ID,
+		"email":          user.Email,
+		"email_verified": user.EmailVerified,
+	})
+}
+
+// ResendVerification повторно відправляє лист підтвердження email
+// @Summary Resend Verification Email
+// @Description Повторно відправляє лист підтвердження (відповідь не розкриває чи існує акаунт)
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param resendRequest body models.ResendVerificationRequest true "Email"
+// @Success 202 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /auth/verify-email/resend [post]
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	logrus.Info("✉️ Resend verification request")
+
+	var req models.ResendVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             "invalid_request",
+			"error_description": "Missing or invalid email",
+		})
+		return
+	}
+
+	if err := h.authService.ResendVerification(req.Email); err != nil {
+		logrus.WithError(err).Error("Failed to resend verification email")
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "If the account exists and is not verified, a verification email has been sent",
+	})
+}
+
+// ResetPassword встановлює новий пароль за токеном з листа
+// @Summary Reset Password
+// @Description Встановлює новий пароль за токеном скидання та завершує всі сесії користувача
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param resetPasswordRequest body models.ResetPasswordRequest true "Token and new password"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /auth/password/reset [post]
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	logrus.Info("🔑 Password reset request")
+
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             "invalid_request",
+			"error_description": "Missing token or new password",
+		})
+		return
+	}
+
+	err := h.authService.ResetPassword(req.Token, req.NewPassword, clientInfo(c))
+	if err != nil {
+		if respondPasswordPolicyError(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrInvalidPasswordResetToken) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":             "invalid_token",
+				"error_description": "Invalid or expired password reset link",
+			})
+			return
+		}
+		logrus.WithError(err).Error("Failed to reset password")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":             "server_error",
+			"error_description": "Failed to reset password",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Password has been reset, please log in with the new password",
+	})
+}
+
+// respondPasswordPolicyError відповідає 400 зі списком порушень, якщо err є порушенням парольної політики
+func respondPasswordPolicyError(c *gin.Context, err error) bool {
+	var policyErr *services.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":             "password_policy_violation",
+		"error_description": "Password does not satisfy the password policy",
+		"violations":        policyErr.Violations,
+	})
+	return true
+}
+
+// respondInvalidScope відповідає 400 invalid_scope зі списком доступних scopes
+func respondInvalidScope(c *gin.Context, err error) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":             "invalid_scope",
+		"error_description": err.Error(),
+		"allowed_scopes":    services.APIScopes,
+	})
+}
@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"go-practice/internal/middleware"
+	"go-practice/internal/models"
+	"go-practice/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FollowHandler містить handlers для підписок на користувачів та налаштувань приватності
+type FollowHandler struct {
+	userService   services.UserService
+	notifications services.NotificationService
+	presence      services.PresenceService
+	audit         services.AuditService
+}
+
+// NewFollowHandler створює новий FollowHandler
+func NewFollowHandler(userService services.UserService, notifications services.NotificationService, presence services.PresenceService, audit services.AuditService) *FollowHandler {
+	return &FollowHandler{
+		userService:   userService,
+		notifications: notifications,
+		presence:      presence,
+		audit:         audit,
+	}
+}
+
+// Follow підписує поточного користувача на іншого користувача
+// @Summary Follow User
+// @Description Підписується на користувача; якщо він вимагає схвалення, підписка очікує його відповіді
+// @Tags follows
+// @Produce json
+// @Param id path string true "User ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/users/{id}/follow [post]
+func (h *FollowHandler) Follow(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	followeeID := normalizeUserID(c.Param("id"))
+	follow, created, err := h.userService.Follow(userID, followeeID)
+	recordAudit(c, h.audit, services.AuditActionFollow, followeeID, err, nil)
+	if err != nil {
+		h.respondError(c, err, "Failed to follow user")
+		return
+	}
+
+	message, notificationType := "User followed", services.NotificationFollow
+	if follow.Status == services.FollowPending {
+		message, notificationType = "Follow request sent", services.NotificationFollowRequest
+	}
+	if created {
+		notify(c, h.notifications, followeeID, notificationType, userID, nil)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"follow":  follow,
+	})
+}
+
+// Unfollow скасовує підписку або запит на підписку
+// @Summary Unfollow User
+// @Description Скасовує підписку на користувача або запит на підписку, що очікує схвалення
+// @Tags follows
+// @Produce json
+// @Param id path string true "User ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/users/{id}/follow [delete]
+func (h *FollowHandler) Unfollow(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	followeeID := normalizeUserID(c.Param("id"))
+	err := h.userService.Unfollow(userID, followeeID)
+	recordAudit(c, h.audit, services.AuditActionUnfollow, followeeID, err, nil)
+	if err != nil {
+		h.respondError(c, err, "Failed to unfollow user")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "User unfollowed",
+		"followee_id": followeeID,
+	})
+}
+
+// ListFollowers повертає підписників поточного користувача
+// @Summary List Followers
+// @Description Повертає схвалених підписників з пагінацією
+// @Tags follows
+// @Produce json
+// @Param limit query int false "Кількість (за замовчуванням 20, максимум 100)"
+// @Param offset query int false "Зсув"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/follows/followers [get]
+func (h *FollowHandler) ListFollowers(c *gin.Context) {
+	h.listUsers(c, h.userService.GetFollowers, "Failed to list followers")
+}
+
+// ListFollowing повертає користувачів, на яких підписаний поточний користувач
+// @Summary List Following
+// @Description Повертає схвалені підписки з пагінацією
+// @Tags follows
+// @Produce json
+// @Param limit query int false "Кількість (за замовчуванням 20, максимум 100)"
+// @Param offset query int false "Зсув"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/follows/following [get]
+func (h *FollowHandler) ListFollowing(c *gin.Context) {
+	h.listUsers(c, h.userService.GetFollowing, "Failed to list following")
+}
+
+// ListRequests повертає запити на підписку, що очікують схвалення поточного користувача
+// @Summary List Follow Requests
+// @Description Повертає запити на підписку зі статусом pending
+// @Tags follows
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/follows/requests [get]
+func (h *FollowHandler) ListRequests(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	requests, err := h.userService.GetFollowRequests(userID)
+	if err != nil {
+		h.respondError(c, err, "Failed to list follow requests")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"requests": requests,
+		"count":    len(requests),
+	})
+}
+
+// ApproveRequest схвалює запит на підписку
+// @Summary Approve Follow Request
+// @Description Схвалює запит користувача на підписку
+// @Tags follows
+// @Produce json
+// @Param id path string true "Follower user ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/follows/requests/{id}/approve [post]
+func (h *FollowHandler) ApproveRequest(c *gin.Context) {
+	approve := func(userID, followerID string) error {
+		err := h.userService.ApproveFollow(userID, followerID)
+		if err == nil {
+			notify(c, h.notifications, followerID, services.NotificationFollowApproved, userID, nil)
+		}
+		return err
+	}
+	h.updateRequest(c, approve, services.AuditActionFollowApprove, "Follow request approved")
+}
+
+// DeclineRequest відхиляє запит на підписку
+// @Summary Decline Follow Request
+// @Description Відхиляє запит користувача на підписку
+// @Tags follows
+// @Produce json
+// @Param id path string true "Follower user ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/follows/requests/{id}/decline [post]
+func (h *FollowHandler) DeclineRequest(c *gin.Context) {
+	h.updateRequest(c, h.userService.DeclineFollow, services.AuditActionFollowDecline, "Follow request declined")
+}
+
+// UpdatePrivacy змінює налаштування приватності поточного користувача
+// @Summary Update Privacy Settings
+// @Description Вмикає або вимикає схвалення нових підписок (після вимкнення запити, що очікують, схвалюються)
+// @Description та приховування статусу онлайн і last_seen_at від друзів. Передаються лише поля, які потрібно змінити.
+// @Tags follows
+// @Accept json
+// @Produce json
+// @Param settings body models.PrivacySettings true "Налаштування приватності"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/v1/profile/privacy [put]
+func (h *FollowHandler) UpdatePrivacy(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	var req models.PrivacySettings
+	if err := c.ShouldBindJSON(&req); err != nil || (req.FollowApprovalRequired == nil && req.HidePresence == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             "invalid_request",
+			"error_description": "Invalid or missing follow_approval_required or hide_presence",
+		})
+		return
+	}
+
+	metadata := map[string]interface{}{}
+	if req.FollowApprovalRequired != nil {
+		metadata["follow_approval_required"] = *req.FollowApprovalRequired
+	}
+	if req.HidePresence != nil {
+		metadata["hide_presence"] = *req.HidePresence
+	}
+
+	err := h.userService.UpdatePrivacy(userID, req)
+	recordAudit(c, h.audit, services.AuditActionPrivacyUpdate, userID, err, metadata)
+	if err != nil {
+		h.respondError(c, err, "Failed to update privacy settings")
+		return
+	}
+
+	// Друзі одразу бачать, що статус приховано або знову показано
+	if req.HidePresence != nil {
+		if err := h.presence.Announce(userID); err != nil {
+			middleware.Logger(c).WithError(err).Warn("Failed to announce presence after privacy update")
+		}
+	}
+
+	profile, err := h.userService.GetProfile(userID)
+	if err != nil {
+		h.respondError(c, err, "Failed to get profile")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Privacy settings updated",
+		"profile": profile,
+	})
+}
+
+// listUsers повертає сторінку користувачів для поточного користувача
+func (h *FollowHandler) listUsers(c *gin.Context, list func(userID string, limit, offset int) ([]services.User, int64, error), message string) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	limit, offset := pagination(c)
+	users, total, err := list(userID, limit, offset)
+	if err != nil {
+		h.respondError(c, err, message)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   users,
+		"count":  len(users),
+		"total":  total,
+		"offset": offset,
+	})
+}
+
+// updateRequest відповідає на запит на підписку від користувача з path параметра id і фіксує дію в журналі аудиту
+func (h *FollowHandler) updateRequest(c *gin.Context, update func(userID, followerID string) error, action, message string) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	followerID := normalizeUserID(c.Param("id"))
+	err := update(userID, followerID)
+	recordAudit(c, h.audit, action, followerID, err, nil)
+	if err != nil {
+		h.respondError(c, err, message)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     message,
+		"follower_id": followerID,
+	})
+}
+
+// respondError перетворює помилки підписок на HTTP відповіді
+func (h *FollowHandler) respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrCannotFollowSelf):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             "invalid_request",
+			"error_description": "Cannot follow yourself",
+		})
+	case errors.Is(err, services.ErrFollowBlocked):
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":             "forbidden",
+			"error_description": "Cannot follow this user",
+		})
+	case errors.Is(err, services.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":             "not_found",
+			"error_description": "User not found",
+		})
+	case errors.Is(err, services.ErrFollowNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":             "not_found",
+			"error_description": "Follow not found",
+		})
+	default:
+		middleware.Logger(c).WithError(err).Error(message)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":             "server_error",
+			"error_description": message,
+		})
+	}
+}
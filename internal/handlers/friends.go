@@ -0,0 +1,822 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-practice/internal/middleware"
+	"go-practice/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FriendHandler містить handlers для запитів у друзі, видалення друзів, блокування та груп друзів
+type FriendHandler struct {
+	friendService services.FriendService
+	groupService  services.FriendGroupService
+	notifications services.NotificationService
+	audit         services.AuditService
+}
+
+// NewFriendHandler створює новий FriendHandler
+func NewFriendHandler(friendService services.FriendService, groupService services.FriendGroupService, notifications services.NotificationService, audit services.AuditService) *FriendHandler {
+	return &FriendHandler{
+		friendService: friendService,
+		groupService:  groupService,
+		notifications: notifications,
+		audit:         audit,
+	}
+}
+
+// SendRequest надсилає запит у друзі
+// @Summary Send Friend Request
+// @Description Надсилає запит у друзі; дружба створюється лише після прийняття. Зустрічний запит приймається одразу.
+// @Tags friends
+// @Accept json
+// @Produce json
+// @Param friend_id body string true "ID користувача, якому надсилається запит"
+// @Security BearerAuth
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/friends/requests [post]
+func (h *FriendHandler) SendRequest(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	var req struct {
+		FriendID string `json:"friend_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             "invalid_request",
+			"error_description": "Invalid or missing friend_id",
+		})
+		return
+	}
+
+	friendID := normalizeUserID(req.FriendID)
+	request, err := h.friendService.SendRequest(userID, friendID)
+	recordAudit(c, h.audit, services.AuditActionFriendRequestSend, friendID, err, nil)
+	if err != nil {
+		h.respondError(c, err, "Failed to send friend request")
+		return
+	}
+
+	if request.Status == services.FriendRequestAccepted {
+		notify(c, h.notifications, friendID, services.NotificationFriendRequestAccepted, userID,
+			map[string]interface{}{"request_id": request.ID})
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Friend request accepted",
+			"request": request,
+		})
+		return
+	}
+
+	notify(c, h.notifications, friendID, services.NotificationFriendRequest, userID,
+		map[string]interface{}{"request_id": request.ID})
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Friend request sent",
+		"request": request,
+	})
+}
+
+// ListIncoming повертає запити в друзі, що очікують відповіді поточного користувача
+// @Summary List Incoming Friend Requests
+// @Description Повертає вхідні запити в друзі зі статусом pending
+// @Tags friends
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/friends/requests/incoming [get]
+func (h *FriendHandler) ListIncoming(c *gin.Context) {
+	h.listRequests(c, h.friendService.ListIncoming)
+}
+
+// ListOutgoing повертає надіслані поточним користувачем запити, на які ще не відповіли
+// @Summary List Outgoing Friend Requests
+// @Description Повертає вихідні запити в друзі зі статусом pending
+// @Tags friends
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/friends/requests/outgoing [get]
+func (h *FriendHandler) ListOutgoing(c *gin.Context) {
+	h.listRequests(c, h.friendService.ListOutgoing)
+}
+
+// AcceptRequest приймає вхідний запит у друзі
+// @Summary Accept Friend Request
+// @Description Приймає вхідний запит; користувачі стають друзями в обох напрямках
+// @Tags friends
+// @Produce json
+// @Param id path int true "Friend request ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/friends/requests/{id}/accept [post]
+func (h *FriendHandler) AcceptRequest(c *gin.Context) {
+	accept := func(userID string, requestID uint64) (*services.FriendRequest, error) {
+		request, err := h.friendService.Accept(userID, requestID)
+		if err == nil {
+			notify(c, h.notifications, request.SenderID, services.NotificationFriendRequestAccepted, userID,
+				map[string]interface{}{"request_id": request.ID})
+		}
+		return request, err
+	}
+	h.updateRequest(c, accept, services.AuditActionFriendRequestAccept, "Friend request accepted")
+}
+
+// DeclineRequest відхиляє вхідний запит у друзі
+// @Summary Decline Friend Request
+// @Description Відхиляє вхідний запит у друзі
+// @Tags friends
+// @Produce json
+// @Param id path int true "Friend request ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/friends/requests/{id}/decline [post]
+func (h *FriendHandler) DeclineRequest(c *gin.Context) {
+	h.updateRequest(c, h.friendService.Decline, services.AuditActionFriendRequestDecline, "Friend request declined")
+}
+
+// CancelRequest скасовує надісланий поточним користувачем запит
+// @Summary Cancel Friend Request
+// @Description Скасовує власний запит у друзі, на який ще не відповіли
+// @Tags friends
+// @Produce json
+// @Param id path int true "Friend request ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/friends/requests/{id}/cancel [post]
+func (h *FriendHandler) CancelRequest(c *gin.Context) {
+	h.updateRequest(c, h.friendService.Cancel, services.AuditActionFriendRequestCancel, "Friend request cancelled")
+}
+
+// RemoveFriend видаляє користувача з друзів в обох напрямках
+// @Summary Remove Friend
+// @Description Видаляє дружбу з користувачем для обох сторін
+// @Tags friends
+// @Produce json
+// @Param id path string true "Friend user ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/friends/{id} [delete]
+func (h *FriendHandler) RemoveFriend(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	friendID := normalizeUserID(c.Param("id"))
+	err := h.friendService.RemoveFriend(userID, friendID)
+	recordAudit(c, h.audit, services.AuditActionFriendRemove, friendID, err, nil)
+	if err != nil {
+		h.respondError(c, err, "Failed to remove friend")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Friend removed",
+		"friend_id": friendID,
+	})
+}
+
+// BlockUser блокує користувача
+// @Summary Block User
+// @Description Блокує користувача: дружба видаляється, запити закриваються, заблокований не може надсилати нові запити
+// @Tags friends
+// @Accept json
+// @Produce json
+// @Param user_id body string true "ID користувача, якого блокуємо"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/blocks [post]
+func (h *FriendHandler) BlockUser(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	var req struct {
+		UserID string `json:"user_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             "invalid_request",
+			"error_description": "Invalid or missing user_id",
+		})
+		return
+	}
+
+	blockedID := normalizeUserID(req.UserID)
+	block, err := h.friendService.Block(userID, blockedID)
+	recordAudit(c, h.audit, services.AuditActionUserBlock, blockedID, err, nil)
+	if err != nil {
+		h.respondError(c, err, "Failed to block user")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User blocked",
+		"block":   block,
+	})
+}
+
+// UnblockUser знімає блокування з користувача
+// @Summary Unblock User
+// @Description Знімає блокування; видалена при блокуванні дружба не відновлюється
+// @Tags friends
+// @Produce json
+// @Param id path string true "Blocked user ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/blocks/{id} [delete]
+func (h *FriendHandler) UnblockUser(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	blockedID := normalizeUserID(c.Param("id"))
+	err := h.friendService.Unblock(userID, blockedID)
+	recordAudit(c, h.audit, services.AuditActionUserUnblock, blockedID, err, nil)
+	if err != nil {
+		h.respondError(c, err, "Failed to unblock user")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User unblocked",
+		"user_id": blockedID,
+	})
+}
+
+// ListBlocked повертає користувачів, заблокованих поточним користувачем
+// @Summary List Blocked Users
+// @Description Повертає список заблокованих користувачів від новіших до старіших
+// @Tags friends
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/blocks [get]
+func (h *FriendHandler) ListBlocked(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	blocks, err := h.friendService.ListBlocked(userID)
+	if err != nil {
+		middleware.Logger(c).WithError(err).Error("Failed to list blocked users")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":             "server_error",
+			"error_description": "Failed to list blocked users",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"blocks": blocks,
+		"count":  len(blocks),
+	})
+}
+
+// MutualFriends повертає спільних друзів поточного користувача та іншого користувача
+// @Summary Mutual Friends
+// @Description Повертає спільних друзів з пагінацією
+// @Tags friends
+// @Produce json
+// @Param id path string true "User ID"
+// @Param limit query int false "Кількість (за замовчуванням 20, максимум 100)"
+// @Param offset query int false "Зсув"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/friends/mutual/{id} [get]
+func (h *FriendHandler) MutualFriends(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	limit, offset := pagination(c)
+	users, total, err := h.friendService.MutualFriends(userID, normalizeUserID(c.Param("id")), limit, offset)
+	if err != nil {
+		h.respondError(c, err, "Failed to get mutual friends")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   users,
+		"count":  len(users),
+		"total":  total,
+		"offset": offset,
+	})
+}
+
+// Suggestions повертає людей, яких поточний користувач може знати
+// @Summary Friend Suggestions
+// @Description Друзі друзів, упорядковані за кількістю спільних друзів; без друзів, активних запитів та блокувань
+// @Tags friends
+// @Produce json
+// @Param limit query int false "Кількість (за замовчуванням 20, максимум 100)"
+// @Param offset query int false "Зсув"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/friends/suggestions [get]
+func (h *FriendHandler) Suggestions(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	limit, offset := pagination(c)
+	suggestions, total, err := h.friendService.Suggestions(userID, limit, offset)
+	if err != nil {
+		h.respondError(c, err, "Failed to get friend suggestions")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   suggestions,
+		"count":  len(suggestions),
+		"total":  total,
+		"offset": offset,
+	})
+}
+
+// ListGroups повертає групи друзів поточного користувача
+// @Summary List Friend Groups
+// @Description Повертає групи друзів з кількістю учасників
+// @Tags friends
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/friends/groups [get]
+func (h *FriendHandler) ListGroups(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	groups, err := h.groupService.ListGroups(userID)
+	if err != nil {
+		h.respondError(c, err, "Failed to list friend groups")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"groups": groups,
+		"count":  len(groups),
+	})
+}
+
+// CreateGroup створює групу друзів
+// @Summary Create Friend Group
+// @Description Створює іменовану групу друзів, наприклад "Сім'я" або "Робота"
+// @Tags friends
+// @Accept json
+// @Produce json
+// @Param name body string true "Назва групи (до 64 символів)"
+// @Security BearerAuth
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/friends/groups [post]
+func (h *FriendHandler) CreateGroup(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             "invalid_request",
+			"error_description": "Invalid or missing name",
+		})
+		return
+	}
+
+	group, err := h.groupService.CreateGroup(userID, req.Name)
+	if err != nil {
+		h.respondError(c, err, "Failed to create friend group")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Friend group created",
+		"group":   group,
+	})
+}
+
+// GetGroup повертає групу друзів разом з учасниками
+// @Summary Get Friend Group
+// @Description Повертає групу друзів та її учасників
+// @Tags friends
+// @Produce json
+// @Param id path int true "Group ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/friends/groups/{id} [get]
+func (h *FriendHandler) GetGroup(c *gin.Context) {
+	userID, groupID, ok := h.groupParams(c)
+	if !ok {
+		return
+	}
+
+	group, err := h.groupService.GetGroup(userID, groupID)
+	if err != nil {
+		h.respondError(c, err, "Failed to get friend group")
+		return
+	}
+	members, err := h.groupService.GetMembers(userID, groupID)
+	if err != nil {
+		h.respondError(c, err, "Failed to get friend group")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"group":   group,
+		"members": members,
+	})
+}
+
+// RenameGroup змінює назву групи друзів
+// @Summary Rename Friend Group
+// @Description Змінює назву групи друзів
+// @Tags friends
+// @Accept json
+// @Produce json
+// @Param id path int true "Group ID"
+// @Param name body string true "Нова назва групи"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/friends/groups/{id} [patch]
+func (h *FriendHandler) RenameGroup(c *gin.Context) {
+	userID, groupID, ok := h.groupParams(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             "invalid_request",
+			"error_description": "Invalid or missing name",
+		})
+		return
+	}
+
+	group, err := h.groupService.RenameGroup(userID, groupID, req.Name)
+	if err != nil {
+		h.respondError(c, err, "Failed to rename friend group")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Friend group updated",
+		"group":   group,
+	})
+}
+
+// DeleteGroup видаляє групу друзів; дружби при цьому зберігаються
+// @Summary Delete Friend Group
+// @Description Видаляє групу друзів; самі друзі залишаються
+// @Tags friends
+// @Produce json
+// @Param id path int true "Group ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/friends/groups/{id} [delete]
+func (h *FriendHandler) DeleteGroup(c *gin.Context) {
+	userID, groupID, ok := h.groupParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.groupService.DeleteGroup(userID, groupID); err != nil {
+		h.respondError(c, err, "Failed to delete friend group")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Friend group deleted",
+		"group_id": groupID,
+	})
+}
+
+// AddGroupMember додає друга до групи
+// @Summary Add Friend Group Member
+// @Description Додає до групи користувача, який уже є другом
+// @Tags friends
+// @Accept json
+// @Produce json
+// @Param id path int true "Group ID"
+// @Param friend_id body string true "ID друга"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/friends/groups/{id}/members [post]
+func (h *FriendHandler) AddGroupMember(c *gin.Context) {
+	userID, groupID, ok := h.groupParams(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		FriendID string `json:"friend_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             "invalid_request",
+			"error_description": "Invalid or missing friend_id",
+		})
+		return
+	}
+
+	friendID := normalizeUserID(req.FriendID)
+	if err := h.groupService.AddMember(userID, groupID, friendID); err != nil {
+		h.respondError(c, err, "Failed to add friend group member")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Friend added to group",
+		"group_id":  groupID,
+		"friend_id": friendID,
+	})
+}
+
+// RemoveGroupMember видаляє друга з групи
+// @Summary Remove Friend Group Member
+// @Description Видаляє друга з групи; дружба зберігається
+// @Tags friends
+// @Produce json
+// @Param id path int true "Group ID"
+// @Param friend_id path string true "Friend user ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/friends/groups/{id}/members/{friend_id} [delete]
+func (h *FriendHandler) RemoveGroupMember(c *gin.Context) {
+	userID, groupID, ok := h.groupParams(c)
+	if !ok {
+		return
+	}
+
+	friendID := normalizeUserID(c.Param("friend_id"))
+	if err := h.groupService.RemoveMember(userID, groupID, friendID); err != nil {
+		h.respondError(c, err, "Failed to remove friend group member")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Friend removed from group",
+		"group_id":  groupID,
+		"friend_id": friendID,
+	})
+}
+
+// groupParams повертає поточного користувача та ID групи з path; при помилці відповідь уже надіслано
+func (h *FriendHandler) groupParams(c *gin.Context) (userID string, groupID uint64, ok bool) {
+	userID, ok = middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return "", 0, false
+	}
+
+	groupID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		h.respondError(c, services.ErrFriendGroupNotFound, "")
+		return "", 0, false
+	}
+	return userID, groupID, true
+}
+
+// listRequests повертає список запитів поточного користувача
+func (h *FriendHandler) listRequests(c *gin.Context, list func(userID string) ([]services.FriendRequest, error)) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	requests, err := list(userID)
+	if err != nil {
+		middleware.Logger(c).WithError(err).Error("Failed to list friend requests")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":             "server_error",
+			"error_description": "Failed to list friend requests",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"requests": requests,
+		"count":    len(requests),
+	})
+}
+
+// updateRequest змінює статус запиту з path параметра id і фіксує дію в журналі аудиту
+func (h *FriendHandler) updateRequest(c *gin.Context, update func(userID string, requestID uint64) (*services.FriendRequest, error), action, message string) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	requestID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		h.respondError(c, services.ErrFriendRequestNotFound, "")
+		return
+	}
+
+	request, err := update(userID, requestID)
+	subjectID := ""
+	if request != nil {
+		subjectID = request.SenderID
+		if subjectID == userID {
+			subjectID = request.ReceiverID
+		}
+	}
+	recordAudit(c, h.audit, action, subjectID, err, map[string]interface{}{"request_id": requestID})
+	if err != nil {
+		h.respondError(c, err, "Failed to update friend request")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"request": request,
+	})
+}
+
+// respondError перетворює помилки FriendService на HTTP відповіді
+func (h *FriendHandler) respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrFriendRequestSelf):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             "invalid_request",
+			"error_description": "Cannot send a friend request to yourself",
+		})
+	case errors.Is(err, services.ErrCannotBlockSelf):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             "invalid_request",
+			"error_description": "Cannot block yourself",
+		})
+	case errors.Is(err, services.ErrFriendRequestBlocked):
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":             "forbidden",
+			"error_description": "Cannot send a friend request to this user",
+		})
+	case errors.Is(err, services.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":             "not_found",
+			"error_description": "User not found",
+		})
+	case errors.Is(err, services.ErrFriendRequestNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":             "not_found",
+			"error_description": "Friend request not found",
+		})
+	case errors.Is(err, services.ErrNotFriends):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":             "not_found",
+			"error_description": "User is not your friend",
+		})
+	case errors.Is(err, services.ErrBlockNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":             "not_found",
+			"error_description": "User is not blocked",
+		})
+	case errors.Is(err, services.ErrFriendGroupNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":             "not_found",
+			"error_description": "Friend group not found",
+		})
+	case errors.Is(err, services.ErrFriendGroupMemberNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":             "not_found",
+			"error_description": "User is not a member of the friend group",
+		})
+	case errors.Is(err, services.ErrInvalidFriendGroupName):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             "invalid_request",
+			"error_description": "Group name must be 1-64 characters",
+		})
+	case errors.Is(err, services.ErrFriendGroupExists):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":             "group_exists",
+			"error_description": "Friend group with this name already exists",
+		})
+	case errors.Is(err, services.ErrAlreadyFriends):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":             "already_friends",
+			"error_description": "User is already your friend",
+		})
+	case errors.Is(err, services.ErrFriendRequestExists):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":             "request_pending",
+			"error_description": "Friend request already pending",
+		})
+	case errors.Is(err, services.ErrFriendRequestNotPending):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":             "request_closed",
+			"error_description": "Friend request is no longer pending",
+		})
+	default:
+		middleware.Logger(c).WithError(err).Error(message)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":             "server_error",
+			"error_description": message,
+		})
+	}
+}
+
+// pagination читає limit та offset із query; межі limit застосовує сервіс
+func pagination(c *gin.Context) (limit, offset int) {
+	limit, _ = strconv.Atoi(c.Query("limit"))
+	offset, _ = strconv.Atoi(c.Query("offset"))
+	return limit, max(offset, 0)
+}
+
+// normalizeUserID приводить ID користувача до формату з префіксом usr_;
+// старі клієнти передають ID без префікса
+func normalizeUserID(id string) string {
+	id = strings.TrimSpace(id)
+	if !strings.HasPrefix(id, "usr_") {
+		id = "usr_" + id
+	}
+	return id
+}
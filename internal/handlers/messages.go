@@ -0,0 +1,471 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-practice/internal/middleware"
+	"go-practice/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// messageSocketWriteTimeout скільки чекати на запис у WebSocket, перш ніж вважати клієнта відключеним
+	messageSocketWriteTimeout = 10 * time.Second
+	// messageSocketReadLimit максимальний розмір вхідного кадру; клієнт надсилає лише службові кадри
+	messageSocketReadLimit = 512
+)
+
+// MessageHandler містить handlers для особистих повідомлень між друзями
+type MessageHandler struct {
+	messageService services.MessageService
+	keepAlive      time.Duration
+	allowedOrigins []string
+}
+
+// NewMessageHandler створює новий MessageHandler.
+// keepAlive — інтервал ping кадрів WebSocket; allowedOrigins — CORS origins, з яких дозволене з'єднання (крім власного host).
+func NewMessageHandler(messageService services.MessageService, keepAlive time.Duration, allowedOrigins []string) *MessageHandler {
+	return &MessageHandler{
+		messageService: messageService,
+		keepAlive:      keepAlive,
+		allowedOrigins: allowedOrigins,
+	}
+}
+
+// ListConversations повертає розмови поточного користувача
+// @Summary List Conversations
+// @Description Повертає розмови з останнім повідомленням і кількістю непрочитаних, від найновіших
+// @Tags messages
+// @Produce json
+// @Param limit query int false "Кількість (за замовчуванням 20, максимум 100)"
+// @Param offset query int false "Зсув"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/conversations [get]
+func (h *MessageHandler) ListConversations(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	limit, offset := pagination(c)
+	conversations, total, err := h.messageService.ListConversations(userID, limit, offset)
+	if err != nil {
+		h.respondError(c, err, "Failed to list conversations")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   conversations,
+		"count":  len(conversations),
+		"total":  total,
+		"offset": offset,
+	})
+}
+
+// StartConversation відкриває розмову з другом
+// @Summary Start Conversation
+// @Description Повертає розмову з другом, створюючи її за потреби
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param request body object true "user_id"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/conversations [post]
+func (h *MessageHandler) StartConversation(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	var req struct {
+		UserID string `json:"user_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             "invalid_request",
+			"error_description": "Invalid or missing user_id",
+		})
+		return
+	}
+
+	conversation, err := h.messageService.StartConversation(userID, normalizeUserID(req.UserID))
+	if err != nil {
+		h.respondError(c, err, "Failed to start conversation")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"conversation": conversation,
+	})
+}
+
+// GetConversation повертає розмову поточного користувача
+// @Summary Get Conversation
+// @Description Повертає розмову з останнім повідомленням і кількістю непрочитаних
+// @Tags messages
+// @Produce json
+// @Param id path int true "Conversation ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/conversations/{id} [get]
+func (h *MessageHandler) GetConversation(c *gin.Context) {
+	userID, conversationID, ok := h.conversationParams(c)
+	if !ok {
+		return
+	}
+
+	conversation, err := h.messageService.GetConversation(userID, conversationID)
+	if err != nil {
+		h.respondError(c, err, "Failed to get conversation")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"conversation": conversation,
+	})
+}
+
+// History повертає повідомлення розмови
+// @Summary Conversation History
+// @Description Повертає повідомлення від новіших до старіших. Для наступної сторінки передайте next_before як before.
+// @Tags messages
+// @Produce json
+// @Param id path int true "Conversation ID"
+// @Param before query int false "ID повідомлення, старіші за яке повернути"
+// @Param limit query int false "Кількість (за замовчуванням 20, максимум 100)"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/conversations/{id}/messages [get]
+func (h *MessageHandler) History(c *gin.Context) {
+	userID, conversationID, ok := h.conversationParams(c)
+	if !ok {
+		return
+	}
+
+	before, _ := strconv.ParseUint(c.Query("before"), 10, 64)
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	messages, next, err := h.messageService.History(userID, conversationID, before, limit)
+	if err != nil {
+		h.respondError(c, err, "Failed to get messages")
+		return
+	}
+
+	response := gin.H{
+		"data":  messages,
+		"count": len(messages),
+	}
+	if next > 0 {
+		response["next_before"] = next
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// SendMessage надсилає повідомлення в розмову
+// @Summary Send Message
+// @Description Надсилає повідомлення другу; учасники отримують його через WebSocket /api/v1/messages/ws
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param id path int true "Conversation ID"
+// @Param request body object true "body"
+// @Security BearerAuth
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/conversations/{id}/messages [post]
+func (h *MessageHandler) SendMessage(c *gin.Context) {
+	userID, conversationID, ok := h.conversationParams(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Body string `json:"body" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             "invalid_request",
+			"error_description": "Invalid or missing body",
+		})
+		return
+	}
+
+	message, err := h.messageService.SendMessage(userID, conversationID, req.Body)
+	if err != nil {
+		h.respondError(c, err, "Failed to send message")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": message,
+	})
+}
+
+// MarkRead позначає повідомлення розмови прочитаними
+// @Summary Mark Conversation Read
+// @Description Позначає прочитаними вхідні повідомлення до message_id включно (без message_id — усі) і надсилає співрозмовнику подію read
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param id path int true "Conversation ID"
+// @Param request body object false "message_id"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/conversations/{id}/read [post]
+func (h *MessageHandler) MarkRead(c *gin.Context) {
+	userID, conversationID, ok := h.conversationParams(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		MessageID uint64 `json:"message_id"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":             "invalid_request",
+				"error_description": "Invalid message_id",
+			})
+			return
+		}
+	}
+
+	count, err := h.messageService.MarkRead(userID, conversationID, req.MessageID)
+	if err != nil {
+		h.respondError(c, err, "Failed to mark messages as read")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Messages marked as read",
+		"count":   count,
+	})
+}
+
+// DeleteMessage видаляє власне повідомлення
+// @Summary Delete Message
+// @Description Видаляє власне повідомлення для обох учасників розмови
+// @Tags messages
+// @Produce json
+// @Param id path int true "Conversation ID"
+// @Param message_id path int true "Message ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/conversations/{id}/messages/{message_id} [delete]
+func (h *MessageHandler) DeleteMessage(c *gin.Context) {
+	userID, conversationID, ok := h.conversationParams(c)
+	if !ok {
+		return
+	}
+
+	messageID, err := strconv.ParseUint(c.Param("message_id"), 10, 64)
+	if err != nil {
+		h.respondError(c, services.ErrMessageNotFound, "")
+		return
+	}
+
+	if err := h.messageService.DeleteMessage(userID, conversationID, messageID); err != nil {
+		h.respondError(c, err, "Failed to delete message")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Message deleted",
+	})
+}
+
+// Stream доставляє події розмов поточного користувача через WebSocket
+// @Summary Message Stream
+// @Description WebSocket: JSON події message, message_deleted та read для всіх розмов користувача.
+// @Description Браузерні клієнти автентифікуються cookie сесії або JWT у параметрі access_token.
+// @Description Повідомлення надсилаються через POST /api/v1/conversations/{id}/messages; пропущені під час розриву — в історії розмови.
+// @Tags messages
+// @Param access_token query string false "JWT access token, якщо не можна передати заголовок Authorization"
+// @Security BearerAuth
+// @Success 101 {string} string "switching protocols"
+// @Router /api/v1/messages/ws [get]
+func (h *MessageHandler) Stream(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	// Cookie браузер додає до з'єднання з будь-якого сайту, тому для неї Origin обов'язковий
+	method, _ := middleware.GetAuthMethod(c)
+	upgrader := websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			return checkSocketOrigin(r, h.allowedOrigins, method == middleware.AuthMethodSessionCookie)
+		},
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrader уже відповів клієнту з помилкою handshake
+		middleware.Logger(c).WithError(err).Warn("Failed to upgrade message stream")
+		return
+	}
+	defer conn.Close()
+
+	events, unsubscribe := h.messageService.Subscribe(userID)
+	defer unsubscribe()
+
+	// Клієнт нічого не надсилає, але читання потрібне для обробки pong та close кадрів
+	closed := make(chan struct{})
+	conn.SetReadLimit(messageSocketReadLimit)
+	_ = conn.SetReadDeadline(time.Now().Add(2 * h.keepAlive))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * h.keepAlive))
+	})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	keepAlive := time.NewTicker(h.keepAlive)
+	defer keepAlive.Stop()
+
+	// З'єднання закривається, коли токен чи сесія перестають бути дійсними; клієнт перепідключається з новими
+	authorizationLost := middleware.AuthorizationLost(c)
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-authorizationLost:
+			message := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "authorization expired")
+			_ = conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(messageSocketWriteTimeout))
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			_ = conn.SetWriteDeadline(time.Now().Add(messageSocketWriteTimeout))
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(messageSocketWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// conversationParams повертає поточного користувача та ID розмови з шляху; при помилці відповідь уже надіслана
+func (h *MessageHandler) conversationParams(c *gin.Context) (string, uint64, bool) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return "", 0, false
+	}
+
+	conversationID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		h.respondError(c, services.ErrConversationNotFound, "")
+		return "", 0, false
+	}
+	return userID, conversationID, true
+}
+
+// respondError перетворює помилки MessageService на HTTP відповіді
+func (h *MessageHandler) respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrCannotMessageSelf):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             "invalid_request",
+			"error_description": "Cannot message yourself",
+		})
+	case errors.Is(err, services.ErrInvalidMessage):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             "invalid_request",
+			"error_description": "Message must be 1-4000 characters",
+		})
+	case errors.Is(err, services.ErrNotFriends):
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":             "forbidden",
+			"error_description": "You can only message your friends",
+		})
+	case errors.Is(err, services.ErrMessagingBlocked):
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":             "forbidden",
+			"error_description": "Cannot message this user",
+		})
+	case errors.Is(err, services.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":             "not_found",
+			"error_description": "User not found",
+		})
+	case errors.Is(err, services.ErrConversationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":             "not_found",
+			"error_description": "Conversation not found",
+		})
+	case errors.Is(err, services.ErrMessageNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":             "not_found",
+			"error_description": "Message not found",
+		})
+	default:
+		middleware.Logger(c).WithError(err).Error(message)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":             "server_error",
+			"error_description": message,
+		})
+	}
+}
+
+// checkSocketOrigin дозволяє WebSocket з'єднання з власного host або з явно перелічених CORS origins.
+// Без Origin (не браузер) з'єднання дозволене лише якщо requireOrigin = false. "*" для WebSocket не враховується:
+// інакше сторонній сайт міг би відкрити з'єднання з cookie сесії користувача і читати його повідомлення.
+func checkSocketOrigin(r *http.Request, allowedOrigins []string, requireOrigin bool) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return !requireOrigin
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, allowed := range allowedOrigins {
+		if allowed == origin {
+			return true
+		}
+	}
+	return false
+}
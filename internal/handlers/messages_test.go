@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckSocketOrigin(t *testing.T) {
+	allowed := []string{"https://app.example.com", "*"}
+
+	tests := []struct {
+		name          string
+		origin        string
+		requireOrigin bool
+		want          bool
+	}{
+		{"same host", "https://api.example.com", true, true},
+		{"listed origin", "https://app.example.com", true, true},
+		{"unlisted origin", "https://evil.example.net", false, false},
+		{"wildcard is ignored", "https://evil.example.net", true, false},
+		{"listed origin with other scheme", "http://app.example.com", true, false},
+		{"missing origin for bearer token", "", false, true},
+		{"missing origin for cookie session", "", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "http://api.example.com/api/v1/messages/stream", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			if got := checkSocketOrigin(r, allowed, tt.requireOrigin); got != tt.want {
+				t.Fatalf("checkSocketOrigin(%q, requireOrigin=%v) = %v, want %v", tt.origin, tt.requireOrigin, got, tt.want)
+			}
+		})
+	}
+}
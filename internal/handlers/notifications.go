@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-practice/internal/middleware"
+	"go-practice/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// NotificationHandler містить handlers для сповіщень користувача
+type NotificationHandler struct {
+	notificationService services.NotificationService
+	keepAlive           time.Duration
+}
+
+// NewNotificationHandler створює новий NotificationHandler.
+// keepAlive — інтервал коментарів-пінгів, які не дають проксі закрити неактивний потік.
+func NewNotificationHandler(notificationService services.NotificationService, keepAlive time.Duration) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+		keepAlive:           keepAlive,
+	}
+}
+
+// List повертає сповіщення поточного користувача
+// @Summary List Notifications
+// @Description Повертає сповіщення від новіших до старіших з пагінацією та кількістю непрочитаних
+// @Tags notifications
+// @Produce json
+// @Param unread query bool false "Лише непрочитані"
+// @Param limit query int false "Кількість (за замовчуванням 20, максимум 100)"
+// @Param offset query int false "Зсув"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/notifications [get]
+func (h *NotificationHandler) List(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	unreadOnly, _ := strconv.ParseBool(c.Query("unread"))
+	limit, offset := pagination(c)
+	notifications, total, err := h.notificationService.List(userID, unreadOnly, limit, offset)
+	if err != nil {
+		h.respondError(c, err, "Failed to list notifications")
+		return
+	}
+
+	unread, err := h.notificationService.UnreadCount(userID)
+	if err != nil {
+		h.respondError(c, err, "Failed to list notifications")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   notifications,
+		"count":  len(notifications),
+		"total":  total,
+		"offset": offset,
+		"unread": unread,
+	})
+}
+
+// MarkRead позначає сповіщення прочитаним
+// @Summary Mark Notification Read
+// @Description Позначає сповіщення прочитаним
+// @Tags notifications
+// @Produce json
+// @Param id path int true "Notification ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/notifications/{id}/read [post]
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	notificationID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		h.respondError(c, services.ErrNotificationNotFound, "")
+		return
+	}
+
+	notification, err := h.notificationService.MarkRead(userID, notificationID)
+	if err != nil {
+		h.respondError(c, err, "Failed to mark notification as read")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Notification marked as read",
+		"notification": notification,
+	})
+}
+
+// MarkAllRead позначає прочитаними всі сповіщення поточного користувача
+// @Summary Mark All Notifications Read
+// @Description Позначає прочитаними всі непрочитані сповіщення
+// @Tags notifications
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/notifications/read [post]
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	count, err := h.notificationService.MarkAllRead(userID)
+	if err != nil {
+		h.respondError(c, err, "Failed to mark notifications as read")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Notifications marked as read",
+		"count":   count,
+	})
+}
+
+// Stream надсилає нові сповіщення поточного користувача в реальному часі
+// @Summary Notification Stream
+// @Description Server-Sent Events: спершу подія unread_count, далі подія notification для кожного нового сповіщення.
+// @Description Сповіщення, пропущені під час розриву, доступні через GET /api/v1/notifications?unread=true.
+// @Tags notifications
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Success 200 {string} string "event stream"
+// @Router /api/v1/notifications/stream [get]
+func (h *NotificationHandler) Stream(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	// Підписуємось до читання лічильника, щоб не пропустити сповіщення між ними
+	events, unsubscribe := h.notificationService.Subscribe(userID)
+	defer unsubscribe()
+
+	unread, err := h.notificationService.UnreadCount(userID)
+	if err != nil {
+		h.respondError(c, err, "Failed to open notification stream")
+		return
+	}
+
+	// write_timeout сервера обірвав би довготривалу відповідь
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		middleware.Logger(c).WithError(err).Warn("Failed to clear write deadline for notification stream")
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.SSEvent("unread_count", gin.H{"unread": unread})
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(h.keepAlive)
+	defer keepAlive.Stop()
+
+	// Потік закривається, коли токен чи сесія перестають бути дійсними; клієнт перепідключається з новими
+	authorizationLost := middleware.AuthorizationLost(c)
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-authorizationLost:
+			return false
+		case notification, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent("notification", notification)
+			return true
+		case <-keepAlive.C:
+			_, err := fmt.Fprint(w, ": keep-alive\n\n")
+			return err == nil
+		}
+	})
+}
+
+// respondError перетворює помилки NotificationService на HTTP відповіді
+func (h *NotificationHandler) respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrNotificationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":             "not_found",
+			"error_description": "Notification not found",
+		})
+	default:
+		middleware.Logger(c).WithError(err).Error(message)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":             "server_error",
+			"error_description": message,
+		})
+	}
+}
+
+// notify створює сповіщення для userID; помилка не перериває основну дію, лише логується
+func notify(c *gin.Context, notifications services.NotificationService, userID, notificationType, actorID string, payload map[string]interface{}) {
+	if _, err := notifications.Notify(userID, notificationType, actorID, payload); err != nil {
+		middleware.Logger(c).WithError(err).WithFields(logrus.Fields{
+			"notification_type": notificationType,
+			"user_id":           userID,
+		}).Error("Failed to create notification")
+	}
+}
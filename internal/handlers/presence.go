@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go-practice/internal/middleware"
+	"go-practice/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PresenceHandler містить handlers для присутності друзів
+type PresenceHandler struct {
+	presence    services.PresenceService
+	userService services.UserService
+	keepAlive   time.Duration
+}
+
+// NewPresenceHandler створює новий PresenceHandler.
+// keepAlive — інтервал коментарів-пінгів, які не дають проксі закрити неактивний потік.
+func NewPresenceHandler(presence services.PresenceService, userService services.UserService, keepAlive time.Duration) *PresenceHandler {
+	return &PresenceHandler{
+		presence:    presence,
+		userService: userService,
+		keepAlive:   keepAlive,
+	}
+}
+
+// Stream надсилає зміни присутності друзів поточного користувача в реальному часі
+// @Summary Friends Presence Stream
+// @Description Server-Sent Events: спершу подія snapshot з присутністю всіх друзів, далі подія presence для кожного друга,
+// @Description що став онлайн, офлайн або приховав статус (hidden). Поки потік відкритий, поточний користувач теж онлайн.
+// @Tags friends
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Success 200 {string} string "event stream"
+// @Router /api/v1/friends/presence/stream [get]
+func (h *PresenceHandler) Stream(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "unauthorized",
+			"error_description": "User not authenticated",
+		})
+		return
+	}
+
+	// Підписуємось до читання знімка, щоб не пропустити зміни між ними
+	events, unsubscribe := h.presence.Subscribe(userID)
+	defer unsubscribe()
+
+	friends, err := h.userService.GetFriends(userID)
+	if err != nil {
+		middleware.Logger(c).WithError(err).Error("Failed to open presence stream")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":             "server_error",
+			"error_description": "Failed to open presence stream",
+		})
+		return
+	}
+	presence := h.presence.Presence(friends)
+	snapshot := make([]services.Presence, len(friends))
+	for i, friend := range friends {
+		snapshot[i] = presence[friend.ID]
+	}
+
+	// write_timeout сервера обірвав би довготривалу відповідь
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		middleware.Logger(c).WithError(err).Warn("Failed to clear write deadline for presence stream")
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.SSEvent("snapshot", gin.H{"data": snapshot})
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(h.keepAlive)
+	defer keepAlive.Stop()
+
+	// Потік закривається, коли токен чи сесія перестають бути дійсними; клієнт перепідключається з новими
+	authorizationLost := middleware.AuthorizationLost(c)
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-authorizationLost:
+			return false
+		case presence, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent("presence", presence)
+			return true
+		case <-keepAlive.C:
+			_, err := fmt.Fprint(w, ": keep-alive\n\n")
+			return err == nil
+		}
+	})
+}
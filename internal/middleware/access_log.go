@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// redactedQueryParams query-параметри з обліковими даними, значення яких не потрапляють в access log
+var redactedQueryParams = []string{webSocketTokenParam, "token", "code", "id_token_hint"}
+
+// AccessLogger пише access log у форматі gin.Logger, маскуючи значення redactedQueryParams.
+// JWT у WebSocket URL, токени з листів та authorization code інакше зберігались би в логах.
+func AccessLogger() gin.HandlerFunc {
+	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
+		var statusColor, methodColor, resetColor string
+		if param.IsOutputColor() {
+			statusColor = param.StatusCodeColor()
+			methodColor = param.MethodColor()
+			resetColor = param.ResetColor()
+		}
+
+		if param.Latency > time.Minute {
+			param.Latency = param.Latency.Truncate(time.Second)
+		}
+		return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v\n%s",
+			param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+			statusColor, param.StatusCode, resetColor,
+			param.Latency,
+			param.ClientIP,
+			methodColor, param.Method, resetColor,
+			redactPath(param.Path),
+			param.ErrorMessage,
+		)
+	})
+}
+
+// redactPath замінює значення чутливих query-параметрів у шляху запиту на REDACTED
+func redactPath(path string) string {
+	base, rawQuery, found := strings.Cut(path, "?")
+	if !found {
+		return path
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		// Запит, який не вдалося розібрати, логується без параметрів
+		return base + "?REDACTED"
+	}
+
+	redacted := false
+	for _, name := range redactedQueryParams {
+		if values, ok := query[name]; ok {
+			for i := range values {
+				values[i] = "REDACTED"
+			}
+			redacted = true
+		}
+	}
+	if !redacted {
+		return path
+	}
+	return base + "?" + query.Encode()
+}
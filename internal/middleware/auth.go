@@ -1,17 +1,28 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
+	"go-practice/internal/models"
 	"go-practice/internal/services"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
 
-// AuthMiddleware створює middleware для перевірки JWT токенів
-func AuthMiddleware(jwtService services.JWTService, userService services.UserService) gin.HandlerFunc {
+// Способи автентифікації запиту
+const (
+	AuthMethodJWT                 = "jwt"
+	AuthMethodPersonalAccessToken = "personal_access_token"
+)
+
+// AuthMiddleware створює middleware для перевірки JWT токенів та personal access tokens.
+// З requireActiveSession JWT з sid приймається лише поки його сесія існує: завершення сесії
+// (logout, idle timeout, видалення) одразу відкликає її токени ціною звернення до сховища сесій на кожен запит.
+func AuthMiddleware(jwtService services.JWTService, userService services.UserService, patService services.PersonalAccessTokenService, impersonationService services.ImpersonationService, revocationStore services.RevocationStore, authService services.AuthService, requireActiveSession bool) gin.HandlerFunc {
 	return gin.HandlerFunc(func(c *gin.Context) {
 		// Отримуємо Authorization header
 		authHeader := c.GetHeader("Authorization")
@@ -48,25 +59,118 @@ func AuthMiddleware(jwtService services.JWTService, userService services.UserSer
 			return
 		}
 
-		// Валідуємо токен через JWTService
-		userID, err := jwtService.GetUserIDFromToken(token)
+		var (
+			userID string
+			claims *services.AccessTokenClaims
+			pat    *services.PersonalAccessToken
+			err    error
+		)
+
+		if services.IsPersonalAccessToken(token) {
+			// Personal access token перевіряється за хешем у базі даних
+			pat, err = patService.Authenticate(token, c.ClientIP())
+			if err != nil {
+				logrus.WithError(err).Warn("Invalid personal access token")
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"error":             "invalid_token",
+					"error_description": "Token validation failed",
+				})
+				c.Abort()
+				return
+			}
+			userID = pat.UserID
+		} else {
+			// Валідуємо токен через JWTService
+			claims, err = jwtService.ParseAccessToken(token)
+			if err != nil {
+				logrus.WithError(err).Warn("Invalid access token")
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"error":             "invalid_token",
+					"error_description": "Token validation failed",
+				})
+				c.Abort()
+				return
+			}
+			userID = claims.UserID
+
+			// Токени відкликаної сесії відхиляються одразу, не чекаючи закінчення терміну дії
+			if claims.SessionID != "" {
+				revoked, err := revocationStore.IsRevoked(claims.SessionID)
+				if err != nil || revoked {
+					logrus.WithError(err).WithFields(logrus.Fields{
+						"user_id":    userID,
+						"session_id": claims.SessionID,
+					}).Warn("Token of revoked session used")
+					c.JSON(http.StatusUnauthorized, gin.H{
+						"error":             "invalid_token",
+						"error_description": "Session has been revoked",
+					})
+					c.Abort()
+					return
+				}
+			}
+
+			// Токен діє лише поки існує сесія, в якій його видано
+			if requireActiveSession && claims.SessionID != "" {
+				client := models.ClientInfo{
+					IPAddress: c.ClientIP(),
+					UserAgent: c.Request.UserAgent(),
+					RequestID: GetRequestID(c),
+				}
+				if _, err := authService.ValidateTokenSession(userID, claims.SessionID, client); err != nil {
+					if !errors.Is(err, services.ErrSessionNotFound) {
+						logrus.WithError(err).Error("Failed to validate token session")
+					}
+					logrus.WithFields(logrus.Fields{
+						"user_id":    userID,
+						"session_id": claims.SessionID,
+					}).Warn("Token of ended session used")
+					c.JSON(http.StatusUnauthorized, gin.H{
+						"error":             "invalid_token",
+						"error_description": "Session has ended",
+					})
+					c.Abort()
+					return
+				}
+			}
+
+			// Токен імперсонації діє лише поки сесія імперсонації не відкликана
+			if claims.Act != nil {
+				if err := impersonationService.Validate(claims.ID); err != nil {
+					logrus.WithError(err).WithFields(logrus.Fields{
+						"user_id":          userID,
+						"impersonator_id":  claims.Act.Subject,
+						"impersonation_id": claims.ID,
+					}).Warn("Ended impersonation token used")
+					c.JSON(http.StatusUnauthorized, gin.H{
+						"error":             "invalid_token",
+						"error_description": "Impersonation session has ended",
+					})
+					c.Abort()
+					return
+				}
+			}
+		}
+
+		// Отримуємо користувача з бази даних
+		user, err := userService.GetUserByID(userID)
 		if err != nil {
-			logrus.WithError(err).Warn("Invalid access token")
+			logrus.WithError(err).Error("Failed to get user from token")
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error":             "invalid_token",
-				"error_description": "Token validation failed",
+				"error_description": "User not found",
 			})
 			c.Abort()
 			return
 		}
 
-		// Отримуємо користувача з бази даних
-		user, err := userService.GetUserByID(userID)
-		if err != nil {
-			logrus.WithError(err).Error("Failed to get user from token")
+		// Токени, видані до зміни пароля чи email, скидання пароля або примусового виходу, відкликані.
+		// Це стосується й personal access tokens, створених до цього моменту.
+		if isCredentialRevoked(user, claims, pat) {
+			logrus.WithField("user_id", userID).Warn("Revoked access token used")
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error":             "invalid_token",
-				"error_description": "User not found",
+				"error_description": "Token has been revoked",
 			})
 			c.Abort()
 			return
@@ -86,6 +190,41 @@ func AuthMiddleware(jwtService services.JWTService, userService services.UserSer
 		// Зберігаємо користувача в контексті для подальшого використання
 		c.Set("user", user)
 		c.Set("user_id", userID)
+		if pat != nil {
+			c.Set("auth_method", AuthMethodPersonalAccessToken)
+			c.Set("token_id", pat.ID)
+			c.Set("scopes", pat.Scopes)
+		} else {
+			c.Set("auth_method", AuthMethodJWT)
+			c.Set("scopes", claims.Scope)
+			if claims.SessionID != "" {
+				c.Set("session_id", claims.SessionID)
+			}
+		}
+
+		// Довготривалі з'єднання повторно перевіряють облікові дані, поки відкриті
+		setReauthorization(c, credentialsExpiry(claims, pat), func() error {
+			return reauthorize(userService, patService, impersonationService, revocationStore, authService, requireActiveSession,
+				token, claims, pat, models.ClientInfo{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent(), RequestID: GetRequestID(c)})
+		})
+
+		// Логер запиту з даними користувача; при імперсонації кожен запис містить адміністратора
+		logFields := logrus.Fields{"user_id": userID}
+		if claims != nil && claims.Act != nil {
+			c.Set("impersonator_id", claims.Act.Subject)
+			c.Set("impersonation_id", claims.ID)
+			logFields["impersonator_id"] = claims.Act.Subject
+			logFields["impersonation_id"] = claims.ID
+		}
+		logger := Logger(c).WithFields(logFields)
+		c.Set("logger", logger)
+
+		if claims != nil && claims.Act != nil {
+			logger.WithFields(logrus.Fields{
+				"method": c.Request.Method,
+				"path":   c.Request.URL.Path,
+			}).Info("Impersonated request")
+		}
 
 		logrus.WithFields(logrus.Fields{
 			"user_id": userID,
@@ -98,6 +237,70 @@ func AuthMiddleware(jwtService services.JWTService, userService services.UserSer
 	})
 }
 
+// credentialsExpiry повертає момент закінчення дії JWT або personal access token (нульовий — без терміну)
+func credentialsExpiry(claims *services.AccessTokenClaims, pat *services.PersonalAccessToken) time.Time {
+	if claims != nil && claims.ExpiresAt != nil {
+		return claims.ExpiresAt.Time
+	}
+	if pat != nil && pat.ExpiresAt != nil {
+		return *pat.ExpiresAt
+	}
+	return time.Time{}
+}
+
+// reauthorize повторює перевірки AuthMiddleware для вже прийнятого токена: акаунт активний,
+// токен не відкликано зміною облікових даних, сесію та імперсонацію не завершено
+func reauthorize(userService services.UserService, patService services.PersonalAccessTokenService, impersonationService services.ImpersonationService, revocationStore services.RevocationStore, authService services.AuthService, requireActiveSession bool, token string, claims *services.AccessTokenClaims, pat *services.PersonalAccessToken, client models.ClientInfo) error {
+	var userID string
+	if pat != nil {
+		if _, err := patService.Authenticate(token, client.IPAddress); err != nil {
+			return err
+		}
+		userID = pat.UserID
+	} else {
+		userID = claims.UserID
+	}
+
+	user, err := userService.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if !user.IsActive || isCredentialRevoked(user, claims, pat) {
+		return services.ErrTokenRevoked
+	}
+	if pat != nil {
+		return nil
+	}
+
+	if claims.SessionID != "" {
+		revoked, err := revocationStore.IsRevoked(claims.SessionID)
+		if err != nil {
+			return err
+		}
+		if revoked {
+			return services.ErrTokenRevoked
+		}
+		if requireActiveSession {
+			if _, err := authService.ValidateTokenSession(user.ID, claims.SessionID, client); err != nil {
+				return err
+			}
+		}
+	}
+
+	if claims.Act != nil {
+		return impersonationService.Validate(claims.ID)
+	}
+	return nil
+}
+
+// isCredentialRevoked перевіряє чи JWT або personal access token видано до tokens_valid_after користувача
+func isCredentialRevoked(user *services.User, claims *services.AccessTokenClaims, pat *services.PersonalAccessToken) bool {
+	if pat != nil {
+		return user.IsTokenRevoked(pat.CreatedAt)
+	}
+	return claims.IssuedAt == nil || user.IsTokenRevoked(claims.IssuedAt.Time)
+}
+
 // GetCurrentUser витягує поточного користувача з контексту
 func GetCurrentUser(c *gin.Context) (*services.User, bool) {
 	user, exists := c.Get("user")
@@ -119,3 +322,58 @@ func GetCurrentUserID(c *gin.Context) (string, bool) {
 	userIDStr, ok := userID.(string)
 	return userIDStr, ok
 }
+
+// GetTokenScopes повертає scopes токена запиту.
+// ok = false означає, що запит не пройшов через AuthMiddleware.
+func GetTokenScopes(c *gin.Context) ([]string, bool) {
+	scopes, exists := c.Get("scopes")
+	if !exists {
+		return nil, false
+	}
+
+	scopesList, ok := scopes.([]string)
+	return scopesList, ok
+}
+
+// GetSessionID повертає ID сесії, до якої прив'язаний токен запиту
+func GetSessionID(c *gin.Context) (string, bool) {
+	sessionID, exists := c.Get("session_id")
+	if !exists {
+		return "", false
+	}
+
+	sessionIDStr, ok := sessionID.(string)
+	return sessionIDStr, ok
+}
+
+// GetAuthMethod повертає спосіб автентифікації запиту (jwt, personal_access_token або session_cookie)
+func GetAuthMethod(c *gin.Context) (string, bool) {
+	method, exists := c.Get("auth_method")
+	if !exists {
+		return "", false
+	}
+
+	methodStr, ok := method.(string)
+	return methodStr, ok
+}
+
+// GetImpersonatorID повертає ID адміністратора, якщо запит виконується в режимі імперсонації
+func GetImpersonatorID(c *gin.Context) (string, bool) {
+	impersonatorID, exists := c.Get("impersonator_id")
+	if !exists {
+		return "", false
+	}
+
+	impersonatorIDStr, ok := impersonatorID.(string)
+	return impersonatorIDStr, ok
+}
+
+// Logger повертає логер запиту з request_id та контекстом автентифікації (user_id, impersonator_id)
+func Logger(c *gin.Context) *logrus.Entry {
+	if logger, exists := c.Get("logger"); exists {
+		if entry, ok := logger.(*logrus.Entry); ok {
+			return entry
+		}
+	}
+	return logrus.NewEntry(logrus.StandardLogger())
+}
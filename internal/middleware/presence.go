@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"go-practice/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TrackPresence позначає автентифікованого користувача активним.
+// Запити адміністратора від імені користувача не роблять користувача онлайн.
+func TrackPresence(presence services.PresenceService) gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		if userID, ok := presenceUserID(c); ok {
+			presence.Touch(userID)
+		}
+		c.Next()
+	})
+}
+
+// RealtimeConnection тримає користувача онлайн, поки відкрите довготривале з'єднання (SSE або WebSocket)
+func RealtimeConnection(presence services.PresenceService) gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		if userID, ok := presenceUserID(c); ok {
+			disconnect := presence.Connect(userID)
+			defer disconnect()
+		}
+		c.Next()
+	})
+}
+
+// presenceUserID повертає користувача, чия активність враховується в присутності
+func presenceUserID(c *gin.Context) (string, bool) {
+	if _, impersonated := GetImpersonatorID(c); impersonated {
+		return "", false
+	}
+	return GetCurrentUserID(c)
+}
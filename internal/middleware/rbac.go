@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"go-practice/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// RequirePermission створює middleware, яке пропускає лише користувачів з усіма вказаними дозволами.
+// Має використовуватись після AuthMiddleware.
+func RequirePermission(rbacService services.RBACService, permissions ...string) gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		userID, ok := GetCurrentUserID(c)
+		if !ok {
+			abortForbidden(c, "User not authenticated")
+			return
+		}
+
+		granted, err := rbacService.GetUserPermissions(userID)
+		if err != nil {
+			logrus.WithError(err).Error("Failed to load user permissions")
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":             "server_error",
+				"error_description": "Failed to check permissions",
+			})
+			c.Abort()
+			return
+		}
+
+		if !services.HasScopes(granted, permissions...) {
+			logrus.WithFields(logrus.Fields{
+				"user_id":     userID,
+				"path":        c.Request.URL.Path,
+				"permissions": permissions,
+			}).Warn("User lacks required permissions")
+			abortForbidden(c, "Missing permissions: "+strings.Join(permissions, ", "))
+			return
+		}
+
+		c.Set("permissions", granted)
+		c.Next()
+	})
+}
+
+// RequireRole створює middleware, яке пропускає лише користувачів з однією з вказаних ролей.
+// Має використовуватись після AuthMiddleware.
+func RequireRole(rbacService services.RBACService, roles ...string) gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		userID, ok := GetCurrentUserID(c)
+		if !ok {
+			abortForbidden(c, "User not authenticated")
+			return
+		}
+
+		userRoles, err := rbacService.GetUserRoles(userID)
+		if err != nil {
+			logrus.WithError(err).Error("Failed to load user roles")
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":             "server_error",
+				"error_description": "Failed to check roles",
+			})
+			c.Abort()
+			return
+		}
+
+		for _, role := range roles {
+			for _, userRole := range userRoles {
+				if role == userRole {
+					c.Set("roles", userRoles)
+					c.Next()
+					return
+				}
+			}
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"user_id": userID,
+			"path":    c.Request.URL.Path,
+			"roles":   roles,
+		}).Warn("User lacks required role")
+		abortForbidden(c, "Requires one of roles: "+strings.Join(roles, ", "))
+	})
+}
+
+// abortForbidden відповідає 403 і зупиняє обробку запиту
+func abortForbidden(c *gin.Context, description string) {
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":             "forbidden",
+		"error_description": description,
+	})
+	c.Abort()
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// RequestIDHeader заголовок, у якому передається та повертається ідентифікатор запиту
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID обмежує формат ідентифікатора, отриманого від клієнта або проксі
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID створює middleware, яке присвоює кожному запиту ідентифікатор.
+// Ідентифікатор з вхідного заголовка зберігається, якщо має допустимий формат.
+func RequestID() gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = generateRequestID()
+		}
+
+		c.Set("request_id", requestID)
+		c.Set("logger", logrus.WithField("request_id", requestID))
+		c.Header(RequestIDHeader, requestID)
+
+		c.Next()
+	})
+}
+
+// GetRequestID повертає ідентифікатор поточного запиту
+func GetRequestID(c *gin.Context) string {
+	return c.GetString("request_id")
+}
+
+// generateRequestID генерує випадковий ідентифікатор запиту
+func generateRequestID() string {
+	bytes := make([]byte, 16)
+	rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
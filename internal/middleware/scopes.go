@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go-practice/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// RequireScopes створює middleware, яке пропускає лише токени з усіма вказаними scopes.
+// Має використовуватись після AuthMiddleware. Відмова формується згідно RFC 6750.
+func RequireScopes(required ...string) gin.HandlerFunc {
+	scope := strings.Join(required, " ")
+
+	return gin.HandlerFunc(func(c *gin.Context) {
+		granted, _ := GetTokenScopes(c)
+		if !services.HasScopes(granted, required...) {
+			logrus.WithFields(logrus.Fields{
+				"path":     c.Request.URL.Path,
+				"required": required,
+				"granted":  granted,
+			}).Warn("Token lacks required scopes")
+			description := "The access token does not grant the required scope"
+			c.Header("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", error_description="%s", scope="%s"`, description, scope))
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":             "insufficient_scope",
+				"error_description": description,
+				"scope":             scope,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	})
+}
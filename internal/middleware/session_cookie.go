@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-practice/internal/models"
+	"go-practice/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// AuthMethodSessionCookie спосіб автентифікації браузерних запитів через cookie сесії
+const AuthMethodSessionCookie = "session_cookie"
+
+// CSRFHeader заголовок, у якому клієнт повертає значення CSRF cookie для змінюючих запитів
+const CSRFHeader = "X-CSRF-Token"
+
+// SessionExpiresInHeader заголовок відповіді з кількістю секунд до завершення сесії без подальшої активності
+const SessionExpiresInHeader = "X-Session-Expires-In"
+
+// csrfCookieName cookie з CSRF токеном; доступна JavaScript, щоб SPA могла передати її в заголовку
+const csrfCookieName = "csrf_token"
+
+// SessionCookies видає, читає та очищує cookie браузерної сесії і CSRF cookie
+type SessionCookies struct {
+	codec    services.SessionCookieCodec
+	name     string
+	maxAge   int
+	secure   bool
+	httpOnly bool
+	sameSite http.SameSite
+}
+
+// NewSessionCookies створює SessionCookies з налаштуваннями cookie з конфігурації
+func NewSessionCookies(codec services.SessionCookieCodec, name string, maxAge int, secure, httpOnly bool, sameSite http.SameSite) *SessionCookies {
+	return &SessionCookies{
+		codec:    codec,
+		name:     name,
+		maxAge:   maxAge,
+		secure:   secure,
+		httpOnly: httpOnly,
+		sameSite: sameSite,
+	}
+}
+
+// Set встановлює підписану cookie сесії та CSRF cookie, прив'язану до неї
+func (sc *SessionCookies) Set(c *gin.Context, sessionID string) {
+	c.SetSameSite(sc.sameSite)
+	c.SetCookie(sc.name, sc.codec.Encode(sessionID), sc.maxAge, "/", "", sc.secure, sc.httpOnly)
+	c.SetCookie(csrfCookieName, sc.codec.CSRFToken(sessionID), sc.maxAge, "/", "", sc.secure, false)
+}
+
+// Clear видаляє cookie сесії та CSRF cookie
+func (sc *SessionCookies) Clear(c *gin.Context) {
+	c.SetSameSite(sc.sameSite)
+	c.SetCookie(sc.name, "", -1, "/", "", sc.secure, sc.httpOnly)
+	c.SetCookie(csrfCookieName, "", -1, "/", "", sc.secure, false)
+}
+
+// SessionID повертає ID сесії з cookie запиту.
+// present = false означає, що cookie немає; err — що cookie підроблена або пошкоджена.
+func (sc *SessionCookies) SessionID(c *gin.Context) (sessionID string, present bool, err error) {
+	value, err := c.Cookie(sc.name)
+	if err != nil || value == "" {
+		return "", false, nil
+	}
+
+	sessionID, err = sc.codec.Decode(value)
+	return sessionID, true, err
+}
+
+// ValidCSRF перевіряє CSRF токен змінюючого запиту; безпечні методи (GET, HEAD, OPTIONS) не перевіряються
+func (sc *SessionCookies) ValidCSRF(c *gin.Context, sessionID string) bool {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return sc.codec.ValidCSRFToken(sessionID, c.GetHeader(CSRFHeader))
+}
+
+// CookieAuthMiddleware автентифікує браузерні запити за cookie сесії як альтернатива AuthMiddleware.
+// Запити з Authorization header або без cookie сесії передаються в bearer (зазвичай AuthMiddleware).
+func CookieAuthMiddleware(cookies *SessionCookies, authService services.AuthService, bearer gin.HandlerFunc) gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		if cookies == nil || c.GetHeader("Authorization") != "" {
+			bearer(c)
+			return
+		}
+
+		sessionID, present, err := cookies.SessionID(c)
+		if !present {
+			bearer(c)
+			return
+		}
+		if err != nil {
+			logrus.WithError(err).Warn("Invalid session cookie")
+			cookies.Clear(c)
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":             "invalid_session",
+				"error_description": "Session cookie is invalid",
+			})
+			c.Abort()
+			return
+		}
+
+		// CSRF перевіряється до ротації, бо клієнт надсилає токен поточної сесії
+		if !cookies.ValidCSRF(c, sessionID) {
+			logrus.WithFields(logrus.Fields{
+				"path":   c.Request.URL.Path,
+				"method": c.Request.Method,
+			}).Warn("CSRF token mismatch")
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":             "csrf_failed",
+				"error_description": "Missing or invalid " + CSRFHeader + " header",
+			})
+			c.Abort()
+			return
+		}
+
+		client := models.ClientInfo{
+			IPAddress: c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+			RequestID: GetRequestID(c),
+		}
+		session, user, err := authService.AuthenticateSession(sessionID, client)
+		if err != nil {
+			if !errors.Is(err, services.ErrSessionNotFound) {
+				logrus.WithError(err).Error("Failed to authenticate session cookie")
+			}
+			cookies.Clear(c)
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":             "invalid_session",
+				"error_description": "Session has expired or been revoked",
+			})
+			c.Abort()
+			return
+		}
+
+		if !user.IsActive {
+			logrus.WithField("user_id", user.ID).Warn("Inactive user attempted access")
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":             "account_disabled",
+				"error_description": "User account is disabled",
+			})
+			c.Abort()
+			return
+		}
+
+		// Після зміни привілеїв сесія отримала новий ID — оновлюємо cookie клієнта
+		if session.SessionID != sessionID {
+			cookies.Set(c, session.SessionID)
+		}
+
+		// SPA може попередити користувача до завершення сесії
+		c.Header(SessionExpiresInHeader, strconv.FormatInt(int64(time.Until(session.Deadline()).Seconds()), 10))
+
+		c.Set("user", user)
+		c.Set("user_id", user.ID)
+		c.Set("auth_method", AuthMethodSessionCookie)
+		c.Set("session_id", session.SessionID)
+		c.Set("scopes", sessionScopes(session))
+		c.Set("logger", Logger(c).WithField("user_id", user.ID))
+
+		// Довготривалі з'єднання перевіряють, що сесію не завершено (logout, відкликання, деактивація акаунта)
+		setReauthorization(c, time.Time{}, func() error {
+			_, err := authService.ValidateTokenSession(user.ID, session.SessionID, client)
+			return err
+		})
+
+		c.Next()
+	})
+}
+
+// sessionScopes повертає API scopes сесії; порожній список означає всі APIScopes, як і в токенах
+func sessionScopes(session *services.SessionData) []string {
+	if len(session.Scopes) == 0 {
+		return services.APIScopes
+	}
+	return session.Scopes
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// reauthorizeInterval як часто довготривале з'єднання повторно перевіряє облікові дані
+const reauthorizeInterval = time.Minute
+
+// errNoCredentials повертається повторною перевіркою запиту, який не пройшов автентифікацію
+var errNoCredentials = errors.New("request has no credentials to recheck")
+
+// setReauthorization зберігає момент закінчення дії облікових даних запиту (нульовий — без терміну)
+// та функцію, що повторно перевіряє їх відкликання
+func setReauthorization(c *gin.Context, expiresAt time.Time, reauthorize func() error) {
+	c.Set("auth_expires_at", expiresAt)
+	c.Set("reauthorize", reauthorize)
+}
+
+// AuthorizationLost повертає канал, що закривається, коли облікові дані довготривалого з'єднання (SSE, WebSocket)
+// перестають бути дійсними: настав термін дії токена або повторна перевірка раз на reauthorizeInterval
+// виявила завершену сесію, відкликаний токен, імперсонацію чи деактивований акаунт.
+// Перевірка зупиняється разом із запитом.
+func AuthorizationLost(c *gin.Context) <-chan struct{} {
+	lost := make(chan struct{})
+	ctx := c.Request.Context()
+	expiresAt := c.GetTime("auth_expires_at")
+	logger := Logger(c)
+
+	value, _ := c.Get("reauthorize")
+	reauthorize, ok := value.(func() error)
+	if !ok {
+		reauthorize = func() error { return errNoCredentials }
+	}
+
+	go func() {
+		var expired <-chan time.Time
+		if !expiresAt.IsZero() {
+			timer := time.NewTimer(time.Until(expiresAt))
+			defer timer.Stop()
+			expired = timer.C
+		}
+
+		recheck := time.NewTicker(reauthorizeInterval)
+		defer recheck.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-expired:
+				logger.Info("Closing stream: credentials expired")
+				close(lost)
+				return
+			case <-recheck.C:
+				if err := reauthorize(); err != nil {
+					logger.WithError(err).Info("Closing stream: credentials no longer valid")
+					close(lost)
+					return
+				}
+			}
+		}
+	}()
+
+	return lost
+}
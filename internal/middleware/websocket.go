@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go-practice/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+// webSocketTokenParam query-параметр з access token для WebSocket з'єднань
+const webSocketTokenParam = "access_token"
+
+// WebSocketToken переносить access token з query-параметра в заголовок Authorization для WebSocket upgrade запитів.
+// Браузерний WebSocket API не дозволяє задати заголовки, тож клієнт без cookie сесії передає JWT в URL.
+// Personal access tokens в URL не приймаються: на відміну від короткоживучих JWT вони потрапили б у логи надовго.
+func WebSocketToken() gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		token := c.Query(webSocketTokenParam)
+		if token == "" || c.GetHeader("Authorization") != "" || !websocket.IsWebSocketUpgrade(c.Request) {
+			c.Next()
+			return
+		}
+
+		if services.IsPersonalAccessToken(token) {
+			logrus.Warn("Personal access token passed in WebSocket URL")
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":             "invalid_token",
+				"error_description": "Personal access tokens must be sent in the Authorization header",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Request.Header.Set("Authorization", "Bearer "+token)
+		c.Next()
+	})
+}
@@ -11,11 +11,16 @@ type Token struct {
 	ExpiresIn    int64     `json:"expires_in"`
 	ExpiresAt    time.Time `json:"expires_at"`
 	Scope        string    `json:"scope,omitempty"`
+	SessionID    string    `json:"-"` // для cookie сесії; клієнту не повертається
+
+	// SessionExpiresAt момент завершення сесії без активності (абсолютний термін або idle timeout)
+	SessionExpiresAt *time.Time `json:"session_expires_at,omitempty"`
 }
 
 // TokenRefreshRequest представляє запит на оновлення токена
 type TokenRefreshRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
+	Scope        string `json:"scope,omitempty"` // може лише звузити scopes початкового токена
 }
 
 // CallbackRequest представляє параметри callback запиту
@@ -33,3 +38,35 @@ type Session struct {
 	ExpiresAt    time.Time `json:"expires_at"`
 	CreatedAt    time.Time `json:"created_at"`
 }
+
+// ClientInfo містить дані про клієнта, що виконує запит
+type ClientInfo struct {
+	IPAddress string `json:"ip_address"`
+	UserAgent string `json:"user_agent"`
+	RequestID string `json:"request_id,omitempty"`
+	// ImpersonatorID адміністратор, який виконує запит у режимі імперсонації
+	ImpersonatorID string `json:"impersonator_id,omitempty"`
+}
+
+// DeviceInfo містить дані про пристрій, визначені з User-Agent
+type DeviceInfo struct {
+	Browser string `json:"browser"`
+	OS      string `json:"os"`
+	Type    string `json:"type"` // desktop, mobile, tablet, bot або unknown
+}
+
+// SessionInfo представляє активну сесію користувача
+type SessionInfo struct {
+	ID         string    `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// IdleExpiresAt момент завершення сесії без активності; відсутній, якщо idle timeout вимкнений
+	IdleExpiresAt *time.Time `json:"idle_expires_at,omitempty"`
+	// ExpiresIn скільки секунд залишилось до завершення сесії без подальшої активності
+	ExpiresIn int64      `json:"expires_in"`
+	IPAddress string     `json:"ip_address"`
+	UserAgent string     `json:"user_agent"`
+	Device    DeviceInfo `json:"device"`
+	Current   bool       `json:"current"`
+}
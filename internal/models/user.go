@@ -4,18 +4,19 @@ import "time"
 
 // User представляє користувача системи
 type User struct {
-	ID       string    `json:"id"`
-	Email    string    `json:"email"`
-	Name     string    `json:"name"`
-	Picture  string    `json:"picture,omitempty"`
-	Sub      string    `json:"sub"`       // OIDC subject identifier
-	Iss      string    `json:"iss"`       // OIDC issuer
-	Aud      []string  `json:"aud"`       // OIDC audience
-	Exp      int64     `json:"exp"`       // OIDC expiration time
-	Iat      int64     `json:"iat"`       // OIDC issued at time
-	AuthTime int64     `json:"auth_time"` // OIDC authentication time
-	CreateAt time.Time `json:"created_at"`
-	UpdateAt time.Time `json:"updated_at"`
+	ID            string    `json:"id"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	Name          string    `json:"name"`
+	Picture       string    `json:"picture,omitempty"`
+	Sub           string    `json:"sub"`       // OIDC subject identifier
+	Iss           string    `json:"iss"`       // OIDC issuer
+	Aud           []string  `json:"aud"`       // OIDC audience
+	Exp           int64     `json:"exp"`       // OIDC expiration time
+	Iat           int64     `json:"iat"`       // OIDC issued at time
+	AuthTime      int64     `json:"auth_time"` // OIDC authentication time
+	CreateAt      time.Time `json:"created_at"`
+	UpdateAt      time.Time `json:"updated_at"`
 }
 
 // UserProfile представляє профіль користувача
@@ -25,6 +26,17 @@ type UserProfile struct {
 	Name     string            `json:"name"`
 	Picture  string            `json:"picture,omitempty"`
 	Metadata map[string]string `json:"metadata,omitempty"`
+
+	FollowersCount         int64 `json:"followers_count"`
+	FollowingCount         int64 `json:"following_count"`
+	FollowApprovalRequired bool  `json:"follow_approval_required"`
+	HidePresence           bool  `json:"hide_presence"`
+}
+
+// PrivacySettings представляє зміну налаштувань приватності; передані лише ті поля, які потрібно змінити
+type PrivacySettings struct {
+	FollowApprovalRequired *bool `json:"follow_approval_required"`
+	HidePresence           *bool `json:"hide_presence"`
 }
 
 // LoginRequest представляє запит на вхід через OIDC
@@ -44,6 +56,7 @@ type OIDCLoginResponse struct {
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
+	Scope    string `json:"scope,omitempty"` // API scopes через пробіл; порожнє значення — всі доступні
 }
 
 // LoginResponse представляє відповідь на успішний вхід
@@ -51,15 +64,20 @@ type LoginResponse struct {
 	UserID      string `json:"user_id"`
 	Email       string `json:"email"`
 	Name        string `json:"name"`
-	AccessToken string `json:"access_token"`
+	AccessToken string `json:"access_token,omitempty"` // у режимі cookie сесій не повертається
+	Scope       string `json:"scope"`
 	Message     string `json:"message"`
+	SessionID   string `json:"-"` // для cookie сесії; клієнту не повертається
+
+	// SessionExpiresAt момент завершення сесії без активності (абсолютний термін або idle timeout)
+	SessionExpiresAt *time.Time `json:"session_expires_at,omitempty"`
 }
 
 // RegisterRequest представляє запит на реєстрацію
 type RegisterRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Name     string `json:"name" binding:"required,min=2"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"` // вимоги визначає парольна політика
 }
 
 // RegisterResponse представляє відповідь на реєстрацію
@@ -70,3 +88,53 @@ type RegisterResponse struct {
 	Message string `json:"message"`
 	AuthURL string `json:"auth_url,omitempty"` // для автоматичного входу після реєстрації
 }
+
+// ResendVerificationRequest представляє запит на повторну відправку листа підтвердження
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ChangePasswordRequest представляє запит на зміну пароля.
+// CurrentPassword не потрібен користувачам без локального пароля (OIDC).
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// ChangeEmailRequest представляє запит на зміну email
+type ChangeEmailRequest struct {
+	NewEmail        string `json:"new_email" binding:"required,email"`
+	CurrentPassword string `json:"current_password"`
+}
+
+// CreatePersonalAccessTokenRequest представляє запит на створення personal access token
+type CreatePersonalAccessTokenRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1,max=365"` // 0 — без терміну дії
+}
+
+// AssignRoleRequest представляє запит на призначення ролі користувачу
+type AssignRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// ResetPasswordRequest представляє запит на встановлення нового пароля за токеном з листа
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// AdminUpdateUserRequest представляє зміну полів користувача адміністратором.
+// Передані лише ті поля, які потрібно змінити.
+type AdminUpdateUserRequest struct {
+	Name            *string `json:"name" binding:"omitempty,min=2"`
+	Email           *string `json:"email" binding:"omitempty,email"`
+	Picture         *string `json:"picture"`
+	IsEmailVerified *bool   `json:"is_email_verified"`
+}
+
+// ImpersonateRequest представляє запит адміністратора на імперсонацію користувача
+type ImpersonateRequest struct {
+	Reason string `json:"reason" binding:"required,min=5,max=500"`
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go-practice/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Дії, що фіксуються в журналі аудиту
+const (
+	AuditActionLogin                = "auth.login"
+	AuditActionLogout               = "auth.logout"
+	AuditActionRegister             = "auth.register"
+	AuditActionTokenRefresh         = "auth.token_refresh"
+	AuditActionPasswordReset        = "auth.password_reset"
+	AuditActionPasswordChange       = "account.password_change"
+	AuditActionEmailChangeRequest   = "account.email_change_request"
+	AuditActionEmailChange          = "account.email_change"
+	AuditActionTokenCreate          = "account.token_create"
+	AuditActionTokenRevoke          = "account.token_revoke"
+	AuditActionSessionRevoke        = "account.session_revoke"
+	AuditActionSessionRotate        = "auth.session_rotate"
+	AuditActionProfileUpdate        = "profile.update"
+	AuditActionFriendRequestSend    = "friends.request_send"
+	AuditActionFriendRequestAccept  = "friends.request_accept"
+	AuditActionFriendRequestDecline = "friends.request_decline"
+	AuditActionFriendRequestCancel  = "friends.request_cancel"
+	AuditActionFriendRemove         = "friends.remove"
+	AuditActionUserBlock            = "friends.block"
+	AuditActionUserUnblock          = "friends.unblock"
+	AuditActionFollow               = "follows.follow"
+	AuditActionUnfollow             = "follows.unfollow"
+	AuditActionFollowApprove        = "follows.approve"
+	AuditActionFollowDecline        = "follows.decline"
+	AuditActionPrivacyUpdate        = "profile.privacy_update"
+	AuditActionUserUpdate           = "admin.user_update"
+	AuditActionUserDeactivate       = "admin.user_deactivate"
+	AuditActionUserReactivate       = "admin.user_reactivate"
+	AuditActionUserLogout           = "admin.user_logout"
+	AuditActionUserPasswordReset    = "admin.user_password_reset"
+	AuditActionUserDelete           = "admin.user_delete"
+	AuditActionUserUnlock           = "admin.user_unlock"
+	AuditActionRoleAssign           = "admin.role_assign"
+	AuditActionRoleRemove           = "admin.role_remove"
+	AuditActionImpersonationStart   = "admin.impersonation_start"
+	AuditActionImpersonationRevoke  = "impersonation.revoke"
+)
+
+// Результати дій у журналі аудиту
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+)
+
+// defaultAuditQueryLimit та maxAuditQueryLimit обмежують розмір сторінки журналу
+const (
+	defaultAuditQueryLimit = 50
+	maxAuditQueryLimit     = 200
+)
+
+// AuditLog представляє незмінний запис журналу аудиту
+type AuditLog struct {
+	ID        uint64                 `gorm:"primaryKey;autoIncrement" json:"id"`
+	Action    string                 `gorm:"size:64;not null;index" json:"action"`
+	ActorID   string                 `gorm:"size:255;index" json:"actor_id,omitempty"`
+	SubjectID string                 `gorm:"size:255;index" json:"subject_id,omitempty"`
+	IPAddress string                 `gorm:"size:64" json:"ip_address,omitempty"`
+	UserAgent string                 `gorm:"size:512" json:"user_agent,omitempty"`
+	RequestID string                 `gorm:"size:64;index" json:"request_id,omitempty"`
+	Outcome   string                 `gorm:"size:16;not null" json:"outcome"`
+	Metadata  map[string]interface{} `gorm:"serializer:json;type:jsonb" json:"metadata,omitempty"`
+	CreatedAt time.Time              `gorm:"not null;index" json:"created_at"`
+}
+
+// TableName явно задає ім'я таблиці для GORM
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+// AuditEntry описує подію для запису в журнал
+type AuditEntry struct {
+	Action    string
+	ActorID   string // хто виконав дію; порожній для анонімних запитів
+	SubjectID string // над ким виконано дію
+	Outcome   string
+	Client    models.ClientInfo
+	Metadata  map[string]interface{}
+}
+
+// AuditFilter містить параметри вибірки журналу аудиту.
+// Cursor — ID останнього запису попередньої сторінки (записи йдуть від новіших до старіших).
+type AuditFilter struct {
+	Action    string
+	ActorID   string
+	SubjectID string
+	Outcome   string
+	Since     *time.Time
+	Until     *time.Time
+	Cursor    uint64
+	Limit     int
+}
+
+// AuditService інтерфейс для журналу аудиту подій безпеки
+type AuditService interface {
+	Record(entry AuditEntry)
+	Query(filter AuditFilter) ([]AuditLog, uint64, error)
+	PurgeOlderThan(cutoff time.Time) (int64, error)
+}
+
+// auditService реалізація AuditService (PostgreSQL)
+type auditService struct {
+	db        *gorm.DB
+	retention time.Duration
+}
+
+// NewAuditService створює новий AuditService.
+// Якщо purgeInterval > 0, записи старші за retention періодично видаляються.
+func NewAuditService(db *gorm.DB, retention, purgeInterval time.Duration) AuditService {
+	service := &auditService{
+		db:        db,
+		retention: retention,
+	}
+
+	if retention > 0 && purgeInterval > 0 {
+		go service.purgeRoutine(purgeInterval)
+	}
+
+	return service
+}
+
+// Record зберігає подію в журналі. Помилка запису не перериває основну дію —
+// подія все одно потрапляє в лог застосунку.
+// Дію в режимі імперсонації виконав адміністратор: він стає ActorID, а користувач потрапляє в metadata.
+func (s *auditService) Record(entry AuditEntry) {
+	if entry.Client.ImpersonatorID != "" {
+		metadata := make(map[string]interface{}, len(entry.Metadata)+2)
+		for key, value := range entry.Metadata {
+			metadata[key] = value
+		}
+		metadata["impersonator_id"] = entry.Client.ImpersonatorID
+		metadata["impersonated_user_id"] = entry.ActorID
+		entry.ActorID = entry.Client.ImpersonatorID
+		entry.Metadata = metadata
+	}
+
+	record := &AuditLog{
+		Action:    entry.Action,
+		ActorID:   entry.ActorID,
+		SubjectID: entry.SubjectID,
+		IPAddress: entry.Client.IPAddress,
+		UserAgent: truncate(entry.Client.UserAgent, 512),
+		RequestID: entry.Client.RequestID,
+		Outcome:   entry.Outcome,
+		Metadata:  entry.Metadata,
+		CreatedAt: time.Now(),
+	}
+
+	fields := logrus.Fields{
+		"actor_id":   entry.ActorID,
+		"subject_id": entry.SubjectID,
+		"outcome":    entry.Outcome,
+		"ip_address": entry.Client.IPAddress,
+		"request_id": entry.Client.RequestID,
+	}
+	for key, value := range entry.Metadata {
+		fields["meta_"+key] = value
+	}
+	logSecurityEvent(entry.Action, fields)
+
+	if err := s.db.Create(record).Error; err != nil {
+		logrus.WithError(err).WithField("audit_event", entry.Action).Error("Failed to store audit log entry")
+	}
+}
+
+// Query повертає сторінку журналу та курсор наступної сторінки (0 — більше записів немає)
+func (s *auditService) Query(filter AuditFilter) ([]AuditLog, uint64, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultAuditQueryLimit
+	}
+	if limit > maxAuditQueryLimit {
+		limit = maxAuditQueryLimit
+	}
+
+	query := s.db.Model(&AuditLog{})
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.ActorID != "" {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.SubjectID != "" {
+		query = query.Where("subject_id = ?", filter.SubjectID)
+	}
+	if filter.Outcome != "" {
+		query = query.Where("outcome = ?", filter.Outcome)
+	}
+	if filter.Since != nil {
+		query = query.Where("created_at >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		query = query.Where("created_at < ?", *filter.Until)
+	}
+	if filter.Cursor > 0 {
+		query = query.Where("id < ?", filter.Cursor)
+	}
+
+	// Беремо на один запис більше, щоб знати чи є наступна сторінка
+	var logs []AuditLog
+	if err := query.Order("id DESC").Limit(limit + 1).Find(&logs).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to query audit log: %w", err)
+	}
+
+	var nextCursor uint64
+	if len(logs) > limit {
+		logs = logs[:limit]
+		nextCursor = logs[limit-1].ID
+	}
+	return logs, nextCursor, nil
+}
+
+// PurgeOlderThan видаляє записи, створені до cutoff
+func (s *auditService) PurgeOlderThan(cutoff time.Time) (int64, error) {
+	result := s.db.Where("created_at < ?", cutoff).Delete(&AuditLog{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to purge audit log: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// purgeRoutine періодично видаляє записи, старші за термін зберігання
+func (s *auditService) purgeRoutine(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		purged, err := s.PurgeOlderThan(time.Now().Add(-s.retention))
+		if err != nil {
+			logrus.WithError(err).Error("Audit log retention purge failed")
+			continue
+		}
+		if purged > 0 {
+			logrus.WithField("purged", purged).Info("Expired audit log entries purged")
+		}
+	}
+}
+
+// truncate обрізає рядок до заданої довжини в байтах, не розриваючи UTF-8 символи
+func truncate(value string, max int) string {
+	if len(value) <= max {
+		return value
+	}
+	return strings.ToValidUTF8(value[:max], "")
+}
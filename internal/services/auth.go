@@ -1,33 +1,69 @@
 package services
 
 import (
+	"errors"
+	"sort"
+	"time"
+
 	"go-practice/internal/models"
 
 	"github.com/sirupsen/logrus"
 )
 
+var (
+	// ErrEmailNotVerified повертається коли вхід заборонено до підтвердження email
+	ErrEmailNotVerified = errors.New("email address is not verified")
+	// ErrProviderEmailNotVerified повертається коли OIDC провайдер не підтвердив email існуючого акаунта
+	ErrProviderEmailNotVerified = errors.New("identity provider has not verified the email address")
+	// ErrInvalidCredentials повертається при невдалому вході, включно з тимчасовим блокуванням
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	// ErrInvalidCurrentPassword повертається коли поточний пароль при зміні облікових даних невірний
+	ErrInvalidCurrentPassword = errors.New("current password is incorrect")
+	// ErrTokenRevoked повертається для токенів, виданих до останньої зміни облікових даних або з відкликаної сесії
+	ErrTokenRevoked = errors.New("token has been revoked")
+	// ErrSessionNotFound повертається коли сесія не існує або належить іншому користувачу
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+// sessionTouchInterval як часто використання сесії записується в сховище (продовження idle timeout)
+const sessionTouchInterval = time.Minute
+
 // authService реалізація AuthService
 type authService struct {
-	userService         UserService
-	jwtService          JWTService
-	stateService        StateService
-	oidcProviderService OIDCProviderService
-	sessionManager      SessionManager
+	userService              UserService
+	jwtService               JWTService
+	stateService             StateService
+	oidcProviderService      OIDCProviderService
+	sessionManager           SessionManager
+	emailVerificationService EmailVerificationService
+	loginAttemptService      LoginAttemptService
+	rbacService              RBACService
+	passwordResetService     PasswordResetService
+	auditService             AuditService
+	revocationStore          RevocationStore
+	requireVerifiedEmail     bool
 }
 
 // NewAuthService створює новий AuthService
-func NewAuthService(userService UserService, jwtService JWTService, stateService StateService, oidcProviderService OIDCProviderService, sessionManager SessionManager) AuthService {
+func NewAuthService(userService UserService, jwtService JWTService, stateService StateService, oidcProviderService OIDCProviderService, sessionManager SessionManager, emailVerificationService EmailVerificationService, loginAttemptService LoginAttemptService, rbacService RBACService, passwordResetService PasswordResetService, auditService AuditService, revocationStore RevocationStore, requireVerifiedEmail bool) AuthService {
 	return &authService{
-		userService:         userService,
-		jwtService:          jwtService,
-		stateService:        stateService,
-		oidcProviderService: oidcProviderService,
-		sessionManager:      sessionManager,
+		userService:              userService,
+		jwtService:               jwtService,
+		stateService:             stateService,
+		oidcProviderService:      oidcProviderService,
+		sessionManager:           sessionManager,
+		emailVerificationService: emailVerificationService,
+		loginAttemptService:      loginAttemptService,
+		rbacService:              rbacService,
+		passwordResetService:     passwordResetService,
+		auditService:             auditService,
+		revocationStore:          revocationStore,
+		requireVerifiedEmail:     requireVerifiedEmail,
 	}
 }
 
 // Register реєструє нового користувача
-func (s *authService) Register(req *models.RegisterRequest) (*models.RegisterResponse, error) {
+func (s *authService) Register(req *models.RegisterRequest, client models.ClientInfo) (*models.RegisterResponse, error) {
 	logrus.WithFields(logrus.Fields{
 		"email": req.Email,
 		"name":  req.Name,
@@ -37,49 +73,360 @@ func (s *authService) Register(req *models.RegisterRequest) (*models.RegisterRes
 	response, err := s.userService.RegisterUser(*req)
 	if err != nil {
 		logrus.WithError(err).Error("Failed to register user")
+		s.audit(AuditActionRegister, "", AuditOutcomeFailure, client, map[string]interface{}{
+			"email":  req.Email,
+			"reason": err.Error(),
+		})
 		return nil, err
 	}
+	s.audit(AuditActionRegister, response.UserID, AuditOutcomeSuccess, client, nil)
+
+	// Відправляємо лист підтвердження; помилка відправки не скасовує реєстрацію
+	user, err := s.userService.GetUserByID(response.UserID)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to load registered user for verification")
+	} else if err := s.emailVerificationService.SendVerification(user); err != nil {
+		logrus.WithError(err).WithField("user_id", user.ID).Error("Failed to send verification email")
+	}
 
 	logrus.WithField("user_id", response.UserID).Info("User registered successfully via AuthService")
 	return response, nil
 }
 
-func (s *authService) DefaultLogin(lr *models.LoginRequest) (*models.LoginResponse, error) {
-	user, err := s.userService.ValidatePassword(lr.Email, lr.Password)
+// VerifyEmail підтверджує email користувача за токеном з листа
+func (s *authService) VerifyEmail(token string, client models.ClientInfo) (*models.User, error) {
+	user, emailChanged, err := s.emailVerificationService.VerifyEmail(token)
 	if err != nil {
-		logrus.WithError(err).Error("Failed to validate password")
+		logrus.WithError(err).Warn("Email verification failed")
 		return nil, err
 	}
 
-	// Генеруємо токени для користувача
-	tokens, err := s.jwtService.GenerateTokens(user)
+	// Зміна email є зміною облікових даних — завершуємо всі сесії
+	if emailChanged {
+		if err := s.RevokeUserSessions(user.ID); err != nil {
+			return nil, err
+		}
+		s.audit(AuditActionEmailChange, user.ID, AuditOutcomeSuccess, client, map[string]interface{}{
+			"email": user.Email,
+		})
+	}
+
+	return toModelUser(user), nil
+}
+
+// ChangePassword змінює пароль користувача, відкликає всі сесії та видає нові токени поточному клієнту.
+// Нові токени отримують лише scopes, надані клієнту раніше.
+// Користувачі без локального пароля (OIDC) можуть встановити його без поточного пароля.
+func (s *authService) ChangePassword(userID, currentPassword, newPassword string, scopes []string, client models.ClientInfo) (*models.Token, error) {
+	user, err := s.userService.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	hadPassword := user.PasswordHash != ""
+	if hadPassword {
+		if err := s.verifyCurrentPassword(user, currentPassword, AuditActionPasswordChange, client); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.userService.SetPassword(user.ID, newPassword); err != nil {
+		s.audit(AuditActionPasswordChange, user.ID, AuditOutcomeFailure, client, map[string]interface{}{
+			"reason": err.Error(),
+		})
+		return nil, err
+	}
+
+	if err := s.RevokeUserSessions(user.ID); err != nil {
+		return nil, err
+	}
+
+	s.audit(AuditActionPasswordChange, user.ID, AuditOutcomeSuccess, client, map[string]interface{}{
+		"password_set": !hadPassword,
+	})
+
+	session, err := s.sessionManager.CreateSession(user.ID, client.IPAddress, client.UserAgent)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to create session after password change")
+		return nil, err
+	}
+	if err := s.sessionManager.UpdateSessionScopes(session.SessionID, scopes); err != nil {
+		logrus.WithError(err).Error("Failed to store session scopes after password change")
+		return nil, err
+	}
+
+	tokens, err := s.generateTokens(user, scopes, session.SessionID)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to generate tokens after password change")
+		return nil, err
+	}
+	setSessionExpiry(tokens, session)
+
+	return tokens, nil
+}
+
+// ChangeEmail відправляє лист підтвердження на нову адресу.
+// Email змінюється лише після переходу за посиланням з листа.
+func (s *authService) ChangeEmail(userID, currentPassword, newEmail string, client models.ClientInfo) error {
+	user, err := s.userService.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if user.PasswordHash != "" {
+		if err := s.verifyCurrentPassword(user, currentPassword, AuditActionEmailChangeRequest, client); err != nil {
+			return err
+		}
+	}
+
+	if existing, err := s.userService.GetUserByEmail(newEmail); err == nil && existing != nil {
+		return ErrEmailAlreadyInUse
+	}
+
+	if err := s.emailVerificationService.SendEmailChangeVerification(user, newEmail); err != nil {
+		logrus.WithError(err).WithField("user_id", user.ID).Error("Failed to send email change verification")
+		return err
+	}
+
+	s.audit(AuditActionEmailChangeRequest, user.ID, AuditOutcomeSuccess, client, map[string]interface{}{
+		"new_email": newEmail,
+	})
+	return nil
+}
+
+// RevokeUserSessions завершує всі сесії користувача і робить недійсними раніше видані токени
+func (s *authService) RevokeUserSessions(userID string) error {
+	// JWT зберігає час видачі з точністю до секунди, тому межу також округлюємо
+	validAfter := time.Now().Truncate(time.Second)
+	if err := s.userService.UpdateUser(userID, map[string]interface{}{
+		"tokens_valid_after": validAfter,
+	}); err != nil {
+		logrus.WithError(err).Error("Failed to revoke user tokens")
+		return err
+	}
+
+	sessions, err := s.sessionManager.GetUserSessions(userID)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to get user sessions")
+		return err
+	}
+	for _, session := range sessions {
+		if err := s.sessionManager.DeleteSession(session.SessionID); err != nil {
+			logrus.WithError(err).WithField("session_id", session.SessionID).Warn("Failed to delete session")
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"user_id":          userID,
+		"revoked_sessions": len(sessions),
+	}).Info("User sessions revoked")
+	return nil
+}
+
+// ListSessions повертає активні сесії користувача, починаючи з останньої використаної
+func (s *authService) ListSessions(userID string) ([]*SessionData, error) {
+	sessions, err := s.sessionManager.GetUserSessions(userID)
 	if err != nil {
-		logrus.WithError(err).Error("Failed to generate tokens")
 		return nil, err
 	}
 
-	// Створюємо сесію для користувача
-	_, err = s.sessionManager.CreateSession(user.ID, tokens.AccessToken, tokens.RefreshToken)
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+	return sessions, nil
+}
+
+// RevokeSession завершує сесію користувача; токени, видані в ній, одразу стають недійсними
+func (s *authService) RevokeSession(userID, sessionID string, client models.ClientInfo) error {
+	session, err := s.sessionManager.GetSession(sessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil || session.UserID != userID {
+		return ErrSessionNotFound
+	}
+
+	if err := s.revokeSession(session.SessionID); err != nil {
+		return err
+	}
+
+	s.audit(AuditActionSessionRevoke, userID, AuditOutcomeSuccess, client, map[string]interface{}{
+		"session_id": session.SessionID,
+	})
+	return nil
+}
+
+// RevokeOtherSessions завершує всі сесії користувача, крім поточної, і повертає їх кількість
+func (s *authService) RevokeOtherSessions(userID, currentSessionID string, client models.ClientInfo) (int, error) {
+	sessions, err := s.sessionManager.GetUserSessions(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	revoked := 0
+	for _, session := range sessions {
+		if session.SessionID == currentSessionID {
+			continue
+		}
+		if err := s.revokeSession(session.SessionID); err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+
+	s.audit(AuditActionSessionRevoke, userID, AuditOutcomeSuccess, client, map[string]interface{}{
+		"except_current":   currentSessionID,
+		"revoked_sessions": revoked,
+	})
+	return revoked, nil
+}
+
+// revokeSession відкликає токени сесії до кінця терміну дії refresh token і видаляє саму сесію
+func (s *authService) revokeSession(sessionID string) error {
+	if err := s.revocationStore.Revoke(sessionID, time.Now().Add(RefreshTokenTTL)); err != nil {
+		return err
+	}
+	return s.sessionManager.DeleteSession(sessionID)
+}
+
+// ResetPassword встановлює новий пароль за токеном скидання і завершує всі сесії користувача
+func (s *authService) ResetPassword(token, newPassword string, client models.ClientInfo) error {
+	user, err := s.passwordResetService.ResetPassword(token, newPassword)
+	if err != nil {
+		logrus.WithError(err).Warn("Password reset failed")
+		s.audit(AuditActionPasswordReset, "", AuditOutcomeFailure, client, map[string]interface{}{
+			"reason": err.Error(),
+		})
+		return err
+	}
+
+	if err := s.RevokeUserSessions(user.ID); err != nil {
+		return err
+	}
+
+	s.audit(AuditActionPasswordReset, user.ID, AuditOutcomeSuccess, client, nil)
+	return nil
+}
+
+// verifyCurrentPassword перевіряє поточний пароль користувача; відмова фіксується в журналі як невдала action.
+// Спроби рахуються тими ж лічильниками, що й вхід, тож викрадений токен не дозволяє перебирати пароль.
+func (s *authService) verifyCurrentPassword(user *User, password, action string, client models.ClientInfo) error {
+	// Поки діє блокування, пароль все одно перевіряється, щоб час відповіді не видавав блокування
+	if err := s.loginAttemptService.Check(user.Email, client.IPAddress); err != nil {
+		_, _ = s.userService.ValidatePassword(user.Email, password)
+		s.audit(action, user.ID, AuditOutcomeFailure, client, map[string]interface{}{
+			"reason": "throttled",
+		})
+		return ErrInvalidCurrentPassword
+	}
+
+	if _, err := s.userService.ValidatePassword(user.Email, password); err != nil {
+		s.loginAttemptService.RecordFailure(user.Email, client.IPAddress)
+		s.audit(action, user.ID, AuditOutcomeFailure, client, map[string]interface{}{
+			"reason": "invalid_current_password",
+		})
+		return ErrInvalidCurrentPassword
+	}
+
+	s.loginAttemptService.RecordSuccess(user.Email, client.IPAddress)
+	return nil
+}
+
+// ResendVerification повторно відправляє лист підтвердження email
+func (s *authService) ResendVerification(email string) error {
+	return s.emailVerificationService.ResendVerification(email)
+}
+
+func (s *authService) DefaultLogin(lr *models.LoginRequest, client models.ClientInfo) (*models.LoginResponse, error) {
+	scopes, err := ParseScopes(lr.Scope, APIScopes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Поки діє блокування, пароль все одно перевіряється, щоб час відповіді не видавав блокування
+	if err := s.loginAttemptService.Check(lr.Email, client.IPAddress); err != nil {
+		_, _ = s.userService.ValidatePassword(lr.Email, lr.Password)
+		s.audit(AuditActionLogin, "", AuditOutcomeFailure, client, map[string]interface{}{
+			"email":  lr.Email,
+			"reason": "throttled",
+		})
+		return nil, ErrInvalidCredentials
+	}
+
+	user, err := s.userService.ValidatePassword(lr.Email, lr.Password)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to validate password")
+		s.loginAttemptService.RecordFailure(lr.Email, client.IPAddress)
+		s.audit(AuditActionLogin, "", AuditOutcomeFailure, client, map[string]interface{}{
+			"email":  lr.Email,
+			"reason": "invalid_credentials",
+		})
+		return nil, ErrInvalidCredentials
+	}
+
+	s.loginAttemptService.RecordSuccess(lr.Email, client.IPAddress)
+
+	if s.requireVerifiedEmail && !user.IsEmailVerified {
+		logrus.WithField("user_id", user.ID).Warn("Login rejected: email not verified")
+		s.audit(AuditActionLogin, user.ID, AuditOutcomeFailure, client, map[string]interface{}{
+			"reason": "email_not_verified",
+		})
+		return nil, ErrEmailNotVerified
+	}
+
+	if user.PasswordResetRequired {
+		logrus.WithField("user_id", user.ID).Warn("Login rejected: password reset required")
+		s.audit(AuditActionLogin, user.ID, AuditOutcomeFailure, client, map[string]interface{}{
+			"reason": "password_reset_required",
+		})
+		return nil, ErrPasswordResetRequired
+	}
+
+	// Створюємо сесію для користувача; токени прив'язуються до неї через sid
+	session, err := s.sessionManager.CreateSession(user.ID, client.IPAddress, client.UserAgent)
 	if err != nil {
 		logrus.WithError(err).Error("Failed to create session")
 		return nil, err
 	}
+	if err := s.sessionManager.UpdateSessionScopes(session.SessionID, scopes); err != nil {
+		logrus.WithError(err).Error("Failed to store session scopes")
+		return nil, err
+	}
+
+	// Генеруємо токени для користувача
+	tokens, err := s.generateTokens(user, scopes, session.SessionID)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to generate tokens")
+		return nil, err
+	}
 
 	response := &models.LoginResponse{
 		UserID:      user.ID,
 		Email:       user.Email,
 		Name:        user.Name,
 		AccessToken: tokens.AccessToken,
+		Scope:       tokens.Scope,
 		Message:     "Login successful",
+		SessionID:   session.SessionID,
 	}
+	expiresAt := session.Deadline()
+	response.SessionExpiresAt = &expiresAt
 
+	s.audit(AuditActionLogin, user.ID, AuditOutcomeSuccess, client, map[string]interface{}{
+		"method": "password",
+	})
 	logrus.Info("User logged in successfully")
 	return response, nil
 }
 
-func (s *authService) Login(redirectURI string) (*models.OIDCLoginResponse, error) {
+func (s *authService) Login(redirectURI, scope string) (*models.OIDCLoginResponse, error) {
 	logrus.Info("AuthService: Login called")
 
+	scopes, err := ParseScopes(scope, APIScopes)
+	if err != nil {
+		return nil, err
+	}
+
 	// Створюємо сесію для відстеження OIDC flow
 	session, err := s.sessionManager.CreateSession("", "", "") // UserID буде оновлений після успішної автентифікації
 	if err != nil {
@@ -87,6 +434,12 @@ func (s *authService) Login(redirectURI string) (*models.OIDCLoginResponse, erro
 		return nil, err
 	}
 
+	// Запам'ятовуємо запитані scopes до завершення callback
+	if err := s.sessionManager.UpdateSessionScopes(session.SessionID, scopes); err != nil {
+		logrus.WithError(err).Error("Failed to store requested scopes")
+		return nil, err
+	}
+
 	// Генеруємо state для CSRF захисту, використовуючи session ID
 	state, err := s.stateService.GenerateState(session.SessionID)
 	if err != nil {
@@ -118,7 +471,7 @@ func (s *authService) Login(redirectURI string) (*models.OIDCLoginResponse, erro
 }
 
 // HandleCallback обробляє callback від OIDC провайдера
-func (s *authService) HandleCallback(code, state string) (*models.Token, *models.User, error) {
+func (s *authService) HandleCallback(code, state string, client models.ClientInfo) (*models.Token, *models.User, error) {
 	logrus.WithFields(logrus.Fields{
 		"code":  code[:10] + "...",
 		"state": state[:10] + "...",
@@ -156,41 +509,68 @@ func (s *authService) HandleCallback(code, state string) (*models.Token, *models
 		return nil, nil, err
 	}
 
+	// Акаунт з непідтвердженим email міг зареєструвати не власник адреси
+	previous, _ := s.userService.GetUserByEmail(idTokenClaims.Email)
+
 	// Створюємо або оновлюємо користувача в нашій системі
 	user, err := s.userService.CreateOrUpdateFromOIDC(
 		idTokenClaims.UserID,
 		idTokenClaims.Email,
 		idTokenClaims.Name,
 		idTokenClaims.Picture,
+		idTokenClaims.EmailVerified,
 	)
 	if err != nil {
 		logrus.WithError(err).Error("Failed to create/update user from OIDC")
+		if errors.Is(err, ErrProviderEmailNotVerified) && previous != nil {
+			s.audit(AuditActionLogin, previous.ID, AuditOutcomeFailure, client, map[string]interface{}{
+				"method": "oidc",
+				"reason": "provider_email_not_verified",
+			})
+		}
 		return nil, nil, err
 	}
 
+	// Власник адреси підтвердив її через провайдера — сесії та токени, видані до цього, відкликаються
+	if previous != nil && !previous.IsEmailVerified && user.IsEmailVerified {
+		if err := s.RevokeUserSessions(user.ID); err != nil {
+			return nil, nil, err
+		}
+		s.audit(AuditActionSessionRevoke, user.ID, AuditOutcomeSuccess, client, map[string]interface{}{
+			"reason":           "email_verified_via_oidc",
+			"password_cleared": previous.PasswordHash != "",
+		})
+	}
+
+	if s.requireVerifiedEmail && !user.IsEmailVerified {
+		logrus.WithField("user_id", user.ID).Warn("OIDC login rejected: email not verified")
+		s.audit(AuditActionLogin, user.ID, AuditOutcomeFailure, client, map[string]interface{}{
+			"method": "oidc",
+			"reason": "email_not_verified",
+		})
+		return nil, nil, ErrEmailNotVerified
+	}
+
 	// Оновлюємо сесію з user ID
-	err = s.sessionManager.UpdateSessionUser(sessionID, user.ID)
+	err = s.sessionManager.UpdateSessionUser(sessionID, user.ID, client)
 	if err != nil {
 		logrus.WithError(err).Error("Failed to update session with user ID")
 	}
 
 	// Генеруємо наші внутрішні JWT токени
-	tokens, err := s.jwtService.GenerateTokens(user)
+	tokens, err := s.generateTokens(user, session.Scopes, sessionID)
 	if err != nil {
 		logrus.WithError(err).Error("Failed to generate internal tokens")
 		return nil, nil, err
 	}
+	setSessionExpiry(tokens, session)
 
 	// Конвертуємо user в models.User
-	modelUser := &models.User{
-		ID:       user.ID,
-		Email:    user.Email,
-		Name:     user.Name,
-		Picture:  user.Picture,
-		CreateAt: user.CreatedAt,
-		UpdateAt: user.UpdatedAt,
-	}
+	modelUser := toModelUser(user)
 
+	s.audit(AuditActionLogin, user.ID, AuditOutcomeSuccess, client, map[string]interface{}{
+		"method": "oidc",
+	})
 	logrus.WithFields(logrus.Fields{
 		"user_id":    user.ID,
 		"session_id": sessionID,
@@ -199,8 +579,8 @@ func (s *authService) HandleCallback(code, state string) (*models.Token, *models
 	return tokens, modelUser, nil
 }
 
-// Logout завершує сесію користувача
-func (s *authService) Logout(userID string) error {
+// Logout завершує сесію користувача; якщо sessionID відомий, токени цієї сесії відкликаються
+func (s *authService) Logout(userID, sessionID string, client models.ClientInfo) error {
 	logrus.WithField("userID", userID).Info("AuthService: Logout called")
 
 	// Перевіряємо чи користувач існує
@@ -210,22 +590,47 @@ func (s *authService) Logout(userID string) error {
 		return err
 	}
 
-	// TODO: Implement token blacklisting/invalidation
-	// TODO: Remove user sessions from Redis/DB
+	if sessionID != "" {
+		if err := s.revokeSession(sessionID); err != nil {
+			logrus.WithError(err).WithField("session_id", sessionID).Error("Failed to revoke session on logout")
+			return err
+		}
+	}
+
 	// TODO: Notify OIDC provider about logout (if required)
 
+	s.audit(AuditActionLogout, userID, AuditOutcomeSuccess, client, nil)
+
 	logrus.WithField("user_id", userID).Info("User logged out successfully")
 	return nil
 }
 
+// LogoutByToken завершує сесію, до якої прив'язаний access token (claim sid); токени цієї сесії одразу відкликаються
+func (s *authService) LogoutByToken(accessToken string, client models.ClientInfo) error {
+	// GetUserInfo відхиляє прострочені та вже відкликані токени
+	user, err := s.GetUserInfo(accessToken)
+	if err != nil {
+		return err
+	}
+
+	claims, err := s.jwtService.ParseAccessToken(accessToken)
+	if err != nil {
+		return err
+	}
+	return s.Logout(user.ID, claims.SessionID, client)
+}
+
 // RefreshToken оновлює access token
-func (s *authService) RefreshToken(refreshToken string) (*models.Token, error) {
+func (s *authService) RefreshToken(refreshToken, scope string, client models.ClientInfo) (*models.Token, error) {
 	logrus.Info("AuthService: RefreshToken called")
 
 	// Валідуємо refresh token
 	refreshClaims, err := s.jwtService.ValidateRefreshToken(refreshToken)
 	if err != nil {
 		logrus.WithError(err).Error("Invalid refresh token")
+		s.audit(AuditActionTokenRefresh, "", AuditOutcomeFailure, client, map[string]interface{}{
+			"reason": "invalid_token",
+		})
 		return nil, err
 	}
 
@@ -236,13 +641,54 @@ func (s *authService) RefreshToken(refreshToken string) (*models.Token, error) {
 		return nil, err
 	}
 
-	// Генеруємо нові токени
-	tokens, err := s.jwtService.GenerateTokens(user)
+	// Refresh token, виданий до зміни облікових даних або з відкликаної сесії, більше не дійсний
+	if refreshClaims.IssuedAt == nil || user.IsTokenRevoked(refreshClaims.IssuedAt.Time) || s.isSessionRevoked(refreshClaims.SessionID) {
+		logrus.WithField("user_id", user.ID).Warn("Refresh token revoked")
+		s.audit(AuditActionTokenRefresh, user.ID, AuditOutcomeFailure, client, map[string]interface{}{
+			"reason": "revoked",
+		})
+		return nil, ErrTokenRevoked
+	}
+
+	// Сесія, що завершилась за абсолютним терміном або idle timeout, не може оновлювати токени
+	var session *SessionData
+	if refreshClaims.SessionID != "" {
+		session, err = s.sessionManager.GetSession(refreshClaims.SessionID)
+		if err != nil {
+			logrus.WithError(err).Error("Failed to get session for refresh")
+			return nil, err
+		}
+		if session == nil {
+			logrus.WithField("user_id", user.ID).Warn("Refresh token of expired session")
+			s.audit(AuditActionTokenRefresh, user.ID, AuditOutcomeFailure, client, map[string]interface{}{
+				"reason": "session_expired",
+			})
+			return nil, ErrTokenRevoked
+		}
+	}
+
+	// Новий токен може мати лише scopes початкового (старі refresh токени без scope мають усі)
+	allowed := refreshClaims.Scope
+	if len(allowed) == 0 {
+		allowed = APIScopes
+	}
+	scopes, err := ParseScopes(scope, allowed)
+	if err != nil {
+		return nil, err
+	}
+
+	// Генеруємо нові токени в межах тієї ж сесії
+	tokens, err := s.generateTokens(user, scopes, refreshClaims.SessionID)
 	if err != nil {
 		logrus.WithError(err).Error("Failed to generate new tokens")
 		return nil, err
 	}
 
+	if session != nil {
+		setSessionExpiry(tokens, s.touchSession(session, client.IPAddress))
+	}
+
+	s.audit(AuditActionTokenRefresh, user.ID, AuditOutcomeSuccess, client, nil)
 	logrus.WithField("user_id", user.ID).Info("Tokens refreshed successfully")
 	return tokens, nil
 }
@@ -252,11 +698,12 @@ func (s *authService) GetUserInfo(accessToken string) (*models.User, error) {
 	logrus.Info("AuthService: GetUserInfo called")
 
 	// Валідуємо access token через JWTService
-	userID, err := s.jwtService.GetUserIDFromToken(accessToken)
+	claims, err := s.jwtService.ParseAccessToken(accessToken)
 	if err != nil {
 		logrus.WithError(err).Error("Invalid access token")
 		return nil, err
 	}
+	userID := claims.UserID
 
 	// Отримуємо користувача з бази даних
 	user, err := s.userService.GetUserByID(userID)
@@ -265,19 +712,157 @@ func (s *authService) GetUserInfo(accessToken string) (*models.User, error) {
 		return nil, err
 	}
 
+	if claims.IssuedAt == nil || user.IsTokenRevoked(claims.IssuedAt.Time) || s.isSessionRevoked(claims.SessionID) {
+		return nil, ErrTokenRevoked
+	}
+
 	logrus.WithField("user_id", userID).Info("User info retrieved successfully")
 
 	// Конвертуємо services.User в models.User
-	modelUser := &models.User{
-		ID:       user.ID,
-		Email:    user.Email,
-		Name:     user.Name,
-		Picture:  user.Picture,
-		CreateAt: user.CreatedAt,
-		UpdateAt: user.UpdatedAt,
+	return toModelUser(user), nil
+}
+
+// AuthenticateSession перевіряє сесію з cookie і повертає її разом з користувачем.
+// Якщо ролі користувача змінились після видачі ID сесії, сесія отримує новий ID.
+func (s *authService) AuthenticateSession(sessionID string, client models.ClientInfo) (*SessionData, *User, error) {
+	if s.isSessionRevoked(sessionID) {
+		return nil, nil, ErrSessionNotFound
 	}
 
-	return modelUser, nil
+	session, err := s.sessionManager.GetSession(sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if session == nil || session.UserID == "" {
+		return nil, nil, ErrSessionNotFound
+	}
+
+	user, err := s.userService.GetUserByID(session.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if user.NeedsSessionRotation(session.CreatedAt) {
+		session, err = s.rotateSession(user.ID, sessionID, client)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return s.touchSession(session, client.IPAddress), user, nil
+}
+
+// ValidateTokenSession перевіряє, що сесія, до якої прив'язаний токен, ще існує і належить користувачу.
+// Використання токена продовжує idle timeout сесії.
+func (s *authService) ValidateTokenSession(userID, sessionID string, client models.ClientInfo) (*SessionData, error) {
+	session, err := s.sessionManager.GetSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || session.UserID != userID {
+		return nil, ErrSessionNotFound
+	}
+
+	return s.touchSession(session, client.IPAddress), nil
+}
+
+// touchSession продовжує idle timeout сесії. Щоб не писати в сховище на кожен запит,
+// запис виконується не частіше ніж раз на sessionTouchInterval або при зміні IP адреси.
+func (s *authService) touchSession(session *SessionData, ipAddress string) *SessionData {
+	ipChanged := ipAddress != "" && ipAddress != session.IPAddress
+	if !ipChanged && time.Since(session.LastSeenAt) < sessionTouchInterval {
+		return session
+	}
+
+	touched, err := s.sessionManager.TouchSession(session.SessionID, ipAddress)
+	if err != nil {
+		logrus.WithError(err).WithField("session_id", session.SessionID).Warn("Failed to touch session")
+		return session
+	}
+	if touched == nil {
+		return session
+	}
+	return touched
+}
+
+// rotateSession видає сесії новий ID і відкликає старий разом з токенами, виданими в ньому
+func (s *authService) rotateSession(userID, sessionID string, client models.ClientInfo) (*SessionData, error) {
+	rotated, err := s.sessionManager.RotateSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if rotated == nil {
+		return nil, ErrSessionNotFound
+	}
+
+	if err := s.revocationStore.Revoke(sessionID, time.Now().Add(RefreshTokenTTL)); err != nil {
+		return nil, err
+	}
+
+	s.audit(AuditActionSessionRotate, userID, AuditOutcomeSuccess, client, map[string]interface{}{
+		"reason": "privileges_changed",
+	})
+	return rotated, nil
+}
+
+// audit записує в журнал дію, яку користувач виконує над власним акаунтом
+func (s *authService) audit(action, userID, outcome string, client models.ClientInfo, metadata map[string]interface{}) {
+	s.auditService.Record(AuditEntry{
+		Action:    action,
+		ActorID:   userID,
+		SubjectID: userID,
+		Outcome:   outcome,
+		Client:    client,
+		Metadata:  metadata,
+	})
+}
+
+// generateTokens випускає токени сесії з поточними ролями користувача та наданими scopes
+func (s *authService) generateTokens(user *User, scopes []string, sessionID string) (*models.Token, error) {
+	roles, err := s.rbacService.GetUserRoles(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.jwtService.GenerateTokens(user, TokenOptions{Roles: roles, Scopes: scopes, SessionID: sessionID})
+	if err != nil {
+		return nil, err
+	}
+	tokens.SessionID = sessionID
+	return tokens, nil
+}
+
+// isSessionRevoked перевіряє чи сесію токена відкликано (токени без sid не прив'язані до сесії)
+func (s *authService) isSessionRevoked(sessionID string) bool {
+	if sessionID == "" {
+		return false
+	}
+	revoked, err := s.revocationStore.IsRevoked(sessionID)
+	if err != nil {
+		// Якщо сховище недоступне, безпечніше відхилити токен
+		logrus.WithError(err).Error("Failed to check session revocation")
+		return true
+	}
+	return revoked
+}
+
+// setSessionExpiry додає до токенів момент, коли сесія завершиться без подальшої активності
+func setSessionExpiry(tokens *models.Token, session *SessionData) {
+	expiresAt := session.Deadline()
+	tokens.SessionExpiresAt = &expiresAt
+}
+
+// toModelUser конвертує services.User в models.User
+func toModelUser(user *User) *models.User {
+	return &models.User{
+		ID:            user.ID,
+		Email:         user.Email,
+		EmailVerified: user.IsEmailVerified,
+		Name:          user.Name,
+		Picture:       user.Picture,
+		CreateAt:      user.CreatedAt,
+		UpdateAt:      user.UpdatedAt,
+	}
 }
 
 // generateRandomString генерує випадковий рядок заданої довжини
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidVerificationToken повертається коли токен верифікації не знайдено або вже використано
+	ErrInvalidVerificationToken = errors.New("invalid or already used verification token")
+	// ErrVerificationTokenExpired повертається коли термін дії токена верифікації минув
+	ErrVerificationTokenExpired = errors.New("verification token expired")
+	// ErrEmailAlreadyInUse повертається коли email вже належить іншому користувачу
+	ErrEmailAlreadyInUse = errors.New("email address is already in use")
+)
+
+// resendCooldown мінімальний інтервал між повторними відправками листа
+const resendCooldown = time.Minute
+
+// EmailVerificationToken представляє одноразовий токен підтвердження email
+type EmailVerificationToken struct {
+	ID            uint       `gorm:"primaryKey;autoIncrement"`
+	UserID        string     `gorm:"size:255;not null;index"`
+	Email         string     `gorm:"size:255;not null"`
+	IsEmailChange bool       `gorm:"default:false"` // токен підтверджує нову адресу при зміні email
+	TokenHash     string     `gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt     time.Time  `gorm:"not null;index"`
+	UsedAt        *time.Time `gorm:"index"`
+	CreatedAt     time.Time
+}
+
+// TableName явно задає ім'я таблиці для GORM
+func (EmailVerificationToken) TableName() string {
+	return "email_verification_tokens"
+}
+
+// EmailVerificationService інтерфейс для підтвердження email адрес
+type EmailVerificationService interface {
+	SendVerification(user *User) error
+	SendEmailChangeVerification(user *User, newEmail string) error
+	VerifyEmail(token string) (user *User, emailChanged bool, err error)
+	ResendVerification(email string) error
+}
+
+// emailVerificationService реалізація EmailVerificationService
+type emailVerificationService struct {
+	db          *gorm.DB
+	userService UserService
+	mailer      Mailer
+	ttl         time.Duration
+	verifyURL   string
+}
+
+// NewEmailVerificationService створює новий EmailVerificationService
+func NewEmailVerificationService(db *gorm.DB, userService UserService, mailer Mailer, ttl time.Duration, verifyURL string) EmailVerificationService {
+	return &emailVerificationService{
+		db:          db,
+		userService: userService,
+		mailer:      mailer,
+		ttl:         ttl,
+		verifyURL:   verifyURL,
+	}
+}
+
+// SendVerification створює токен і відправляє лист з посиланням для підтвердження
+func (s *emailVerificationService) SendVerification(user *User) error {
+	return s.send(user, user.Email, false)
+}
+
+// SendEmailChangeVerification відправляє посилання для підтвердження нової адреси.
+// Email користувача змінюється лише після переходу за посиланням.
+func (s *emailVerificationService) SendEmailChangeVerification(user *User, newEmail string) error {
+	return s.send(user, newEmail, true)
+}
+
+// send створює токен для адреси email і відправляє лист з посиланням
+func (s *emailVerificationService) send(user *User, email string, isEmailChange bool) error {
+	token, err := generateOpaqueToken("", 32)
+	if err != nil {
+		return fmt.Errorf("failed to generate verification token: %w", err)
+	}
+
+	now := time.Now()
+	record := EmailVerificationToken{
+		UserID:        user.ID,
+		Email:         email,
+		IsEmailChange: isEmailChange,
+		TokenHash:     hashToken(token),
+		ExpiresAt:     now.Add(s.ttl),
+		CreatedAt:     now,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Попередні невикористані токени того ж типу більше не дійсні
+		if err := tx.Model(&EmailVerificationToken{}).
+			Where("user_id = ? AND is_email_change = ? AND used_at IS NULL", user.ID, isEmailChange).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Create(&record).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store verification token: %w", err)
+	}
+
+	link := s.verifyURL + "?token=" + token
+	body := fmt.Sprintf("Hello %s,\n\nPlease confirm your email address by opening the link below:\n\n%s\n\nThe link expires in %s.\n",
+		user.Name, link, s.ttl)
+
+	if err := s.mailer.Send(email, "Confirm your email address", body); err != nil {
+		return err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"user_id":         user.ID,
+		"is_email_change": isEmailChange,
+		"expires_at":      record.ExpiresAt,
+	}).Info("Verification email sent")
+
+	return nil
+}
+
+// VerifyEmail перевіряє токен і позначає email користувача як підтверджений.
+// Для токенів зміни email також замінює адресу користувача на нову.
+func (s *emailVerificationService) VerifyEmail(token string) (*User, bool, error) {
+	var record EmailVerificationToken
+	err := s.db.Where("token_hash = ? AND used_at IS NULL", hashToken(token)).First(&record).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, false, ErrInvalidVerificationToken
+		}
+		return nil, false, fmt.Errorf("failed to get verification token: %w", err)
+	}
+
+	if time.Now().After(record.ExpiresAt) {
+		return nil, false, ErrVerificationTokenExpired
+	}
+
+	user, err := s.userService.GetUserByID(record.UserID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Токен видано для іншої адреси — email вже змінився
+	if !record.IsEmailChange && record.Email != user.Email {
+		return nil, false, ErrInvalidVerificationToken
+	}
+
+	emailChanged := record.IsEmailChange && record.Email != user.Email
+
+	now := time.Now()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&EmailVerificationToken{}).
+			Where("id = ? AND used_at IS NULL", record.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidVerificationToken
+		}
+
+		updates := map[string]interface{}{
+			"is_email_verified": true,
+			"updated_at":        now,
+		}
+
+		if emailChanged {
+			var taken int64
+			if err := tx.Model(&User{}).Where("email = ? AND id <> ?", record.Email, user.ID).Count(&taken).Error; err != nil {
+				return err
+			}
+			if taken > 0 {
+				return ErrEmailAlreadyInUse
+			}
+			updates["email"] = record.Email
+		}
+
+		return tx.Model(&User{}).Where("id = ?", user.ID).Updates(updates).Error
+	})
+	if err != nil {
+		if errors.Is(err, ErrInvalidVerificationToken) || errors.Is(err, ErrEmailAlreadyInUse) {
+			return nil, false, err
+		}
+		return nil, false, fmt.Errorf("failed to verify email: %w", err)
+	}
+
+	user.IsEmailVerified = true
+	if emailChanged {
+		logrus.WithFields(logrus.Fields{
+			"user_id":   user.ID,
+			"old_email": user.Email,
+			"new_email": record.Email,
+		}).Info("Email address changed")
+		user.Email = record.Email
+	}
+
+	logrus.WithField("user_id", user.ID).Info("Email verified successfully")
+	return user, emailChanged, nil
+}
+
+// ResendVerification повторно відправляє лист підтвердження.
+// Не повідомляє викликача, чи існує користувач з таким email.
+func (s *emailVerificationService) ResendVerification(email string) error {
+	user, err := s.userService.GetUserByEmail(email)
+	if err != nil {
+		logrus.WithError(err).Debug("Resend verification requested for unknown email")
+		return nil
+	}
+
+	if user.IsEmailVerified {
+		return nil
+	}
+
+	var recent int64
+	err = s.db.Model(&EmailVerificationToken{}).
+		Where("user_id = ? AND is_email_change = ? AND created_at > ?", user.ID, false, time.Now().Add(-resendCooldown)).
+		Count(&recent).Error
+	if err != nil {
+		return fmt.Errorf("failed to check recent verification tokens: %w", err)
+	}
+	if recent > 0 {
+		logrus.WithField("user_id", user.ID).Info("Verification email recently sent, skipping resend")
+		return nil
+	}
+
+	return s.SendVerification(user)
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go-practice/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Статуси підписки
+const (
+	FollowPending  = "pending"
+	FollowAccepted = "accepted"
+)
+
+var (
+	// ErrCannotFollowSelf повертається при спробі підписатися на самого себе
+	ErrCannotFollowSelf = errors.New("cannot follow yourself")
+	// ErrFollowBlocked повертається коли один з користувачів заблокував іншого
+	ErrFollowBlocked = errors.New("follow blocked")
+	// ErrFollowNotFound повертається коли підписки (або запиту на підписку) немає
+	ErrFollowNotFound = errors.New("follow not found")
+)
+
+// Follow одностороння підписка FollowerID на FolloweeID.
+// Якщо FolloweeID вимагає схвалення, підписка до схвалення має статус pending.
+type Follow struct {
+	ID         uint64    `gorm:"primaryKey;autoIncrement" json:"id"`
+	FollowerID string    `gorm:"size:255;not null;uniqueIndex:idx_follows_pair" json:"follower_id"`
+	FolloweeID string    `gorm:"size:255;not null;uniqueIndex:idx_follows_pair;index:idx_follows_followee_status,priority:1" json:"followee_id"`
+	Status     string    `gorm:"size:16;not null;index:idx_follows_followee_status,priority:2" json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+
+	// Заповнюється у списку запитів на підписку
+	Follower *User `gorm:"foreignKey:FollowerID;-:migration" json:"follower,omitempty"`
+}
+
+// TableName явно задає ім'я таблиці для GORM
+func (Follow) TableName() string {
+	return "follows"
+}
+
+// Follow підписує followerID на followeeID. Повторна підписка повертає наявний запис і created = false.
+func (s *userService) Follow(followerID, followeeID string) (follow *Follow, created bool, err error) {
+	if followerID == followeeID {
+		return nil, false, ErrCannotFollowSelf
+	}
+
+	followee, err := s.GetUserByID(followeeID)
+	if err != nil {
+		return nil, false, ErrUserNotFound
+	}
+
+	blocked, err := isBlockedBetween(s.db, followerID, followeeID)
+	if err != nil {
+		return nil, false, err
+	}
+	if blocked {
+		return nil, false, ErrFollowBlocked
+	}
+
+	status := FollowAccepted
+	if followee.FollowApprovalRequired {
+		status = FollowPending
+	}
+
+	follow = &Follow{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+		Status:     status,
+	}
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(follow)
+	if result.Error != nil {
+		return nil, false, fmt.Errorf("failed to follow user: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		if err := s.db.Where("follower_id = ? AND followee_id = ?", followerID, followeeID).First(follow).Error; err != nil {
+			return nil, false, fmt.Errorf("failed to follow user: %w", err)
+		}
+		return follow, false, nil
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"follower_id": followerID,
+		"followee_id": followeeID,
+		"status":      follow.Status,
+	}).Info("User followed")
+
+	return follow, true, nil
+}
+
+// Unfollow скасовує підписку або запит на підписку
+func (s *userService) Unfollow(followerID, followeeID string) error {
+	result := s.db.Where("follower_id = ? AND followee_id = ?", followerID, followeeID).Delete(&Follow{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to unfollow user: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrFollowNotFound
+	}
+	return nil
+}
+
+// GetFollowers повертає сторінку підписників користувача (лише схвалені підписки) і їх загальну кількість
+func (s *userService) GetFollowers(userID string, limit, offset int) ([]User, int64, error) {
+	return s.followUsers(
+		s.db.Model(&Follow{}).Select("follower_id").Where("followee_id = ? AND status = ?", userID, FollowAccepted),
+		limit, offset,
+	)
+}
+
+// GetFollowing повертає сторінку користувачів, на яких підписаний userID (лише схвалені підписки), і їх загальну кількість
+func (s *userService) GetFollowing(userID string, limit, offset int) ([]User, int64, error) {
+	return s.followUsers(
+		s.db.Model(&Follow{}).Select("followee_id").Where("follower_id = ? AND status = ?", userID, FollowAccepted),
+		limit, offset,
+	)
+}
+
+// followUsers повертає сторінку активних користувачів з ID з підзапиту ids
+func (s *userService) followUsers(ids *gorm.DB, limit, offset int) ([]User, int64, error) {
+	query := s.db.Model(&User{}).Where("id IN (?) AND is_active = ?", ids, true)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count follows: %w", err)
+	}
+
+	var users []User
+	if err := query.Order("name, id").Limit(friendListLimit(limit)).Offset(max(offset, 0)).Find(&users).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list follows: %w", err)
+	}
+	return users, total, nil
+}
+
+// GetFollowRequests повертає запити на підписку, що очікують схвалення userID
+func (s *userService) GetFollowRequests(userID string) ([]Follow, error) {
+	var follows []Follow
+	err := s.db.Preload("Follower").
+		Where("followee_id = ? AND status = ?", userID, FollowPending).
+		Order("created_at DESC").
+		Find(&follows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list follow requests: %w", err)
+	}
+	return follows, nil
+}
+
+// ApproveFollow схвалює запит followerID на підписку на userID
+func (s *userService) ApproveFollow(userID, followerID string) error {
+	result := s.db.Model(&Follow{}).
+		Where("follower_id = ? AND followee_id = ? AND status = ?", followerID, userID, FollowPending).
+		Updates(map[string]interface{}{"status": FollowAccepted, "updated_at": time.Now()})
+	if result.Error != nil {
+		return fmt.Errorf("failed to approve follow: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrFollowNotFound
+	}
+	return nil
+}
+
+// DeclineFollow відхиляє запит followerID на підписку на userID
+func (s *userService) DeclineFollow(userID, followerID string) error {
+	result := s.db.Where("follower_id = ? AND followee_id = ? AND status = ?", followerID, userID, FollowPending).Delete(&Follow{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to decline follow: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrFollowNotFound
+	}
+	return nil
+}
+
+// GetFollowCounts повертає кількість підписників і підписок користувача (лише схвалені)
+func (s *userService) GetFollowCounts(userID string) (followers, following int64, err error) {
+	err = s.db.Raw(`
+		SELECT
+			(SELECT COUNT(*) FROM follows f JOIN users u ON u.id = f.follower_id AND u.is_active
+				WHERE f.followee_id = @user AND f.status = @status) AS followers,
+			(SELECT COUNT(*) FROM follows f JOIN users u ON u.id = f.followee_id AND u.is_active
+				WHERE f.follower_id = @user AND f.status = @status) AS following
+	`, map[string]interface{}{"user": userID, "status": FollowAccepted}).Row().Scan(&followers, &following)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count follows: %w", err)
+	}
+	return followers, following, nil
+}
+
+// UpdatePrivacy змінює налаштування приватності: схвалення підписок та приховування присутності.
+// Після вимкнення схвалення підписок усі запити, що очікують, схвалюються.
+func (s *userService) UpdatePrivacy(userID string, settings models.PrivacySettings) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{"updated_at": time.Now()}
+		if settings.FollowApprovalRequired != nil {
+			updates["follow_approval_required"] = *settings.FollowApprovalRequired
+		}
+		if settings.HidePresence != nil {
+			updates["hide_presence"] = *settings.HidePresence
+		}
+		if err := tx.Model(&User{}).Where("id = ?", userID).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to update privacy settings: %w", err)
+		}
+
+		if settings.FollowApprovalRequired != nil && !*settings.FollowApprovalRequired {
+			err := tx.Model(&Follow{}).
+				Where("followee_id = ? AND status = ?", userID, FollowPending).
+				Updates(map[string]interface{}{"status": FollowAccepted, "updated_at": time.Now()}).Error
+			if err != nil {
+				return fmt.Errorf("failed to approve pending follows: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// removeFollows видаляє підписки та запити на підписку між двома користувачами в обох напрямках
+func removeFollows(tx *gorm.DB, userID, otherID string) error {
+	return tx.Where("(follower_id = ? AND followee_id = ?) OR (follower_id = ? AND followee_id = ?)",
+		userID, otherID, otherID, userID).Delete(&Follow{}).Error
+}
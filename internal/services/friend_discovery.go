@@ -0,0 +1,117 @@
+package services
+
+import (
+	"fmt"
+)
+
+// Межі пагінації спільних друзів і рекомендацій
+const (
+	defaultFriendListLimit = 20
+	maxFriendListLimit     = 100
+)
+
+// mutualFriendsQuery вибирає активних спільних друзів @user та @other; обидва JOIN йдуть по idx_friendships_user_friend
+const mutualFriendsQuery = `
+	SELECT u.*
+	FROM friendships a
+	JOIN friendships b ON b.user_id = @other AND b.friend_id = a.friend_id
+	JOIN users u ON u.id = a.friend_id AND u.is_active
+	WHERE a.user_id = @user
+		AND NOT EXISTS (SELECT 1 FROM user_blocks ub WHERE ub.blocker_id = @user AND ub.blocked_id = u.id)`
+
+// suggestionsQuery вибирає друзів друзів @user з кількістю спільних друзів, крім друзів,
+// користувачів з активним запитом у будь-якому напрямку та блокувань в обох напрямках
+const suggestionsQuery = `
+	WITH candidates AS (
+		SELECT f2.friend_id AS candidate_id, COUNT(*) AS mutual_friends
+		FROM friendships f1
+		JOIN friendships f2 ON f2.user_id = f1.friend_id
+		WHERE f1.user_id = @user AND f2.friend_id <> @user
+		GROUP BY f2.friend_id
+	)
+	SELECT u.*, c.mutual_friends
+	FROM candidates c
+	JOIN users u ON u.id = c.candidate_id AND u.is_active
+	WHERE NOT EXISTS (
+			SELECT 1 FROM friendships f WHERE f.user_id = @user AND f.friend_id = c.candidate_id
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM friend_requests r
+			WHERE r.status = 'pending'
+				AND LEAST(r.sender_id, r.receiver_id) = LEAST(@user, c.candidate_id)
+				AND GREATEST(r.sender_id, r.receiver_id) = GREATEST(@user, c.candidate_id)
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM user_blocks ub
+			WHERE (ub.blocker_id = @user AND ub.blocked_id = c.candidate_id)
+				OR (ub.blocker_id = c.candidate_id AND ub.blocked_id = @user)
+		)`
+
+// FriendSuggestion рекомендований користувач з кількістю спільних друзів
+type FriendSuggestion struct {
+	User          `gorm:"embedded"`
+	MutualFriends int64 `json:"mutual_friends"`
+}
+
+// MutualFriends повертає сторінку спільних друзів userID та otherID і їх загальну кількість.
+// Користувач, заблокований будь-ким із двох, вважається відсутнім.
+func (s *friendService) MutualFriends(userID, otherID string, limit, offset int) ([]User, int64, error) {
+	if _, err := s.userService.GetUserByID(otherID); err != nil {
+		return nil, 0, ErrUserNotFound
+	}
+	blocked, err := s.IsBlockedBetween(userID, otherID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if blocked {
+		return nil, 0, ErrUserNotFound
+	}
+
+	params := map[string]interface{}{
+		"user":   userID,
+		"other":  otherID,
+		"limit":  friendListLimit(limit),
+		"offset": max(offset, 0),
+	}
+
+	var total int64
+	if err := s.db.Raw("SELECT COUNT(*) FROM ("+mutualFriendsQuery+") t", params).Scan(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count mutual friends: %w", err)
+	}
+
+	var users []User
+	err = s.db.Raw(mutualFriendsQuery+" ORDER BY u.name, u.id LIMIT @limit OFFSET @offset", params).Scan(&users).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get mutual friends: %w", err)
+	}
+	return users, total, nil
+}
+
+// Suggestions повертає "людей, яких ви можете знати" — друзів друзів, упорядкованих за кількістю спільних друзів
+func (s *friendService) Suggestions(userID string, limit, offset int) ([]FriendSuggestion, int64, error) {
+	params := map[string]interface{}{
+		"user":   userID,
+		"limit":  friendListLimit(limit),
+		"offset": max(offset, 0),
+	}
+
+	var total int64
+	if err := s.db.Raw("SELECT COUNT(*) FROM ("+suggestionsQuery+") t", params).Scan(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count friend suggestions: %w", err)
+	}
+
+	var suggestions []FriendSuggestion
+	err := s.db.Raw(suggestionsQuery+" ORDER BY c.mutual_friends DESC, u.id LIMIT @limit OFFSET @offset", params).Scan(&suggestions).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get friend suggestions: %w", err)
+	}
+	return suggestions, total, nil
+}
+
+// friendListLimit обмежує розмір сторінки значеннями за замовчуванням та максимумом
+func friendListLimit(limit int) int {
+	if limit <= 0 {
+		return defaultFriendListLimit
+	}
+	return min(limit, maxFriendListLimit)
+}
@@ -0,0 +1,293 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxFriendGroupNameLength максимальна довжина назви групи друзів
+const maxFriendGroupNameLength = 64
+
+var (
+	// ErrFriendGroupNotFound повертається коли групи немає або вона належить іншому користувачу
+	ErrFriendGroupNotFound = errors.New("friend group not found")
+	// ErrFriendGroupExists повертається коли у користувача вже є група з такою назвою
+	ErrFriendGroupExists = errors.New("friend group with this name already exists")
+	// ErrFriendGroupMemberNotFound повертається коли користувача немає в групі
+	ErrFriendGroupMemberNotFound = errors.New("user is not a member of the friend group")
+	// ErrInvalidFriendGroupName повертається для порожньої або задовгої назви групи
+	ErrInvalidFriendGroupName = errors.New("invalid friend group name")
+)
+
+// FriendGroup іменована група друзів (наприклад, "Сім'я", "Робота"), яку бачить лише власник
+type FriendGroup struct {
+	ID        uint64    `gorm:"primaryKey;autoIncrement" json:"id"`
+	OwnerID   string    `gorm:"size:255;not null;uniqueIndex:idx_friend_groups_owner_name" json:"owner_id"`
+	Name      string    `gorm:"size:64;not null;uniqueIndex:idx_friend_groups_owner_name" json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Обчислюється у списку груп
+	MemberCount int64 `gorm:"->;-:migration" json:"member_count"`
+}
+
+// TableName явно задає ім'я таблиці для GORM
+func (FriendGroup) TableName() string {
+	return "friend_groups"
+}
+
+// FriendGroupMember друг, доданий до групи
+type FriendGroupMember struct {
+	GroupID   uint64    `gorm:"primaryKey" json:"group_id"`
+	FriendID  string    `gorm:"primaryKey;size:255;index" json:"friend_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName явно задає ім'я таблиці для GORM
+func (FriendGroupMember) TableName() string {
+	return "friend_group_members"
+}
+
+// FriendGroupService інтерфейс для груп друзів
+type FriendGroupService interface {
+	ListGroups(ownerID string) ([]FriendGroup, error)
+	CreateGroup(ownerID, name string) (*FriendGroup, error)
+	GetGroup(ownerID string, groupID uint64) (*FriendGroup, error)
+	RenameGroup(ownerID string, groupID uint64, name string) (*FriendGroup, error)
+	DeleteGroup(ownerID string, groupID uint64) error
+	AddMember(ownerID string, groupID uint64, friendID string) error
+	RemoveMember(ownerID string, groupID uint64, friendID string) error
+	GetGroupByName(ownerID, name string) (*FriendGroup, error)
+	GetMembers(ownerID string, groupID uint64) ([]User, error)
+}
+
+// friendGroupService реалізація FriendGroupService
+type friendGroupService struct {
+	db          *gorm.DB
+	userService UserService
+}
+
+// NewFriendGroupService створює новий FriendGroupService
+func NewFriendGroupService(db *gorm.DB, userService UserService) FriendGroupService {
+	return &friendGroupService{
+		db:          db,
+		userService: userService,
+	}
+}
+
+// ListGroups повертає групи користувача з кількістю учасників
+func (s *friendGroupService) ListGroups(ownerID string) ([]FriendGroup, error) {
+	var groups []FriendGroup
+	err := s.db.Model(&FriendGroup{}).
+		Select("friend_groups.*, (SELECT COUNT(*) FROM friend_group_members m WHERE m.group_id = friend_groups.id) AS member_count").
+		Where("owner_id = ?", ownerID).
+		Order("name").
+		Find(&groups).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list friend groups: %w", err)
+	}
+	return groups, nil
+}
+
+// CreateGroup створює групу друзів
+func (s *friendGroupService) CreateGroup(ownerID, name string) (*FriendGroup, error) {
+	name, err := normalizeFriendGroupName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	group := &FriendGroup{
+		OwnerID: ownerID,
+		Name:    name,
+	}
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(group)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to create friend group: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrFriendGroupExists
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"group_id": group.ID,
+		"owner_id": ownerID,
+	}).Info("Friend group created")
+
+	return group, nil
+}
+
+// GetGroup повертає групу користувача з кількістю учасників
+func (s *friendGroupService) GetGroup(ownerID string, groupID uint64) (*FriendGroup, error) {
+	var group FriendGroup
+	err := s.db.Model(&FriendGroup{}).
+		Select("friend_groups.*, (SELECT COUNT(*) FROM friend_group_members m WHERE m.group_id = friend_groups.id) AS member_count").
+		Where("id = ? AND owner_id = ?", groupID, ownerID).
+		First(&group).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrFriendGroupNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get friend group: %w", err)
+	}
+	return &group, nil
+}
+
+// RenameGroup змінює назву групи
+func (s *friendGroupService) RenameGroup(ownerID string, groupID uint64, name string) (*FriendGroup, error) {
+	name, err := normalizeFriendGroupName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := s.GetGroup(ownerID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if group.Name == name {
+		return group, nil
+	}
+
+	// Зайнятість назви перевіряє унікальний індекс (owner_id, name), а не попередній запит
+	if err := s.db.Model(group).Updates(map[string]interface{}{"name": name, "updated_at": time.Now()}).Error; err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrFriendGroupExists
+		}
+		return nil, fmt.Errorf("failed to rename friend group: %w", err)
+	}
+	group.Name = name
+	return group, nil
+}
+
+// DeleteGroup видаляє групу разом з її учасниками; самі дружби не змінюються
+func (s *friendGroupService) DeleteGroup(ownerID string, groupID uint64) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND owner_id = ?", groupID, ownerID).Delete(&FriendGroup{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrFriendGroupNotFound
+		}
+		return tx.Where("group_id = ?", groupID).Delete(&FriendGroupMember{}).Error
+	})
+	if err != nil {
+		if errors.Is(err, ErrFriendGroupNotFound) {
+			return err
+		}
+		return fmt.Errorf("failed to delete friend group: %w", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"group_id": groupID,
+		"owner_id": ownerID,
+	}).Info("Friend group deleted")
+
+	return nil
+}
+
+// AddMember додає друга до групи; додати можна лише наявного друга. Повторне додавання нічого не змінює.
+func (s *friendGroupService) AddMember(ownerID string, groupID uint64, friendID string) error {
+	if _, err := s.GetGroup(ownerID, groupID); err != nil {
+		return err
+	}
+
+	areFriends, err := s.userService.AreFriends(ownerID, friendID)
+	if err != nil {
+		return fmt.Errorf("failed to check friendship: %w", err)
+	}
+	if !areFriends {
+		return ErrNotFriends
+	}
+
+	member := &FriendGroupMember{
+		GroupID:  groupID,
+		FriendID: friendID,
+	}
+	if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(member).Error; err != nil {
+		return fmt.Errorf("failed to add friend group member: %w", err)
+	}
+	return nil
+}
+
+// RemoveMember видаляє друга з групи
+func (s *friendGroupService) RemoveMember(ownerID string, groupID uint64, friendID string) error {
+	if _, err := s.GetGroup(ownerID, groupID); err != nil {
+		return err
+	}
+
+	result := s.db.Where("group_id = ? AND friend_id = ?", groupID, friendID).Delete(&FriendGroupMember{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to remove friend group member: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrFriendGroupMemberNotFound
+	}
+	return nil
+}
+
+// GetGroupByName повертає групу користувача за назвою
+func (s *friendGroupService) GetGroupByName(ownerID, name string) (*FriendGroup, error) {
+	var group FriendGroup
+	err := s.db.Where("owner_id = ? AND name = ?", ownerID, strings.TrimSpace(name)).First(&group).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrFriendGroupNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get friend group: %w", err)
+	}
+	return &group, nil
+}
+
+// GetMembers повертає активних друзів у групі користувача
+func (s *friendGroupService) GetMembers(ownerID string, groupID uint64) ([]User, error) {
+	var owned int64
+	if err := s.db.Model(&FriendGroup{}).Where("id = ? AND owner_id = ?", groupID, ownerID).Count(&owned).Error; err != nil {
+		return nil, fmt.Errorf("failed to get friend group: %w", err)
+	}
+	if owned == 0 {
+		return nil, ErrFriendGroupNotFound
+	}
+
+	var members []User
+	err := s.db.
+		Where("id IN (?) AND is_active = ?", s.db.Model(&FriendGroupMember{}).Select("friend_id").Where("group_id = ?", groupID), true).
+		Order("name").
+		Find(&members).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get friend group members: %w", err)
+	}
+	return members, nil
+}
+
+// removeFriendGroupMemberships прибирає обох користувачів з груп один одного після завершення дружби
+func removeFriendGroupMemberships(tx *gorm.DB, userID, friendID string) error {
+	return tx.Exec(`
+		DELETE FROM friend_group_members m
+		USING friend_groups g
+		WHERE m.group_id = g.id
+			AND ((g.owner_id = ? AND m.friend_id = ?) OR (g.owner_id = ? AND m.friend_id = ?))
+	`, userID, friendID, friendID, userID).Error
+}
+
+// normalizeFriendGroupName обрізає пробіли та перевіряє довжину назви групи
+func normalizeFriendGroupName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxFriendGroupNameLength {
+		return "", ErrInvalidFriendGroupName
+	}
+	return name, nil
+}
+
+// isUniqueViolation перевіряє, чи помилка Postgres є порушенням унікального обмеження
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
@@ -0,0 +1,289 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Статуси запиту в друзі
+const (
+	FriendRequestPending   = "pending"
+	FriendRequestAccepted  = "accepted"
+	FriendRequestDeclined  = "declined"
+	FriendRequestCancelled = "cancelled"
+)
+
+var (
+	// ErrFriendRequestSelf повертається при спробі надіслати запит у друзі самому собі
+	ErrFriendRequestSelf = errors.New("cannot send a friend request to yourself")
+	// ErrAlreadyFriends повертається коли користувачі вже є друзями
+	ErrAlreadyFriends = errors.New("users are already friends")
+	// ErrFriendRequestExists повертається коли між користувачами вже є активний запит
+	ErrFriendRequestExists = errors.New("friend request already pending")
+	// ErrFriendRequestNotFound повертається коли запиту немає або користувач не є його учасником
+	ErrFriendRequestNotFound = errors.New("friend request not found")
+	// ErrFriendRequestNotPending повертається коли на запит уже відповіли або його скасовано
+	ErrFriendRequestNotPending = errors.New("friend request is no longer pending")
+	// ErrFriendRequestBlocked повертається коли один з користувачів заблокував іншого
+	ErrFriendRequestBlocked = errors.New("friend request blocked")
+	// ErrNotFriends повертається при спробі видалити користувача, який не є другом
+	ErrNotFriends = errors.New("users are not friends")
+)
+
+// FriendRequest запит у друзі; дружба створюється лише після прийняття отримувачем
+type FriendRequest struct {
+	ID          uint64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	SenderID    string     `gorm:"size:255;not null;index" json:"sender_id"`
+	ReceiverID  string     `gorm:"size:255;not null;index" json:"receiver_id"`
+	Status      string     `gorm:"size:16;not null;index" json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	RespondedAt *time.Time `json:"responded_at,omitempty"`
+
+	// Заповнюються у списках запитів
+	Sender   *User `gorm:"foreignKey:SenderID;-:migration" json:"sender,omitempty"`
+	Receiver *User `gorm:"foreignKey:ReceiverID;-:migration" json:"receiver,omitempty"`
+}
+
+// TableName явно задає ім'я таблиці для GORM
+func (FriendRequest) TableName() string {
+	return "friend_requests"
+}
+
+// FriendService інтерфейс для запитів у друзі, видалення друзів, блокування користувачів та пошуку знайомих
+type FriendService interface {
+	SendRequest(senderID, receiverID string) (*FriendRequest, error)
+	ListIncoming(userID string) ([]FriendRequest, error)
+	ListOutgoing(userID string) ([]FriendRequest, error)
+	Accept(userID string, requestID uint64) (*FriendRequest, error)
+	Decline(userID string, requestID uint64) (*FriendRequest, error)
+	Cancel(userID string, requestID uint64) (*FriendRequest, error)
+	RemoveFriend(userID, friendID string) error
+	Block(blockerID, blockedID string) (*UserBlock, error)
+	Unblock(blockerID, blockedID string) error
+	ListBlocked(userID string) ([]UserBlock, error)
+	IsBlockedBetween(userID, otherID string) (bool, error)
+	MutualFriends(userID, otherID string, limit, offset int) ([]User, int64, error)
+	Suggestions(userID string, limit, offset int) ([]FriendSuggestion, int64, error)
+}
+
+// friendService реалізація FriendService
+type friendService struct {
+	db          *gorm.DB
+	userService UserService
+}
+
+// NewFriendService створює новий FriendService
+func NewFriendService(db *gorm.DB, userService UserService) FriendService {
+	return &friendService{
+		db:          db,
+		userService: userService,
+	}
+}
+
+// SendRequest надсилає запит у друзі.
+// Якщо отримувач уже надіслав запит відправнику, цей запит приймається одразу.
+func (s *friendService) SendRequest(senderID, receiverID string) (*FriendRequest, error) {
+	if senderID == receiverID {
+		return nil, ErrFriendRequestSelf
+	}
+
+	if _, err := s.userService.GetUserByID(receiverID); err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	// Заблокований не може надсилати запити тому, хто його заблокував, і навпаки
+	blocked, err := s.IsBlockedBetween(senderID, receiverID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, ErrFriendRequestBlocked
+	}
+
+	areFriends, err := s.userService.AreFriends(senderID, receiverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check friendship: %w", err)
+	}
+	if areFriends {
+		return nil, ErrAlreadyFriends
+	}
+
+	var pending FriendRequest
+	err = s.db.Where("status = ? AND ((sender_id = ? AND receiver_id = ?) OR (sender_id = ? AND receiver_id = ?))",
+		FriendRequestPending, senderID, receiverID, receiverID, senderID).
+		First(&pending).Error
+	switch {
+	case err == nil && pending.SenderID == senderID:
+		return nil, ErrFriendRequestExists
+	case err == nil:
+		// Зустрічний запит: обидва користувачі хочуть дружити
+		return s.Accept(senderID, pending.ID)
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, fmt.Errorf("failed to check friend requests: %w", err)
+	}
+
+	request := &FriendRequest{
+		SenderID:   senderID,
+		ReceiverID: receiverID,
+		Status:     FriendRequestPending,
+	}
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(request)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to create friend request: %w", result.Error)
+	}
+	// Паралельний запит між тією ж парою відсікає унікальний індекс активних запитів
+	if result.RowsAffected == 0 {
+		return nil, ErrFriendRequestExists
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"request_id":  request.ID,
+		"sender_id":   senderID,
+		"receiver_id": receiverID,
+	}).Info("Friend request sent")
+
+	return request, nil
+}
+
+// ListIncoming повертає запити в друзі, що очікують відповіді користувача
+func (s *friendService) ListIncoming(userID string) ([]FriendRequest, error) {
+	var requests []FriendRequest
+	err := s.db.Preload("Sender").
+		Where("receiver_id = ? AND status = ?", userID, FriendRequestPending).
+		Order("created_at DESC").
+		Find(&requests).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list incoming friend requests: %w", err)
+	}
+	return requests, nil
+}
+
+// ListOutgoing повертає надіслані користувачем запити, на які ще не відповіли
+func (s *friendService) ListOutgoing(userID string) ([]FriendRequest, error) {
+	var requests []FriendRequest
+	err := s.db.Preload("Receiver").
+		Where("sender_id = ? AND status = ?", userID, FriendRequestPending).
+		Order("created_at DESC").
+		Find(&requests).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outgoing friend requests: %w", err)
+	}
+	return requests, nil
+}
+
+// Accept приймає вхідний запит і створює дружбу в обох напрямках
+func (s *friendService) Accept(userID string, requestID uint64) (*FriendRequest, error) {
+	return s.respond(requestID, "receiver_id", userID, FriendRequestAccepted, func(tx *gorm.DB, request *FriendRequest) error {
+		now := time.Now()
+		friendships := []map[string]interface{}{
+			{"user_id": request.SenderID, "friend_id": request.ReceiverID, "created_at": now, "updated_at": now},
+			{"user_id": request.ReceiverID, "friend_id": request.SenderID, "created_at": now, "updated_at": now},
+		}
+		return tx.Table("friendships").Clauses(clause.OnConflict{DoNothing: true}).Create(friendships).Error
+	})
+}
+
+// Decline відхиляє вхідний запит
+func (s *friendService) Decline(userID string, requestID uint64) (*FriendRequest, error) {
+	return s.respond(requestID, "receiver_id", userID, FriendRequestDeclined, nil)
+}
+
+// Cancel скасовує власний запит, на який ще не відповіли
+func (s *friendService) Cancel(userID string, requestID uint64) (*FriendRequest, error) {
+	return s.respond(requestID, "sender_id", userID, FriendRequestCancelled, nil)
+}
+
+// respond переводить запит зі статусу pending у status, якщо userID є учасником у ролі party.
+// Блокування рядка гарантує, що на запит відповідають лише один раз; onAccept виконується в тій же транзакції.
+func (s *friendService) respond(requestID uint64, party, userID, status string, onAccept func(tx *gorm.DB, request *FriendRequest) error) (*FriendRequest, error) {
+	var request FriendRequest
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND "+party+" = ?", requestID, userID).
+			First(&request).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrFriendRequestNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if request.Status != FriendRequestPending {
+			return ErrFriendRequestNotPending
+		}
+
+		now := time.Now()
+		request.Status = status
+		request.RespondedAt = &now
+		if err := tx.Model(&request).Updates(map[string]interface{}{
+			"status":       status,
+			"responded_at": now,
+			"updated_at":   now,
+		}).Error; err != nil {
+			return err
+		}
+
+		if onAccept != nil {
+			return onAccept(tx, &request)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrFriendRequestNotFound) || errors.Is(err, ErrFriendRequestNotPending) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to update friend request: %w", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"request_id": request.ID,
+		"user_id":    userID,
+		"status":     status,
+	}).Info("Friend request updated")
+
+	return &request, nil
+}
+
+// RemoveFriend видаляє дружбу в обох напрямках
+func (s *friendService) RemoveFriend(userID, friendID string) error {
+	var removed int64
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		removed, err = removeFriendship(tx, userID, friendID)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove friend: %w", err)
+	}
+	if removed == 0 {
+		return ErrNotFriends
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"user_id":   userID,
+		"friend_id": friendID,
+	}).Info("Friendship removed")
+
+	return nil
+}
+
+// removeFriendship видаляє записи дружби між користувачами в обох напрямках разом з їх членством
+// у групах друзів один одного і повертає кількість видалених записів дружби
+func removeFriendship(tx *gorm.DB, userID, friendID string) (int64, error) {
+	result := tx.Exec(`
+		DELETE FROM friendships
+		WHERE (user_id = ? AND friend_id = ?) OR (user_id = ? AND friend_id = ?)
+	`, userID, friendID, friendID, userID)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if err := removeFriendGroupMemberships(tx, userID, friendID); err != nil {
+		return 0, err
+	}
+	return result.RowsAffected, nil
+}
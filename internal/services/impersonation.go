@@ -0,0 +1,229 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go-practice/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ImpersonationTokenTTL термін дії токена імперсонації
+const ImpersonationTokenTTL = 15 * time.Minute
+
+var (
+	// ErrCannotImpersonateAdmin повертається при спробі імперсонувати адміністратора (або себе)
+	ErrCannotImpersonateAdmin = errors.New("administrators cannot be impersonated")
+	// ErrImpersonationNotFound повертається коли сесію імперсонації не знайдено
+	ErrImpersonationNotFound = errors.New("impersonation session not found")
+	// ErrImpersonationEnded повертається коли сесію імперсонації відкликано або вона завершилась
+	ErrImpersonationEnded = errors.New("impersonation session has ended")
+)
+
+// ImpersonationSession фіксує, хто, коли і чому діяв від імені користувача
+type ImpersonationSession struct {
+	ID         string     `gorm:"primaryKey;size:64" json:"id"` // збігається з jti токена
+	AdminID    string     `gorm:"size:255;not null;index" json:"admin_id"`
+	AdminEmail string     `gorm:"size:255;not null" json:"admin_email"`
+	UserID     string     `gorm:"size:255;not null;index" json:"user_id"`
+	Reason     string     `gorm:"size:500;not null" json:"reason"`
+	IPAddress  string     `gorm:"size:64" json:"ip_address"`
+	UserAgent  string     `gorm:"size:500" json:"user_agent"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	RevokedBy  string     `gorm:"size:255" json:"revoked_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// TableName явно задає ім'я таблиці для GORM
+func (ImpersonationSession) TableName() string {
+	return "impersonation_sessions"
+}
+
+// IsActive перевіряє чи сесія ще діє
+func (s *ImpersonationSession) IsActive() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
+
+// ImpersonationService інтерфейс для імперсонації користувачів адміністраторами
+type ImpersonationService interface {
+	Start(admin *User, targetUserID, reason string, client models.ClientInfo) (*ImpersonationSession, *models.Token, error)
+	Validate(sessionID string) error
+	Revoke(sessionID, revokedBy string) error
+	RevokeForUser(userID, sessionID string) error
+	ListForUser(userID string) ([]ImpersonationSession, error)
+	ListSessions(activeOnly bool) ([]ImpersonationSession, error)
+}
+
+// impersonationService реалізація ImpersonationService
+type impersonationService struct {
+	db          *gorm.DB
+	userService UserService
+	jwtService  JWTService
+	rbacService RBACService
+}
+
+// NewImpersonationService створює новий ImpersonationService
+func NewImpersonationService(db *gorm.DB, userService UserService, jwtService JWTService, rbacService RBACService) ImpersonationService {
+	return &impersonationService{
+		db:          db,
+		userService: userService,
+		jwtService:  jwtService,
+		rbacService: rbacService,
+	}
+}
+
+// Start створює сесію імперсонації і видає короткоживучий access token з claim act
+func (s *impersonationService) Start(admin *User, targetUserID, reason string, client models.ClientInfo) (*ImpersonationSession, *models.Token, error) {
+	if admin.ID == targetUserID {
+		return nil, nil, ErrCannotImpersonateAdmin
+	}
+
+	target, err := s.userService.GetUserByID(targetUserID)
+	if err != nil {
+		return nil, nil, ErrUserNotFound
+	}
+
+	roles, err := s.rbacService.GetUserRoles(target.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if containsScope(roles, RoleAdmin) {
+		return nil, nil, ErrCannotImpersonateAdmin
+	}
+
+	sessionID, err := generateOpaqueToken("imp_", 16)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate impersonation ID: %w", err)
+	}
+
+	now := time.Now()
+	session := &ImpersonationSession{
+		ID:         sessionID,
+		AdminID:    admin.ID,
+		AdminEmail: admin.Email,
+		UserID:     target.ID,
+		Reason:     reason,
+		IPAddress:  client.IPAddress,
+		UserAgent:  client.UserAgent,
+		ExpiresAt:  now.Add(ImpersonationTokenTTL),
+		CreatedAt:  now,
+	}
+	if err := s.db.Create(session).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to store impersonation session: %w", err)
+	}
+
+	token, err := s.jwtService.GenerateAccessToken(target, TokenOptions{
+		Roles:   roles,
+		Scopes:  ImpersonationScopes,
+		Actor:   &ActorClaim{Subject: admin.ID, Email: admin.Email},
+		TokenID: session.ID,
+		TTL:     ImpersonationTokenTTL,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	logSecurityEvent("impersonation_started", logrus.Fields{
+		"admin_id":         admin.ID,
+		"user_id":          target.ID,
+		"impersonation_id": session.ID,
+		"reason":           reason,
+		"ip_address":       client.IPAddress,
+	})
+	return session, token, nil
+}
+
+// Validate перевіряє що сесія імперсонації не відкликана і не завершилась
+func (s *impersonationService) Validate(sessionID string) error {
+	session, err := s.get(sessionID)
+	if err != nil {
+		return err
+	}
+	if !session.IsActive() {
+		return ErrImpersonationEnded
+	}
+	return nil
+}
+
+// Revoke завершує сесію імперсонації
+func (s *impersonationService) Revoke(sessionID, revokedBy string) error {
+	session, err := s.get(sessionID)
+	if err != nil {
+		return err
+	}
+	return s.revoke(session, revokedBy)
+}
+
+// RevokeForUser дозволяє користувачу завершити імперсонацію свого акаунта
+func (s *impersonationService) RevokeForUser(userID, sessionID string) error {
+	session, err := s.get(sessionID)
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID {
+		return ErrImpersonationNotFound
+	}
+	return s.revoke(session, userID)
+}
+
+// ListForUser повертає історію імперсонацій акаунта користувача
+func (s *impersonationService) ListForUser(userID string) ([]ImpersonationSession, error) {
+	var sessions []ImpersonationSession
+	if err := s.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&sessions).Error; err != nil {
+		return nil, fmt.Errorf("failed to list impersonation sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// ListSessions повертає сесії імперсонації всіх користувачів
+func (s *impersonationService) ListSessions(activeOnly bool) ([]ImpersonationSession, error) {
+	query := s.db.Model(&ImpersonationSession{})
+	if activeOnly {
+		query = query.Where("revoked_at IS NULL AND expires_at > ?", time.Now())
+	}
+
+	var sessions []ImpersonationSession
+	if err := query.Order("created_at DESC").Limit(maxUserListLimit).Find(&sessions).Error; err != nil {
+		return nil, fmt.Errorf("failed to list impersonation sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// get знаходить сесію імперсонації за ID
+func (s *impersonationService) get(sessionID string) (*ImpersonationSession, error) {
+	var session ImpersonationSession
+	if err := s.db.Where("id = ?", sessionID).First(&session).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrImpersonationNotFound
+		}
+		return nil, fmt.Errorf("failed to get impersonation session: %w", err)
+	}
+	return &session, nil
+}
+
+// revoke позначає сесію відкликаною
+func (s *impersonationService) revoke(session *ImpersonationSession, revokedBy string) error {
+	if !session.IsActive() {
+		return nil
+	}
+
+	err := s.db.Model(&ImpersonationSession{}).Where("id = ?", session.ID).Updates(map[string]interface{}{
+		"revoked_at": time.Now(),
+		"revoked_by": revokedBy,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to revoke impersonation session: %w", err)
+	}
+
+	logSecurityEvent("impersonation_revoked", logrus.Fields{
+		"impersonation_id": session.ID,
+		"admin_id":         session.AdminID,
+		"user_id":          session.UserID,
+		"revoked_by":       revokedBy,
+	})
+	return nil
+}
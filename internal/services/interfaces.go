@@ -8,13 +8,25 @@ import (
 
 // AuthService інтерфейс для автентифікації
 type AuthService interface {
-	DefaultLogin(lr *models.LoginRequest) (*models.LoginResponse, error)
-	Register(req *models.RegisterRequest) (*models.RegisterResponse, error)
-	Login(redirectURI string) (*models.OIDCLoginResponse, error)
-	HandleCallback(code, state string) (*models.Token, *models.User, error)
-	Logout(userID string) error
-	RefreshToken(refreshToken string) (*models.Token, error)
+	DefaultLogin(lr *models.LoginRequest, client models.ClientInfo) (*models.LoginResponse, error)
+	Register(req *models.RegisterRequest, client models.ClientInfo) (*models.RegisterResponse, error)
+	VerifyEmail(token string, client models.ClientInfo) (*models.User, error)
+	ResendVerification(email string) error
+	ChangePassword(userID, currentPassword, newPassword string, scopes []string, client models.ClientInfo) (*models.Token, error)
+	ChangeEmail(userID, currentPassword, newEmail string, client models.ClientInfo) error
+	RevokeUserSessions(userID string) error
+	ListSessions(userID string) ([]*SessionData, error)
+	RevokeSession(userID, sessionID string, client models.ClientInfo) error
+	RevokeOtherSessions(userID, currentSessionID string, client models.ClientInfo) (int, error)
+	ResetPassword(token, newPassword string, client models.ClientInfo) error
+	Login(redirectURI, scope string) (*models.OIDCLoginResponse, error)
+	HandleCallback(code, state string, client models.ClientInfo) (*models.Token, *models.User, error)
+	Logout(userID, sessionID string, client models.ClientInfo) error
+	LogoutByToken(accessToken string, client models.ClientInfo) error
+	RefreshToken(refreshToken, scope string, client models.ClientInfo) (*models.Token, error)
 	GetUserInfo(accessToken string) (*models.User, error)
+	AuthenticateSession(sessionID string, client models.ClientInfo) (*SessionData, *User, error)
+	ValidateTokenSession(userID, sessionID string, client models.ClientInfo) (*SessionData, error)
 }
 
 // UserService інтерфейс для роботи з користувачами
@@ -22,29 +34,66 @@ type UserService interface {
 	GetAllUsers() ([]User, error)
 	RegisterUser(req models.RegisterRequest) (*models.RegisterResponse, error)
 	GetUserByEmail(email string) (*User, error)
-	SearchUsers(query string) ([]User, error)
+	SearchUsers(viewerID, query string) ([]User, error)
 	GetUserByID(id string) (*User, error)
+	GetVisibleUser(viewerID, id string) (*User, error)
 	ValidatePassword(email, password string) (*User, error)
+	SetPassword(userID, password string) error
+	HashNewPassword(user *User, password string) (string, error)
 	UpdateUser(userID string, updates map[string]interface{}) error
 	AreFriends(userID, friendID string) (bool, error)
-	AddFriend(userID, friendID string) error
 	GetFriends(userID string) ([]User, error)
 	GetIDByUserID(userID string) (string, error)
 	DeleteUser(userID string) error
 	GetProfile(userID string) (*models.UserProfile, error)
-	CreateOrUpdateFromOIDC(sub, email, name, picture string) (*User, error)
+	UpdatePrivacy(userID string, settings models.PrivacySettings) error
+	Follow(followerID, followeeID string) (follow *Follow, created bool, err error)
+	Unfollow(followerID, followeeID string) error
+	GetFollowers(userID string, limit, offset int) ([]User, int64, error)
+	GetFollowing(userID string, limit, offset int) ([]User, int64, error)
+	GetFollowCounts(userID string) (followers, following int64, err error)
+	GetFollowRequests(userID string) ([]Follow, error)
+	ApproveFollow(userID, followerID string) error
+	DeclineFollow(userID, followerID string) error
+	CreateOrUpdateFromOIDC(sub, email, name, picture string, emailVerified bool) (*User, error)
 }
 
-// User представляє користувача в базі даних
+// User представляє користувача в базі даних.
+// Моделі, що підвантажують автора чи адресата через Preload, позначають поле *User тегом "-:migration",
+// щоб їх AutoMigrate не зачіпав таблицю users.
 type User struct {
-	ID           string    `gorm:"primaryKey;size:255" json:"id"`
-	Email        string    `gorm:"uniqueIndex;not null;size:255" json:"email"`
-	Name         string    `gorm:"not null;size:255" json:"name"`
-	PasswordHash string    `gorm:"not null;size:255" json:"-"`
-	Picture      string    `gorm:"size:500" json:"picture,omitempty"`
-	IsActive     bool      `gorm:"default:true" json:"is_active"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID              string    `gorm:"primaryKey;size:255" json:"id"`
+	Email           string    `gorm:"uniqueIndex;not null;size:255" json:"email"`
+	Name            string    `gorm:"not null;size:255" json:"name"`
+	PasswordHash    string    `gorm:"not null;size:255" json:"-"`
+	Picture         string    `gorm:"size:500" json:"picture,omitempty"`
+	IsActive        bool      `gorm:"default:true" json:"is_active"`
+	IsEmailVerified bool      `gorm:"default:false" json:"is_email_verified"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+
+	// Токени, видані раніше цього моменту, вважаються відкликаними
+	TokensValidAfter *time.Time `json:"-"`
+	// Користувач має встановити новий пароль перед наступним входом
+	PasswordResetRequired bool `gorm:"default:false" json:"-"`
+	// Момент останньої зміни ролей; cookie сесії, видані раніше, ротуються
+	PrivilegesChangedAt *time.Time `json:"-"`
+	// Нові підписки на користувача потребують його схвалення
+	FollowApprovalRequired bool `gorm:"default:false" json:"follow_approval_required"`
+	// Останній момент активності; друзям показується через присутність, якщо статус не прихований
+	LastSeenAt *time.Time `json:"-"`
+	// Друзі не бачать статус онлайн та last_seen_at користувача
+	HidePresence bool `gorm:"default:false" json:"hide_presence"`
+}
+
+// IsTokenRevoked перевіряє чи токен, виданий у issuedAt, відкликано зміною облікових даних
+func (u *User) IsTokenRevoked(issuedAt time.Time) bool {
+	return u.TokensValidAfter != nil && issuedAt.Before(*u.TokensValidAfter)
+}
+
+// NeedsSessionRotation перевіряє чи сесію, видану в issuedAt, треба ротувати через зміну привілеїв
+func (u *User) NeedsSessionRotation(issuedAt time.Time) bool {
+	return u.PrivilegesChangedAt != nil && issuedAt.Before(*u.PrivilegesChangedAt)
 }
 
 // SessionService інтерфейс для роботи з сесіями
@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"strings"
 	"time"
 
 	"go-practice/internal/models"
@@ -14,14 +15,19 @@ import (
 
 // JWTService містить логіку для роботи з JWT токенами
 type JWTService interface {
-	GenerateTokens(user *User) (*models.Token, error)
+	GenerateTokens(user *User, opts TokenOptions) (*models.Token, error)
+	GenerateAccessToken(user *User, opts TokenOptions) (*models.Token, error)
 	ValidateAccessToken(tokenString string) (*jwt.Token, error)
+	ParseAccessToken(tokenString string) (*AccessTokenClaims, error)
 	ValidateIDToken(tokenString string) (*jwt.Token, error)
 	ValidateRefreshToken(tokenString string) (*RefreshTokenClaims, error)
 	GetUserIDFromToken(tokenString string) (string, error)
 	ExtractUserIDFromIDToken(idToken string) (string, error)
 }
 
+// RefreshTokenTTL термін дії refresh token; сесія може завершитись раніше (max_age, idle timeout)
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
 // jwtService реалізація JWTService
 type jwtService struct {
 	accessSecret  []byte
@@ -44,9 +50,32 @@ type AccessTokenClaims struct {
 	Email  string   `json:"email"`
 	Name   string   `json:"name"`
 	Scope  []string `json:"scope"`
+	Roles  []string `json:"roles,omitempty"`
+	// SessionID ідентифікує сесію входу; після її відкликання токен недійсний
+	SessionID string `json:"sid,omitempty"`
+	// Act (RFC 8693) вказує адміністратора, який діє від імені користувача
+	Act *ActorClaim `json:"act,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// ActorClaim описує суб'єкта, який фактично виконує дії з токеном
+type ActorClaim struct {
+	Subject string `json:"sub"`
+	Email   string `json:"email,omitempty"`
+}
+
+// TokenOptions містить додаткові дані для випуску токенів
+type TokenOptions struct {
+	Roles     []string
+	Scopes    []string // надані API scopes; порожній список означає всі APIScopes
+	SessionID string   // сесія входу, до якої прив'язані токени
+
+	// Лише для GenerateAccessToken
+	Actor   *ActorClaim
+	TokenID string        // jti; порожній — згенерувати
+	TTL     time.Duration // 0 — стандартний термін дії access token
+}
+
 // IDTokenClaims представляє claims для ID Token (OIDC)
 type IDTokenClaims struct {
 	UserID        string `json:"sub"`
@@ -55,44 +84,36 @@ type IDTokenClaims struct {
 	Picture       string `json:"picture,omitempty"`
 	EmailVerified bool   `json:"email_verified"`
 	AuthTime      int64  `json:"auth_time"`
+	SessionID     string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
 // RefreshTokenClaims представляє claims для Refresh Token
 type RefreshTokenClaims struct {
-	UserID    string `json:"sub"`
-	TokenType string `json:"token_type"`
+	UserID    string   `json:"sub"`
+	TokenType string   `json:"token_type"`
+	Scope     []string `json:"scope,omitempty"`
+	SessionID string   `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
 // GenerateTokens генерує Access, ID та Refresh токени
-func (j *jwtService) GenerateTokens(user *User) (*models.Token, error) {
+func (j *jwtService) GenerateTokens(user *User, opts TokenOptions) (*models.Token, error) {
 	now := time.Now()
-	accessExpiry := now.Add(time.Hour)            // 1 година
-	idExpiry := now.Add(time.Hour)                // 1 година
-	refreshExpiry := now.Add(24 * time.Hour * 30) // 30 днів
+	accessExpiry := now.Add(time.Hour) // 1 година
+	idExpiry := now.Add(time.Hour)     // 1 година
+	refreshExpiry := now.Add(RefreshTokenTTL)
 
-	// Генерація Access Token
-	accessClaims := AccessTokenClaims{
-		UserID: user.ID,
-		Email:  user.Email,
-		Name:   user.Name,
-		Scope:  []string{"openid", "profile", "email"},
-		RegisteredClaims: jwt.RegisteredClaims{
-			Issuer:    "oidc-api-server",
-			Subject:   user.ID,
-			Audience:  []string{"oidc-api-client"},
-			ExpiresAt: jwt.NewNumericDate(accessExpiry),
-			IssuedAt:  jwt.NewNumericDate(now),
-			NotBefore: jwt.NewNumericDate(now),
-			ID:        generateJTI(),
-		},
+	apiScopes := opts.Scopes
+	if len(apiScopes) == 0 {
+		apiScopes = APIScopes
 	}
+	scopes := append(append([]string(nil), identityScopes...), apiScopes...)
 
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessTokenString, err := accessToken.SignedString(j.accessSecret)
+	// Генерація Access Token
+	accessTokenString, err := j.signAccessToken(user, opts, scopes, now, accessExpiry, generateJTI())
 	if err != nil {
-		return nil, fmt.Errorf("failed to sign access token: %w", err)
+		return nil, err
 	}
 
 	// Генерація ID Token (OIDC)
@@ -101,8 +122,9 @@ func (j *jwtService) GenerateTokens(user *User) (*models.Token, error) {
 		Email:         user.Email,
 		Name:          user.Name,
 		Picture:       user.Picture,
-		EmailVerified: true,
+		EmailVerified: user.IsEmailVerified,
 		AuthTime:      now.Unix(),
+		SessionID:     opts.SessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    "oidc-api-server",
 			Subject:   user.ID,
@@ -124,6 +146,8 @@ func (j *jwtService) GenerateTokens(user *User) (*models.Token, error) {
 	refreshClaims := RefreshTokenClaims{
 		UserID:    user.ID,
 		TokenType: "refresh",
+		Scope:     apiScopes,
+		SessionID: opts.SessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    "oidc-api-server",
 			Subject:   user.ID,
@@ -149,10 +173,73 @@ func (j *jwtService) GenerateTokens(user *User) (*models.Token, error) {
 		TokenType:    "Bearer",
 		ExpiresIn:    3600, // 1 година в секундах
 		ExpiresAt:    accessExpiry,
-		Scope:        "openid profile email",
+		Scope:        strings.Join(scopes, " "),
 	}, nil
 }
 
+// GenerateAccessToken генерує лише Access Token (без ID та Refresh токенів)
+func (j *jwtService) GenerateAccessToken(user *User, opts TokenOptions) (*models.Token, error) {
+	now := time.Now()
+	ttl := opts.TTL
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	expiry := now.Add(ttl)
+
+	apiScopes := opts.Scopes
+	if len(apiScopes) == 0 {
+		apiScopes = APIScopes
+	}
+	scopes := append(append([]string(nil), identityScopes...), apiScopes...)
+
+	tokenID := opts.TokenID
+	if tokenID == "" {
+		tokenID = generateJTI()
+	}
+
+	accessTokenString, err := j.signAccessToken(user, opts, scopes, now, expiry, tokenID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Token{
+		AccessToken: accessTokenString,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(ttl.Seconds()),
+		ExpiresAt:   expiry,
+		Scope:       strings.Join(scopes, " "),
+	}, nil
+}
+
+// signAccessToken формує та підписує Access Token
+func (j *jwtService) signAccessToken(user *User, opts TokenOptions, scopes []string, now, expiry time.Time, tokenID string) (string, error) {
+	accessClaims := AccessTokenClaims{
+		UserID:    user.ID,
+		Email:     user.Email,
+		Name:      user.Name,
+		Scope:     scopes,
+		Roles:     opts.Roles,
+		SessionID: opts.SessionID,
+		Act:       opts.Actor,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "oidc-api-server",
+			Subject:   user.ID,
+			Audience:  []string{"oidc-api-client"},
+			ExpiresAt: jwt.NewNumericDate(expiry),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ID:        tokenID,
+		},
+	}
+
+	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
+	accessTokenString, err := accessToken.SignedString(j.accessSecret)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign access token: %w", err)
+	}
+	return accessTokenString, nil
+}
+
 // ValidateAccessToken валідує Access Token
 func (j *jwtService) ValidateAccessToken(tokenString string) (*jwt.Token, error) {
 	return jwt.ParseWithClaims(tokenString, &AccessTokenClaims{}, func(token *jwt.Token) (interface{}, error) {
@@ -163,6 +250,20 @@ func (j *jwtService) ValidateAccessToken(tokenString string) (*jwt.Token, error)
 	})
 }
 
+// ParseAccessToken валідує Access Token і повертає його claims
+func (j *jwtService) ParseAccessToken(tokenString string) (*AccessTokenClaims, error) {
+	token, err := j.ValidateAccessToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*AccessTokenClaims); ok && token.Valid {
+		return claims, nil
+	}
+
+	return nil, fmt.Errorf("invalid token claims")
+}
+
 // ValidateIDToken валідує ID Token
 func (j *jwtService) ValidateIDToken(tokenString string) (*jwt.Token, error) {
 	return jwt.ParseWithClaims(tokenString, &IDTokenClaims{}, func(token *jwt.Token) (interface{}, error) {
@@ -0,0 +1,277 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrLoginThrottled повертається коли спроби входу тимчасово заблоковані
+var ErrLoginThrottled = errors.New("too many failed login attempts")
+
+// LoginAttemptConfig містить пороги захисту від перебору паролів
+type LoginAttemptConfig struct {
+	MaxAccountFailures int
+	MaxIPFailures      int
+	BaseDelay          time.Duration
+	MaxDelay           time.Duration
+	LockoutDuration    time.Duration
+	FailureWindow      time.Duration
+}
+
+// LoginAttemptService інтерфейс для відстеження невдалих спроб входу
+type LoginAttemptService interface {
+	Check(email, ipAddress string) error
+	RecordFailure(email, ipAddress string)
+	RecordSuccess(email, ipAddress string)
+	Unlock(email string)
+	CleanupExpiredAttempts()
+}
+
+// attemptEntry представляє лічильник невдалих спроб для акаунта або IP
+type attemptEntry struct {
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time
+}
+
+// attemptStore зберігає лічильники невдалих спроб входу.
+// Сховище, спільне для всіх реплік (PostgreSQL, Redis), не дає обійти ліміти, розподіляючи спроби між репліками.
+type attemptStore interface {
+	// get повертає лічильник; nil означає, що невдалих спроб немає
+	get(key string) (*attemptEntry, error)
+	// recordFailure атомарно збільшує лічильник (скидаючи його після FailureWindow) і блокує при досягненні threshold
+	recordFailure(key string, threshold int, now time.Time) (*attemptEntry, error)
+	reset(key string) error
+	cleanup(now time.Time) (int64, error)
+}
+
+// Ключі лічильників у сховищі
+const (
+	attemptAccountPrefix = "account:"
+	attemptIPPrefix      = "ip:"
+)
+
+// loginAttemptService реалізація LoginAttemptService поверх attemptStore
+type loginAttemptService struct {
+	store  attemptStore
+	config LoginAttemptConfig
+}
+
+// NewLoginAttemptService створює LoginAttemptService з лічильниками в пам'яті (лише для однієї репліки)
+func NewLoginAttemptService(config LoginAttemptConfig) LoginAttemptService {
+	return newLoginAttemptService(newMemoryAttemptStore(config), config)
+}
+
+// newLoginAttemptService створює LoginAttemptService з вказаним сховищем лічильників
+func newLoginAttemptService(store attemptStore, config LoginAttemptConfig) LoginAttemptService {
+	service := &loginAttemptService{
+		store:  store,
+		config: config,
+	}
+
+	// Запускаємо горутину для очищення застарілих записів
+	go service.cleanupRoutine()
+
+	return service
+}
+
+// Check перевіряє чи дозволена наступна спроба входу для акаунта та IP.
+// Якщо сховище недоступне, безпечніше відхилити спробу.
+func (s *loginAttemptService) Check(email, ipAddress string) error {
+	now := time.Now()
+	for _, key := range []string{attemptAccountPrefix + normalizeEmail(email), attemptIPPrefix + ipAddress} {
+		entry, err := s.store.get(key)
+		if err != nil {
+			logrus.WithError(err).Error("Failed to check login attempts")
+			return ErrLoginThrottled
+		}
+		if s.isBlocked(entry, now) {
+			return ErrLoginThrottled
+		}
+	}
+	return nil
+}
+
+// RecordFailure фіксує невдалу спробу входу
+func (s *loginAttemptService) RecordFailure(email, ipAddress string) {
+	now := time.Now()
+
+	account, err := s.store.recordFailure(attemptAccountPrefix+normalizeEmail(email), s.config.MaxAccountFailures, now)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to record failed login for account")
+	} else if account.LockedUntil.After(now) && account.Failures == s.config.MaxAccountFailures {
+		logSecurityEvent("account_locked", logrus.Fields{
+			"email":        email,
+			"ip_address":   ipAddress,
+			"failures":     account.Failures,
+			"locked_until": account.LockedUntil,
+		})
+	}
+
+	ip, err := s.store.recordFailure(attemptIPPrefix+ipAddress, s.config.MaxIPFailures, now)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to record failed login for IP")
+	} else if ip.LockedUntil.After(now) && ip.Failures == s.config.MaxIPFailures {
+		logSecurityEvent("ip_locked", logrus.Fields{
+			"ip_address":   ipAddress,
+			"failures":     ip.Failures,
+			"locked_until": ip.LockedUntil,
+		})
+	}
+}
+
+// RecordSuccess скидає лічильник акаунта після успішного входу.
+// Лічильник IP не скидається: інакше вхід у власний акаунт між спробами обнуляв би обмеження перебору з цієї адреси.
+func (s *loginAttemptService) RecordSuccess(email, ipAddress string) {
+	if err := s.store.reset(attemptAccountPrefix + normalizeEmail(email)); err != nil {
+		logrus.WithError(err).Error("Failed to reset login attempts")
+	}
+}
+
+// Unlock знімає блокування з акаунта
+func (s *loginAttemptService) Unlock(email string) {
+	if err := s.store.reset(attemptAccountPrefix + normalizeEmail(email)); err != nil {
+		logrus.WithError(err).Error("Failed to unlock account")
+	}
+}
+
+// CleanupExpiredAttempts видаляє записи, які вийшли за межі вікна спостереження
+func (s *loginAttemptService) CleanupExpiredAttempts() {
+	cleaned, err := s.store.cleanup(time.Now())
+	if err != nil {
+		logrus.WithError(err).Error("Failed to clean up expired login attempts")
+		return
+	}
+
+	if cleaned > 0 {
+		logrus.WithField("cleaned_count", cleaned).Debug("Cleaned up expired login attempts")
+	}
+}
+
+// isBlocked перевіряє блокування та експоненційну затримку між спробами
+func (s *loginAttemptService) isBlocked(entry *attemptEntry, now time.Time) bool {
+	if entry == nil {
+		return false
+	}
+	if now.Before(entry.LockedUntil) {
+		return true
+	}
+	if now.Sub(entry.LastFailure) > s.config.FailureWindow {
+		return false
+	}
+	return now.Before(entry.LastFailure.Add(s.backoff(entry.Failures)))
+}
+
+// backoff повертає затримку base * 2^(failures-1), обмежену MaxDelay
+func (s *loginAttemptService) backoff(failures int) time.Duration {
+	if failures <= 0 || s.config.BaseDelay <= 0 {
+		return 0
+	}
+
+	delay := s.config.BaseDelay
+	for i := 1; i < failures && delay < s.config.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > s.config.MaxDelay {
+		delay = s.config.MaxDelay
+	}
+	return delay
+}
+
+// cleanupRoutine періодично очищає застарілі записи
+func (s *loginAttemptService) cleanupRoutine() {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.CleanupExpiredAttempts()
+	}
+}
+
+// memoryAttemptStore реалізація attemptStore (in-memory)
+type memoryAttemptStore struct {
+	entries map[string]*attemptEntry
+	mutex   sync.Mutex
+	config  LoginAttemptConfig
+}
+
+// newMemoryAttemptStore створює attemptStore, що зберігає лічильники в пам'яті процесу
+func newMemoryAttemptStore(config LoginAttemptConfig) *memoryAttemptStore {
+	return &memoryAttemptStore{
+		entries: make(map[string]*attemptEntry),
+		config:  config,
+	}
+}
+
+// get повертає копію лічильника
+func (s *memoryAttemptStore) get(key string) (*attemptEntry, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry, exists := s.entries[key]
+	if !exists {
+		return nil, nil
+	}
+	copied := *entry
+	return &copied, nil
+}
+
+// recordFailure збільшує лічильник і встановлює блокування при досягненні порогу
+func (s *memoryAttemptStore) recordFailure(key string, threshold int, now time.Time) (*attemptEntry, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry, exists := s.entries[key]
+	if !exists || now.Sub(entry.LastFailure) > s.config.FailureWindow {
+		entry = &attemptEntry{}
+		s.entries[key] = entry
+	}
+
+	entry.Failures++
+	entry.LastFailure = now
+
+	if threshold > 0 && entry.Failures >= threshold {
+		entry.LockedUntil = now.Add(s.config.LockoutDuration)
+	}
+
+	copied := *entry
+	return &copied, nil
+}
+
+// reset видаляє лічильник
+func (s *memoryAttemptStore) reset(key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// cleanup видаляє лічильники без блокування, остання спроба яких вийшла за межі вікна
+func (s *memoryAttemptStore) cleanup(now time.Time) (int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var cleaned int64
+	for key, entry := range s.entries {
+		if now.After(entry.LockedUntil) && now.Sub(entry.LastFailure) > s.config.FailureWindow {
+			delete(s.entries, key)
+			cleaned++
+		}
+	}
+	return cleaned, nil
+}
+
+// normalizeEmail приводить email до єдиного вигляду для ключів лічильників
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// logSecurityEvent пише структурований запис про подію безпеки
+func logSecurityEvent(event string, fields logrus.Fields) {
+	logrus.WithFields(fields).WithField("audit_event", event).Info("Security event")
+}
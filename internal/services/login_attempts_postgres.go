@@ -0,0 +1,103 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LoginAttempt представляє лічильник невдалих спроб входу для акаунта або IP
+type LoginAttempt struct {
+	Key         string    `gorm:"primaryKey;size:320"`
+	Failures    int       `gorm:"not null"`
+	LastFailure time.Time `gorm:"not null;index"`
+	LockedUntil time.Time `gorm:"not null"`
+}
+
+// TableName явно задає ім'я таблиці для GORM
+func (LoginAttempt) TableName() string {
+	return "login_attempts"
+}
+
+// pgAttemptStore реалізація attemptStore (PostgreSQL), спільна для всіх реплік
+type pgAttemptStore struct {
+	db     *gorm.DB
+	config LoginAttemptConfig
+}
+
+// NewPostgresLoginAttemptService створює LoginAttemptService з лічильниками в таблиці login_attempts
+func NewPostgresLoginAttemptService(db *gorm.DB, config LoginAttemptConfig) LoginAttemptService {
+	return newLoginAttemptService(&pgAttemptStore{db: db, config: config}, config)
+}
+
+// get повертає лічильник; nil означає, що невдалих спроб немає
+func (s *pgAttemptStore) get(key string) (*attemptEntry, error) {
+	var attempts []LoginAttempt
+	if err := s.db.Where("key = ?", key).Limit(1).Find(&attempts).Error; err != nil {
+		return nil, fmt.Errorf("failed to get login attempts: %w", err)
+	}
+	if len(attempts) == 0 {
+		return nil, nil
+	}
+	return &attemptEntry{
+		Failures:    attempts[0].Failures,
+		LastFailure: attempts[0].LastFailure,
+		LockedUntil: attempts[0].LockedUntil,
+	}, nil
+}
+
+// recordFailure атомарно збільшує лічильник одним upsert, тож паралельні спроби на різних репліках не губляться.
+// Лічильник, остання спроба якого старша за FailureWindow, починається заново разом з блокуванням.
+func (s *pgAttemptStore) recordFailure(key string, threshold int, now time.Time) (*attemptEntry, error) {
+	initialLock := time.Time{}
+	if threshold > 0 && threshold <= 1 {
+		initialLock = now.Add(s.config.LockoutDuration)
+	}
+
+	var attempt LoginAttempt
+	err := s.db.Raw(`
+		INSERT INTO login_attempts AS a (key, failures, last_failure, locked_until)
+		VALUES (@key, 1, @now, @initial_lock)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN a.last_failure < @window_start THEN 1 ELSE a.failures + 1 END,
+			locked_until = CASE
+				WHEN @threshold > 0 AND (CASE WHEN a.last_failure < @window_start THEN 1 ELSE a.failures + 1 END) >= @threshold THEN @locked_until
+				WHEN a.last_failure < @window_start THEN @zero
+				ELSE a.locked_until
+			END,
+			last_failure = @now
+		RETURNING key, failures, last_failure, locked_until`,
+		map[string]interface{}{
+			"key":          key,
+			"now":          now,
+			"initial_lock": initialLock,
+			"window_start": now.Add(-s.config.FailureWindow),
+			"threshold":    threshold,
+			"locked_until": now.Add(s.config.LockoutDuration),
+			"zero":         time.Time{},
+		},
+	).Scan(&attempt).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to record login attempt: %w", err)
+	}
+
+	return &attemptEntry{
+		Failures:    attempt.Failures,
+		LastFailure: attempt.LastFailure,
+		LockedUntil: attempt.LockedUntil,
+	}, nil
+}
+
+// reset видаляє лічильник
+func (s *pgAttemptStore) reset(key string) error {
+	if err := s.db.Where("key = ?", key).Delete(&LoginAttempt{}).Error; err != nil {
+		return fmt.Errorf("failed to reset login attempts: %w", err)
+	}
+	return nil
+}
+
+// cleanup видаляє лічильники без блокування, остання спроба яких вийшла за межі вікна
+func (s *pgAttemptStore) cleanup(now time.Time) (int64, error) {
+	return deleteExpiredRows(s.db, "login_attempts", "key", "locked_until < ? AND last_failure < ?", now, now.Add(-s.config.FailureWindow))
+}
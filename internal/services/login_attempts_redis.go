@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const redisLoginAttemptsPrefix = "login_attempts:"
+
+// redisRecordFailure атомарно збільшує лічильник у hash і встановлює блокування при досягненні порогу.
+// KEYS[1] — ключ; ARGV: now (ms), failure window (ms), threshold, lockout (ms), TTL ключа (ms).
+var redisRecordFailure = redis.NewScript(`
+local failures = tonumber(redis.call('HGET', KEYS[1], 'failures') or '0')
+local last = tonumber(redis.call('HGET', KEYS[1], 'last_failure') or '0')
+local locked = tonumber(redis.call('HGET', KEYS[1], 'locked_until') or '0')
+local now = tonumber(ARGV[1])
+if now - last > tonumber(ARGV[2]) then
+	failures = 0
+	locked = 0
+end
+failures = failures + 1
+local threshold = tonumber(ARGV[3])
+if threshold > 0 and failures >= threshold then
+	locked = now + tonumber(ARGV[4])
+end
+redis.call('HSET', KEYS[1], 'failures', failures, 'last_failure', now, 'locked_until', locked)
+redis.call('PEXPIRE', KEYS[1], ARGV[5])
+return {failures, locked}
+`)
+
+// redisAttemptStore реалізація attemptStore (Redis), спільна для всіх реплік.
+// Лічильник зберігається як hash з TTL, тож застарілі записи Redis видаляє сам.
+type redisAttemptStore struct {
+	client *redis.Client
+	config LoginAttemptConfig
+}
+
+// NewRedisLoginAttemptService створює LoginAttemptService з лічильниками в Redis
+func NewRedisLoginAttemptService(client *redis.Client, config LoginAttemptConfig) LoginAttemptService {
+	return newLoginAttemptService(&redisAttemptStore{client: client, config: config}, config)
+}
+
+// get повертає лічильник; nil означає, що невдалих спроб немає
+func (s *redisAttemptStore) get(key string) (*attemptEntry, error) {
+	values, err := s.client.HMGet(context.Background(), redisLoginAttemptsPrefix+key, "failures", "last_failure", "locked_until").Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login attempts: %w", err)
+	}
+	if values[0] == nil {
+		return nil, nil
+	}
+
+	fields := make([]int64, len(values))
+	for i, value := range values {
+		str, _ := value.(string)
+		if fields[i], err = strconv.ParseInt(str, 10, 64); err != nil {
+			return nil, fmt.Errorf("failed to parse login attempts: %w", err)
+		}
+	}
+	return &attemptEntry{
+		Failures:    int(fields[0]),
+		LastFailure: time.UnixMilli(fields[1]),
+		LockedUntil: time.UnixMilli(fields[2]),
+	}, nil
+}
+
+// recordFailure атомарно збільшує лічильник Lua скриптом
+func (s *redisAttemptStore) recordFailure(key string, threshold int, now time.Time) (*attemptEntry, error) {
+	ttl := s.config.FailureWindow + s.config.LockoutDuration
+	result, err := redisRecordFailure.Run(context.Background(), s.client, []string{redisLoginAttemptsPrefix + key},
+		now.UnixMilli(), s.config.FailureWindow.Milliseconds(), threshold, s.config.LockoutDuration.Milliseconds(), ttl.Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to record login attempt: %w", err)
+	}
+
+	entry := &attemptEntry{
+		Failures:    int(result[0]),
+		LastFailure: now,
+	}
+	if result[1] > 0 {
+		entry.LockedUntil = time.UnixMilli(result[1])
+	}
+	return entry, nil
+}
+
+// reset видаляє лічильник
+func (s *redisAttemptStore) reset(key string) error {
+	if err := s.client.Del(context.Background(), redisLoginAttemptsPrefix+key).Err(); err != nil {
+		return fmt.Errorf("failed to reset login attempts: %w", err)
+	}
+	return nil
+}
+
+// cleanup нічого не робить: Redis сам видаляє записи за TTL
+func (s *redisAttemptStore) cleanup(now time.Time) (int64, error) {
+	return 0, nil
+}
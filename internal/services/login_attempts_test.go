@@ -0,0 +1,139 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+var testLoginAttemptConfig = LoginAttemptConfig{
+	MaxAccountFailures: 5,
+	MaxIPFailures:      20,
+	BaseDelay:          time.Second,
+	MaxDelay:           30 * time.Second,
+	LockoutDuration:    15 * time.Minute,
+	FailureWindow:      15 * time.Minute,
+}
+
+// newTestLoginAttemptService створює сервіс без фонового очищення
+func newTestLoginAttemptService(store attemptStore) *loginAttemptService {
+	return &loginAttemptService{store: store, config: testLoginAttemptConfig}
+}
+
+func TestLoginAttemptBackoff(t *testing.T) {
+	s := newTestLoginAttemptService(nil)
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{5, 16 * time.Second},
+		{6, 30 * time.Second},
+		{100, 30 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := s.backoff(tt.failures); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestLoginAttemptIsBlocked(t *testing.T) {
+	s := newTestLoginAttemptService(nil)
+	now := time.Now()
+
+	tests := []struct {
+		name  string
+		entry *attemptEntry
+		want  bool
+	}{
+		{"no failures", nil, false},
+		{"within backoff", &attemptEntry{Failures: 3, LastFailure: now.Add(-3 * time.Second)}, true},
+		{"after backoff", &attemptEntry{Failures: 3, LastFailure: now.Add(-5 * time.Second)}, false},
+		{"locked", &attemptEntry{Failures: 5, LastFailure: now.Add(-time.Hour), LockedUntil: now.Add(time.Minute)}, true},
+		{"lock expired", &attemptEntry{Failures: 5, LastFailure: now.Add(-time.Hour), LockedUntil: now.Add(-time.Minute)}, false},
+	}
+	for _, tt := range tests {
+		if got := s.isBlocked(tt.entry, now); got != tt.want {
+			t.Errorf("%s: isBlocked = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLoginAttemptLockout(t *testing.T) {
+	s := newTestLoginAttemptService(newMemoryAttemptStore(testLoginAttemptConfig))
+
+	for i := 0; i < testLoginAttemptConfig.MaxAccountFailures; i++ {
+		s.RecordFailure("User@Example.com", "10.0.0.1")
+	}
+
+	entry, err := s.store.get(attemptAccountPrefix + "user@example.com")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if entry == nil || !entry.LockedUntil.After(time.Now().Add(14*time.Minute)) {
+		t.Fatalf("account entry = %+v, want lockout of %v", entry, testLoginAttemptConfig.LockoutDuration)
+	}
+	if err := s.Check("user@example.com", "10.0.0.2"); err != ErrLoginThrottled {
+		t.Fatalf("Check for locked account = %v, want ErrLoginThrottled", err)
+	}
+
+	// Успішний вхід знімає блокування акаунта, але не лічильник IP
+	s.RecordSuccess("user@example.com", "10.0.0.1")
+	if entry, _ := s.store.get(attemptAccountPrefix + "user@example.com"); entry != nil {
+		t.Fatalf("account entry after success = %+v, want nil", entry)
+	}
+	ip, _ := s.store.get(attemptIPPrefix + "10.0.0.1")
+	if ip == nil || ip.Failures != testLoginAttemptConfig.MaxAccountFailures {
+		t.Fatalf("ip entry after success = %+v, want %d failures", ip, testLoginAttemptConfig.MaxAccountFailures)
+	}
+}
+
+func TestRedisAttemptStore(t *testing.T) {
+	_, client := newTestRedis(t)
+	store := &redisAttemptStore{client: client, config: testLoginAttemptConfig}
+	now := time.Now()
+
+	var entry *attemptEntry
+	var err error
+	for i := 1; i <= 3; i++ {
+		entry, err = store.recordFailure("account:user@example.com", 3, now)
+		if err != nil {
+			t.Fatalf("recordFailure: %v", err)
+		}
+		if entry.Failures != i {
+			t.Fatalf("failures = %d, want %d", entry.Failures, i)
+		}
+	}
+	if !entry.LockedUntil.After(now) {
+		t.Fatalf("LockedUntil = %v, want lockout after reaching threshold", entry.LockedUntil)
+	}
+
+	stored, err := store.get("account:user@example.com")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if stored == nil || stored.Failures != 3 || stored.LockedUntil.UnixMilli() != entry.LockedUntil.UnixMilli() {
+		t.Fatalf("get = %+v, want %+v", stored, entry)
+	}
+
+	// Спроба після вікна починає лічильник заново
+	later := now.Add(testLoginAttemptConfig.FailureWindow + time.Second)
+	entry, err = store.recordFailure("account:user@example.com", 3, later)
+	if err != nil {
+		t.Fatalf("recordFailure: %v", err)
+	}
+	if entry.Failures != 1 || !entry.LockedUntil.IsZero() {
+		t.Fatalf("entry after window = %+v, want fresh counter", entry)
+	}
+
+	if err := store.reset("account:user@example.com"); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	if stored, _ := store.get("account:user@example.com"); stored != nil {
+		t.Fatalf("get after reset = %+v, want nil", stored)
+	}
+}
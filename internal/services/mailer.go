@@ -0,0 +1,81 @@
+package services
+
+import (
+	"fmt"
+	"net/smtp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Mailer інтерфейс для відправки email повідомлень
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// logMailer реалізація Mailer яка лише логує повідомлення (для розробки)
+type logMailer struct {
+	from string
+}
+
+// NewLogMailer створює Mailer, який пише листи в лог замість відправки
+func NewLogMailer(from string) Mailer {
+	return &logMailer{
+		from: from,
+	}
+}
+
+// Send логує повідомлення
+func (m *logMailer) Send(to, subject, body string) error {
+	logrus.WithFields(logrus.Fields{
+		"from":    m.from,
+		"to":      to,
+		"subject": subject,
+		"body":    body,
+	}).Info("📧 Email message (log mailer)")
+	return nil
+}
+
+// smtpMailer реалізація Mailer через SMTP
+type smtpMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPMailer створює Mailer, який відправляє листи через SMTP сервер
+func NewSMTPMailer(host string, port int, username, password, from string) Mailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &smtpMailer{
+		addr: fmt.Sprintf("%s:%d", host, port),
+		from: from,
+		auth: auth,
+	}
+}
+
+// Send відправляє лист через SMTP
+func (m *smtpMailer) Send(to, subject, body string) error {
+	var msg strings.Builder
+	msg.WriteString("From: " + m.from + "\r\n")
+	msg.WriteString("To: " + to + "\r\n")
+	msg.WriteString("Subject: " + subject + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(body)
+
+	if err := smtp.SendMail(m.addr, m.auth, m.from, []string{to}, []byte(msg.String())); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"to":      to,
+		"subject": subject,
+	}).Info("Email sent successfully")
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Типи подій розмов
+const (
+	MessageEventMessage = "message"
+	MessageEventDeleted = "message_deleted"
+	MessageEventRead    = "read"
+)
+
+// messageEventKind вид події розмови в PostgresEventBridge
+const messageEventKind = "direct_message"
+
+// messageStreamBuffer скільки подій чекає на повільного підписника, перш ніж нові відкидаються
+const messageStreamBuffer = 32
+
+// MessageEvent подія розмови, що доставляється учасникам у реальному часі
+type MessageEvent struct {
+	Type           string   `json:"type"`
+	ConversationID uint64   `json:"conversation_id"`
+	Message        *Message `json:"message,omitempty"`
+	MessageID      uint64   `json:"message_id,omitempty"`
+	ReaderID       string   `json:"reader_id,omitempty"`
+	ReadUpTo       uint64   `json:"read_up_to,omitempty"`
+}
+
+// MessagePublisher доставляє подію розмови відкритим з'єднанням учасників
+type MessagePublisher interface {
+	Publish(userIDs []string, event MessageEvent) error
+}
+
+// MessageHub розсилає події розмов з'єднанням, відкритим у цій репліці.
+// Сам по собі є MessagePublisher для розгортання з однією реплікою.
+type MessageHub struct {
+	*hub[MessageEvent]
+}
+
+// NewMessageHub створює новий MessageHub
+func NewMessageHub() *MessageHub {
+	return &MessageHub{
+		hub: newHub[MessageEvent](messageStreamBuffer),
+	}
+}
+
+// Publish надсилає подію всім з'єднанням користувачів без блокування.
+// Повільний підписник пропускає подію — повідомлення залишається в історії розмови.
+func (h *MessageHub) Publish(userIDs []string, event MessageEvent) error {
+	h.publish(userIDs, event, func(userID string) {
+		logrus.WithFields(logrus.Fields{
+			"conversation_id": event.ConversationID,
+			"event_type":      event.Type,
+			"user_id":         userID,
+		}).Warn("Message stream is full, dropping event")
+	})
+	return nil
+}
+
+// messageNotifyEvent корисне навантаження NOTIFY; текст повідомлення читається з таблиці,
+// бо розмір payload у PostgreSQL обмежений 8000 байтами
+type messageNotifyEvent struct {
+	UserIDs []string     `json:"user_ids"`
+	Event   MessageEvent `json:"event"`
+}
+
+// pgMessageBridge реалізація MessagePublisher через PostgreSQL LISTEN/NOTIFY:
+// подія з будь-якої репліки доходить до з'єднань учасників в усіх репліках
+type pgMessageBridge struct {
+	bridge *postgresBridge[messageNotifyEvent]
+	db     *gorm.DB
+	hub    *MessageHub
+}
+
+// NewPostgresMessageBridge створює MessagePublisher, що розсилає події розмов між репліками.
+// Спільний слухач events передає отримані події в hub цієї репліки.
+func NewPostgresMessageBridge(db *gorm.DB, events *PostgresEventBridge, hub *MessageHub) MessagePublisher {
+	bridge := &pgMessageBridge{
+		db:  db,
+		hub: hub,
+	}
+	bridge.bridge = newPostgresBridge(events, messageEventKind, bridge.dispatch)
+
+	return bridge
+}
+
+// Publish надсилає подію без тексту повідомлення в канал; доставку в hub виконує слухач кожної репліки
+func (b *pgMessageBridge) Publish(userIDs []string, event MessageEvent) error {
+	if event.Message != nil {
+		event.MessageID = event.Message.ID
+		event.Message = nil
+	}
+	return b.bridge.notify(messageNotifyEvent{UserIDs: userIDs, Event: event})
+}
+
+// dispatch відновлює подію і передає її в hub, якщо учасники мають відкриті з'єднання в цій репліці
+func (b *pgMessageBridge) dispatch(notify messageNotifyEvent) {
+	if !b.hub.hasAnySubscriber(notify.UserIDs...) {
+		return
+	}
+
+	event := notify.Event
+	if event.Type == MessageEventMessage {
+		var message Message
+		if err := b.db.First(&message, event.MessageID).Error; err != nil {
+			logrus.WithError(err).WithField("message_id", event.MessageID).Warn("Failed to load message for stream")
+			return
+		}
+		event.Message = &message
+		event.MessageID = 0
+	}
+	_ = b.hub.Publish(notify.UserIDs, event)
+}
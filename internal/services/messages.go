@@ -0,0 +1,427 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxMessageLength максимальна довжина повідомлення в символах
+const maxMessageLength = 4000
+
+var (
+	// ErrCannotMessageSelf повертається при спробі почати розмову з самим собою
+	ErrCannotMessageSelf = errors.New("cannot message yourself")
+	// ErrMessagingBlocked повертається коли один з користувачів заблокував іншого
+	ErrMessagingBlocked = errors.New("messaging blocked")
+	// ErrConversationNotFound повертається коли розмови немає або користувач не є її учасником
+	ErrConversationNotFound = errors.New("conversation not found")
+	// ErrMessageNotFound повертається коли повідомлення немає, його видалено або воно належить іншому відправнику
+	ErrMessageNotFound = errors.New("message not found")
+	// ErrInvalidMessage повертається для порожнього або задовгого повідомлення
+	ErrInvalidMessage = errors.New("invalid message")
+)
+
+// Conversation розмова двох користувачів; UserAID завжди менший за UserBID, тож пара унікальна незалежно від порядку
+type Conversation struct {
+	ID            uint64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserAID       string     `gorm:"size:255;not null;uniqueIndex:idx_conversations_pair" json:"-"`
+	UserBID       string     `gorm:"size:255;not null;uniqueIndex:idx_conversations_pair;index" json:"-"`
+	LastMessageAt *time.Time `json:"last_message_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// TableName явно задає ім'я таблиці для GORM
+func (Conversation) TableName() string {
+	return "conversations"
+}
+
+// otherParticipant повертає співрозмовника userID
+func (c *Conversation) otherParticipant(userID string) string {
+	if c.UserAID == userID {
+		return c.UserBID
+	}
+	return c.UserAID
+}
+
+// Message повідомлення в розмові. Видалене відправником повідомлення зникає для обох учасників.
+type Message struct {
+	ID             uint64         `gorm:"primaryKey;autoIncrement;index:idx_messages_conversation,priority:2" json:"id"`
+	ConversationID uint64         `gorm:"not null;index:idx_messages_conversation,priority:1" json:"conversation_id"`
+	SenderID       string         `gorm:"size:255;not null" json:"sender_id"`
+	RecipientID    string         `gorm:"size:255;not null" json:"recipient_id"`
+	Body           string         `gorm:"type:text;not null" json:"body"`
+	ReadAt         *time.Time     `json:"read_at"`
+	CreatedAt      time.Time      `json:"created_at"`
+	DeletedAt      gorm.DeletedAt `json:"-"`
+}
+
+// TableName явно задає ім'я таблиці для GORM
+func (Message) TableName() string {
+	return "messages"
+}
+
+// ConversationSummary розмова з погляду учасника: співрозмовник, останнє повідомлення та кількість непрочитаних
+type ConversationSummary struct {
+	Conversation
+	Participant *User    `json:"participant"`
+	LastMessage *Message `json:"last_message"`
+	UnreadCount int64    `json:"unread_count"`
+}
+
+// MessageService інтерфейс для особистих повідомлень між друзями
+type MessageService interface {
+	StartConversation(userID, otherID string) (*ConversationSummary, error)
+	ListConversations(userID string, limit, offset int) ([]ConversationSummary, int64, error)
+	GetConversation(userID string, conversationID uint64) (*ConversationSummary, error)
+	SendMessage(userID string, conversationID uint64, body string) (*Message, error)
+	History(userID string, conversationID, before uint64, limit int) ([]Message, uint64, error)
+	MarkRead(userID string, conversationID, upTo uint64) (int64, error)
+	DeleteMessage(userID string, conversationID, messageID uint64) error
+	Subscribe(userID string) (<-chan MessageEvent, func())
+}
+
+// messageService реалізація MessageService (PostgreSQL)
+type messageService struct {
+	db          *gorm.DB
+	userService UserService
+	hub         *MessageHub
+	publisher   MessagePublisher
+}
+
+// NewMessageService створює новий MessageService.
+// Події розмов доставляються підписникам hub через publisher: напряму або через іншу репліку.
+func NewMessageService(db *gorm.DB, userService UserService, hub *MessageHub, publisher MessagePublisher) MessageService {
+	return &messageService{
+		db:          db,
+		userService: userService,
+		hub:         hub,
+		publisher:   publisher,
+	}
+}
+
+// StartConversation повертає розмову з otherID, створюючи її за потреби. Писати можна лише друзям.
+func (s *messageService) StartConversation(userID, otherID string) (*ConversationSummary, error) {
+	if userID == otherID {
+		return nil, ErrCannotMessageSelf
+	}
+	if _, err := s.userService.GetUserByID(otherID); err != nil {
+		return nil, ErrUserNotFound
+	}
+	if err := s.checkCanMessage(userID, otherID); err != nil {
+		return nil, err
+	}
+
+	conversation := &Conversation{
+		UserAID: min(userID, otherID),
+		UserBID: max(userID, otherID),
+	}
+	if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(conversation).Error; err != nil {
+		return nil, fmt.Errorf("failed to create conversation: %w", err)
+	}
+	err := s.db.Where("user_a_id = ? AND user_b_id = ?", conversation.UserAID, conversation.UserBID).First(conversation).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+
+	summaries, err := s.summarize(userID, []Conversation{*conversation})
+	if err != nil {
+		return nil, err
+	}
+	return &summaries[0], nil
+}
+
+// ListConversations повертає сторінку розмов користувача, починаючи з тих, де останнє повідомлення найновіше
+func (s *messageService) ListConversations(userID string, limit, offset int) ([]ConversationSummary, int64, error) {
+	query := s.db.Model(&Conversation{}).Where("user_a_id = ? OR user_b_id = ?", userID, userID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count conversations: %w", err)
+	}
+
+	var conversations []Conversation
+	err := query.Order("last_message_at DESC NULLS LAST, id DESC").
+		Limit(friendListLimit(limit)).
+		Offset(max(offset, 0)).
+		Find(&conversations).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list conversations: %w", err)
+	}
+
+	summaries, err := s.summarize(userID, conversations)
+	if err != nil {
+		return nil, 0, err
+	}
+	return summaries, total, nil
+}
+
+// GetConversation повертає розмову, учасником якої є userID
+func (s *messageService) GetConversation(userID string, conversationID uint64) (*ConversationSummary, error) {
+	conversation, err := s.conversation(userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries, err := s.summarize(userID, []Conversation{*conversation})
+	if err != nil {
+		return nil, err
+	}
+	return &summaries[0], nil
+}
+
+// SendMessage надсилає повідомлення співрозмовнику, якщо вони досі друзі і ніхто нікого не заблокував
+func (s *messageService) SendMessage(userID string, conversationID uint64, body string) (*Message, error) {
+	body = strings.TrimSpace(body)
+	if body == "" || utf8.RuneCountInString(body) > maxMessageLength {
+		return nil, ErrInvalidMessage
+	}
+
+	conversation, err := s.conversation(userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	recipientID := conversation.otherParticipant(userID)
+	if err := s.checkCanMessage(userID, recipientID); err != nil {
+		return nil, err
+	}
+
+	message := &Message{
+		ConversationID: conversation.ID,
+		SenderID:       userID,
+		RecipientID:    recipientID,
+		Body:           body,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(message).Error; err != nil {
+			return err
+		}
+		return tx.Model(conversation).Updates(map[string]interface{}{
+			"last_message_at": message.CreatedAt,
+			"updated_at":      time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send message: %w", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"conversation_id": conversation.ID,
+		"message_id":      message.ID,
+		"sender_id":       userID,
+	}).Debug("Message sent")
+
+	s.publish(MessageEvent{
+		Type:           MessageEventMessage,
+		ConversationID: conversation.ID,
+		Message:        message,
+	}, userID, recipientID)
+
+	return message, nil
+}
+
+// History повертає сторінку повідомлень розмови від новіших до старіших та курсор наступної сторінки.
+// before — ID найстарішого повідомлення попередньої сторінки (0 — з найновішого); курсор 0 означає, що повідомлень більше немає.
+func (s *messageService) History(userID string, conversationID, before uint64, limit int) ([]Message, uint64, error) {
+	if _, err := s.conversation(userID, conversationID); err != nil {
+		return nil, 0, err
+	}
+
+	limit = friendListLimit(limit)
+	query := s.db.Where("conversation_id = ?", conversationID)
+	if before > 0 {
+		query = query.Where("id < ?", before)
+	}
+
+	var messages []Message
+	if err := query.Order("id DESC").Limit(limit).Find(&messages).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get message history: %w", err)
+	}
+
+	var next uint64
+	if len(messages) == limit {
+		next = messages[len(messages)-1].ID
+	}
+	return messages, next, nil
+}
+
+// MarkRead позначає прочитаними вхідні повідомлення розмови до upTo включно (0 — усі) і повідомляє відправника.
+// Повертає кількість щойно прочитаних повідомлень.
+func (s *messageService) MarkRead(userID string, conversationID, upTo uint64) (int64, error) {
+	conversation, err := s.conversation(userID, conversationID)
+	if err != nil {
+		return 0, err
+	}
+
+	if upTo == 0 {
+		err := s.db.Model(&Message{}).
+			Where("conversation_id = ? AND recipient_id = ?", conversationID, userID).
+			Select("COALESCE(MAX(id), 0)").
+			Scan(&upTo).Error
+		if err != nil {
+			return 0, fmt.Errorf("failed to mark messages as read: %w", err)
+		}
+	}
+
+	result := s.db.Model(&Message{}).
+		Where("conversation_id = ? AND recipient_id = ? AND id <= ? AND read_at IS NULL", conversationID, userID, upTo).
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to mark messages as read: %w", result.Error)
+	}
+
+	if result.RowsAffected > 0 {
+		s.publish(MessageEvent{
+			Type:           MessageEventRead,
+			ConversationID: conversationID,
+			ReaderID:       userID,
+			ReadUpTo:       upTo,
+		}, userID, conversation.otherParticipant(userID))
+	}
+	return result.RowsAffected, nil
+}
+
+// DeleteMessage видаляє (soft delete) власне повідомлення для обох учасників
+func (s *messageService) DeleteMessage(userID string, conversationID, messageID uint64) error {
+	conversation, err := s.conversation(userID, conversationID)
+	if err != nil {
+		return err
+	}
+
+	result := s.db.Where("id = ? AND conversation_id = ? AND sender_id = ?", messageID, conversationID, userID).Delete(&Message{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete message: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrMessageNotFound
+	}
+
+	s.publish(MessageEvent{
+		Type:           MessageEventDeleted,
+		ConversationID: conversationID,
+		MessageID:      messageID,
+	}, userID, conversation.otherParticipant(userID))
+
+	return nil
+}
+
+// Subscribe відкриває потік подій розмов користувача в цій репліці; другий результат закриває потік
+func (s *messageService) Subscribe(userID string) (<-chan MessageEvent, func()) {
+	return s.hub.Subscribe(userID)
+}
+
+// conversation повертає розмову, учасником якої є userID
+func (s *messageService) conversation(userID string, conversationID uint64) (*Conversation, error) {
+	var conversation Conversation
+	err := s.db.Where("id = ? AND (user_a_id = ? OR user_b_id = ?)", conversationID, userID, userID).First(&conversation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+	return &conversation, nil
+}
+
+// checkCanMessage перевіряє, що користувачі друзі і ніхто з них не заблокував іншого
+func (s *messageService) checkCanMessage(userID, otherID string) error {
+	blocked, err := isBlockedBetween(s.db, userID, otherID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrMessagingBlocked
+	}
+
+	areFriends, err := s.userService.AreFriends(userID, otherID)
+	if err != nil {
+		return fmt.Errorf("failed to check friendship: %w", err)
+	}
+	if !areFriends {
+		return ErrNotFriends
+	}
+	return nil
+}
+
+// summarize доповнює розмови співрозмовником, останнім повідомленням та кількістю непрочитаних для userID
+func (s *messageService) summarize(userID string, conversations []Conversation) ([]ConversationSummary, error) {
+	summaries := make([]ConversationSummary, len(conversations))
+	if len(conversations) == 0 {
+		return summaries, nil
+	}
+
+	ids := make([]uint64, len(conversations))
+	participantIDs := make([]string, len(conversations))
+	for i, conversation := range conversations {
+		ids[i] = conversation.ID
+		participantIDs[i] = conversation.otherParticipant(userID)
+	}
+
+	var participants []User
+	if err := s.db.Where("id IN ?", participantIDs).Find(&participants).Error; err != nil {
+		return nil, fmt.Errorf("failed to get conversation participants: %w", err)
+	}
+	participantsByID := make(map[string]*User, len(participants))
+	for i := range participants {
+		participantsByID[participants[i].ID] = &participants[i]
+	}
+
+	var lastMessages []Message
+	err := s.db.Raw(`
+		SELECT DISTINCT ON (conversation_id) *
+		FROM messages
+		WHERE conversation_id IN ? AND deleted_at IS NULL
+		ORDER BY conversation_id, id DESC
+	`, ids).Scan(&lastMessages).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last messages: %w", err)
+	}
+	lastMessagesByID := make(map[uint64]*Message, len(lastMessages))
+	for i := range lastMessages {
+		lastMessagesByID[lastMessages[i].ConversationID] = &lastMessages[i]
+	}
+
+	var unread []struct {
+		ConversationID uint64
+		Count          int64
+	}
+	err = s.db.Model(&Message{}).
+		Select("conversation_id, COUNT(*) AS count").
+		Where("conversation_id IN ? AND recipient_id = ? AND read_at IS NULL", ids, userID).
+		Group("conversation_id").
+		Scan(&unread).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count unread messages: %w", err)
+	}
+	unreadByID := make(map[uint64]int64, len(unread))
+	for _, row := range unread {
+		unreadByID[row.ConversationID] = row.Count
+	}
+
+	for i, conversation := range conversations {
+		summaries[i] = ConversationSummary{
+			Conversation: conversation,
+			Participant:  participantsByID[participantIDs[i]],
+			LastMessage:  lastMessagesByID[conversation.ID],
+			UnreadCount:  unreadByID[conversation.ID],
+		}
+	}
+	return summaries, nil
+}
+
+// publish доставляє подію розмови обом учасникам; помилка доставки лише логується
+func (s *messageService) publish(event MessageEvent, userIDs ...string) {
+	if err := s.publisher.Publish(userIDs, event); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"conversation_id": event.ConversationID,
+			"event_type":      event.Type,
+		}).Warn("Failed to publish message event")
+	}
+}
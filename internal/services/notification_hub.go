@@ -0,0 +1,48 @@
+package services
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// notificationStreamBuffer скільки сповіщень чекає на повільного підписника, перш ніж нові відкидаються
+const notificationStreamBuffer = 16
+
+// NotificationPublisher доставляє нове сповіщення відкритим потокам отримувача
+type NotificationPublisher interface {
+	Publish(notification *Notification) error
+}
+
+// NotificationHub розсилає сповіщення потокам, відкритим у цій репліці.
+// Сам по собі є NotificationPublisher для розгортання з однією реплікою.
+type NotificationHub struct {
+	*hub[Notification]
+}
+
+// NewNotificationHub створює новий NotificationHub
+func NewNotificationHub() *NotificationHub {
+	return &NotificationHub{
+		hub: newHub[Notification](notificationStreamBuffer),
+	}
+}
+
+// Publish надсилає сповіщення всім потокам отримувача без блокування.
+// Повільний підписник пропускає сповіщення — воно залишається у списку непрочитаних.
+func (h *NotificationHub) Publish(notification *Notification) error {
+	h.publish([]string{notification.UserID}, *notification, func(string) {
+		logNotificationError(nil, notification, "Notification stream is full, dropping notification")
+	})
+	return nil
+}
+
+// logNotificationError логує помилку доставки сповіщення
+func logNotificationError(err error, notification *Notification, message string) {
+	entry := logrus.WithFields(logrus.Fields{
+		"notification_id":   notification.ID,
+		"notification_type": notification.Type,
+		"user_id":           notification.UserID,
+	})
+	if err != nil {
+		entry = entry.WithError(err)
+	}
+	entry.Warn(message)
+}
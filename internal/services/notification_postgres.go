@@ -0,0 +1,55 @@
+package services
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// notificationEventKind вид події нового сповіщення в PostgresEventBridge
+const notificationEventKind = "notification"
+
+// notificationEvent корисне навантаження NOTIFY; саме сповіщення читається з таблиці,
+// бо розмір payload у PostgreSQL обмежений 8000 байтами
+type notificationEvent struct {
+	ID     uint64 `json:"id"`
+	UserID string `json:"user_id"`
+}
+
+// pgNotificationBridge реалізація NotificationPublisher через PostgreSQL LISTEN/NOTIFY:
+// сповіщення, створене в будь-якій репліці, доходить до потоків отримувача в усіх репліках
+type pgNotificationBridge struct {
+	bridge *postgresBridge[notificationEvent]
+	db     *gorm.DB
+	hub    *NotificationHub
+}
+
+// NewPostgresNotificationBridge створює NotificationPublisher, що розсилає сповіщення між репліками.
+// Спільний слухач events передає отримані сповіщення в hub цієї репліки.
+func NewPostgresNotificationBridge(db *gorm.DB, events *PostgresEventBridge, hub *NotificationHub) NotificationPublisher {
+	bridge := &pgNotificationBridge{
+		db:  db,
+		hub: hub,
+	}
+	bridge.bridge = newPostgresBridge(events, notificationEventKind, bridge.dispatch)
+
+	return bridge
+}
+
+// Publish надсилає ID сповіщення в канал; доставку в hub виконує слухач кожної репліки, включно з цією
+func (b *pgNotificationBridge) Publish(notification *Notification) error {
+	return b.bridge.notify(notificationEvent{ID: notification.ID, UserID: notification.UserID})
+}
+
+// dispatch читає сповіщення з таблиці і передає його в hub, якщо отримувач має відкриті потоки в цій репліці
+func (b *pgNotificationBridge) dispatch(event notificationEvent) {
+	if !b.hub.hasAnySubscriber(event.UserID) {
+		return
+	}
+
+	var notification Notification
+	if err := b.db.Preload("Actor").First(&notification, event.ID).Error; err != nil {
+		logrus.WithError(err).WithField("notification_id", event.ID).Warn("Failed to load notification for stream")
+		return
+	}
+	_ = b.hub.Publish(&notification)
+}
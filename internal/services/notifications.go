@@ -0,0 +1,159 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Типи сповіщень
+const (
+	NotificationFriendRequest         = "friend_request"
+	NotificationFriendRequestAccepted = "friend_request_accepted"
+	NotificationFollow                = "follow"
+	NotificationFollowRequest         = "follow_request"
+	NotificationFollowApproved        = "follow_approved"
+)
+
+// ErrNotificationNotFound повертається коли сповіщення немає або воно належить іншому користувачу
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// Notification сповіщення користувача UserID про дію ActorID
+type Notification struct {
+	ID        uint64                 `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    string                 `gorm:"size:255;not null;index:idx_notifications_user_created,priority:1" json:"user_id"`
+	Type      string                 `gorm:"size:64;not null" json:"type"`
+	ActorID   string                 `gorm:"size:255" json:"actor_id,omitempty"`
+	Payload   map[string]interface{} `gorm:"serializer:json;type:jsonb" json:"payload,omitempty"`
+	ReadAt    *time.Time             `json:"read_at"`
+	CreatedAt time.Time              `gorm:"not null;index:idx_notifications_user_created,priority:2,sort:desc" json:"created_at"`
+
+	// Заповнюється у списку та потоці сповіщень
+	Actor *User `gorm:"foreignKey:ActorID;-:migration" json:"actor,omitempty"`
+}
+
+// TableName явно задає ім'я таблиці для GORM
+func (Notification) TableName() string {
+	return "notifications"
+}
+
+// NotificationService інтерфейс для сповіщень користувачів
+type NotificationService interface {
+	Notify(userID, notificationType, actorID string, payload map[string]interface{}) (*Notification, error)
+	List(userID string, unreadOnly bool, limit, offset int) ([]Notification, int64, error)
+	UnreadCount(userID string) (int64, error)
+	MarkRead(userID string, notificationID uint64) (*Notification, error)
+	MarkAllRead(userID string) (int64, error)
+	Subscribe(userID string) (<-chan Notification, func())
+}
+
+// notificationService реалізація NotificationService (PostgreSQL)
+type notificationService struct {
+	db        *gorm.DB
+	hub       *NotificationHub
+	publisher NotificationPublisher
+}
+
+// NewNotificationService створює новий NotificationService.
+// Нові сповіщення доставляються підписникам hub через publisher: напряму або через іншу репліку.
+func NewNotificationService(db *gorm.DB, hub *NotificationHub, publisher NotificationPublisher) NotificationService {
+	return &notificationService{
+		db:        db,
+		hub:       hub,
+		publisher: publisher,
+	}
+}
+
+// Notify зберігає сповіщення і надсилає його відкритим потокам отримувача.
+// Помилка доставки в реальному часі не повертається: сповіщення вже збережене і буде в списку.
+func (s *notificationService) Notify(userID, notificationType, actorID string, payload map[string]interface{}) (*Notification, error) {
+	notification := &Notification{
+		UserID:    userID,
+		Type:      notificationType,
+		ActorID:   actorID,
+		Payload:   payload,
+		CreatedAt: time.Now(),
+	}
+	if err := s.db.Create(notification).Error; err != nil {
+		return nil, fmt.Errorf("failed to create notification: %w", err)
+	}
+	if err := s.db.Preload("Actor").First(notification, notification.ID).Error; err != nil {
+		return nil, fmt.Errorf("failed to load notification: %w", err)
+	}
+
+	if err := s.publisher.Publish(notification); err != nil {
+		logNotificationError(err, notification, "Failed to publish notification")
+	}
+	return notification, nil
+}
+
+// List повертає сторінку сповіщень користувача від новіших до старіших і їх загальну кількість
+func (s *notificationService) List(userID string, unreadOnly bool, limit, offset int) ([]Notification, int64, error) {
+	query := s.db.Model(&Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count notifications: %w", err)
+	}
+
+	var notifications []Notification
+	err := query.Preload("Actor").
+		Order("created_at DESC, id DESC").
+		Limit(friendListLimit(limit)).
+		Offset(max(offset, 0)).
+		Find(&notifications).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	return notifications, total, nil
+}
+
+// UnreadCount повертає кількість непрочитаних сповіщень
+func (s *notificationService) UnreadCount(userID string) (int64, error) {
+	var count int64
+	if err := s.db.Model(&Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+	return count, nil
+}
+
+// MarkRead позначає сповіщення прочитаним; повторне позначення зберігає перший момент прочитання
+func (s *notificationService) MarkRead(userID string, notificationID uint64) (*Notification, error) {
+	err := s.db.Model(&Notification{}).
+		Where("id = ? AND user_id = ? AND read_at IS NULL", notificationID, userID).
+		Update("read_at", time.Now()).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark notification as read: %w", err)
+	}
+
+	var notification Notification
+	err = s.db.Preload("Actor").Where("id = ? AND user_id = ?", notificationID, userID).First(&notification).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotificationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification: %w", err)
+	}
+	return &notification, nil
+}
+
+// MarkAllRead позначає прочитаними всі сповіщення користувача і повертає їх кількість
+func (s *notificationService) MarkAllRead(userID string) (int64, error) {
+	result := s.db.Model(&Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to mark notifications as read: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// Subscribe відкриває потік нових сповіщень користувача в цій репліці; другий результат закриває потік
+func (s *notificationService) Subscribe(userID string) (<-chan Notification, func()) {
+	return s.hub.Subscribe(userID)
+}
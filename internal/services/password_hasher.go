@@ -0,0 +1,179 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Підтримувані алгоритми хешування паролів
+const (
+	PasswordAlgorithmArgon2id = "argon2id"
+	PasswordAlgorithmBcrypt   = "bcrypt"
+)
+
+// ErrUnsupportedPasswordHash повертається для хешів невідомого формату
+var ErrUnsupportedPasswordHash = errors.New("unsupported password hash format")
+
+// Argon2Params містить параметри argon2id
+type Argon2Params struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// PasswordHasherConfig містить налаштування хешування паролів
+type PasswordHasherConfig struct {
+	Algorithm  string
+	Argon2     Argon2Params
+	BcryptCost int
+}
+
+// PasswordHasher інтерфейс для хешування та перевірки паролів
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(password, encodedHash string) (bool, error)
+	NeedsRehash(encodedHash string) bool
+}
+
+// passwordHasher реалізація PasswordHasher (argon2id у форматі PHC, bcrypt для сумісності)
+type passwordHasher struct {
+	config PasswordHasherConfig
+}
+
+// NewPasswordHasher створює новий PasswordHasher
+func NewPasswordHasher(config PasswordHasherConfig) PasswordHasher {
+	return &passwordHasher{
+		config: config,
+	}
+}
+
+// Hash хешує пароль налаштованим алгоритмом
+func (h *passwordHasher) Hash(password string) (string, error) {
+	if h.config.Algorithm == PasswordAlgorithmBcrypt {
+		hashed, err := bcrypt.GenerateFromPassword([]byte(password), h.config.BcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(hashed), nil
+	}
+
+	salt := make([]byte, h.config.Argon2.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	params := h.config.Argon2
+	key := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		params.Memory,
+		params.Iterations,
+		params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify перевіряє пароль проти argon2id або bcrypt хешу
+func (h *passwordHasher) Verify(password, encodedHash string) (bool, error) {
+	switch {
+	case encodedHash == "":
+		return false, nil
+	case strings.HasPrefix(encodedHash, "$argon2id$"):
+		params, salt, key, err := decodeArgon2Hash(encodedHash)
+		if err != nil {
+			return false, err
+		}
+		candidate := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+		return subtle.ConstantTimeCompare(key, candidate) == 1, nil
+	case isBcryptHash(encodedHash):
+		err := bcrypt.CompareHashAndPassword([]byte(encodedHash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return true, nil
+	default:
+		return false, ErrUnsupportedPasswordHash
+	}
+}
+
+// NeedsRehash визначає чи хеш створено іншим алгоритмом або з іншими параметрами
+func (h *passwordHasher) NeedsRehash(encodedHash string) bool {
+	if h.config.Algorithm == PasswordAlgorithmBcrypt {
+		if !isBcryptHash(encodedHash) {
+			return true
+		}
+		cost, err := bcrypt.Cost([]byte(encodedHash))
+		return err != nil || cost != h.config.BcryptCost
+	}
+
+	if !strings.HasPrefix(encodedHash, "$argon2id$") {
+		return true
+	}
+	params, salt, _, err := decodeArgon2Hash(encodedHash)
+	if err != nil {
+		return true
+	}
+
+	return params.Memory != h.config.Argon2.Memory ||
+		params.Iterations != h.config.Argon2.Iterations ||
+		params.Parallelism != h.config.Argon2.Parallelism ||
+		params.KeyLength != h.config.Argon2.KeyLength ||
+		uint32(len(salt)) != h.config.Argon2.SaltLength
+}
+
+// decodeArgon2Hash розбирає PHC рядок $argon2id$v=19$m=...,t=...,p=...$salt$hash
+func decodeArgon2Hash(encodedHash string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+
+	parts := strings.Split(encodedHash, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, ErrUnsupportedPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2 version: %w", err)
+	}
+	if version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("incompatible argon2 version: %d", version)
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2 parameters: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2 salt: %w", err)
+	}
+	params.SaltLength = uint32(len(salt))
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2 hash: %w", err)
+	}
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
+
+// isBcryptHash перевіряє чи рядок є bcrypt хешем
+func isBcryptHash(encodedHash string) bool {
+	return strings.HasPrefix(encodedHash, "$2a$") ||
+		strings.HasPrefix(encodedHash, "$2b$") ||
+		strings.HasPrefix(encodedHash, "$2y$")
+}
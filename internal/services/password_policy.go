@@ -0,0 +1,217 @@
+package services
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Правила парольної політики
+const (
+	PasswordRuleMinLength    = "min_length"
+	PasswordRuleMaxLength    = "max_length"
+	PasswordRuleUppercase    = "uppercase"
+	PasswordRuleLowercase    = "lowercase"
+	PasswordRuleDigit        = "digit"
+	PasswordRuleSymbol       = "symbol"
+	PasswordRulePersonalInfo = "personal_info"
+	PasswordRuleBreached     = "breached"
+)
+
+// BcryptMaxPasswordBytes кількість байтів пароля, яку враховує bcrypt
+const BcryptMaxPasswordBytes = 72
+
+// minPersonalInfoLength мінімальна довжина частини імені чи email, яку заборонено містити в паролі
+const minPersonalInfoLength = 3
+
+// PasswordPolicyConfig містить налаштування парольної політики
+type PasswordPolicyConfig struct {
+	MinLength int
+	MaxLength int
+	// MaxBytes обмежує довжину пароля в байтах UTF-8 (0 — без обмеження); bcrypt враховує лише перші 72 байти
+	MaxBytes          int
+	RequireUppercase  bool
+	RequireLowercase  bool
+	RequireDigit      bool
+	RequireSymbol     bool
+	AllowPersonalInfo bool
+
+	// Директорія з файлами діапазонів хешів у форматі k-anonymity (<PREFIX>.txt з рядками SUFFIX:COUNT)
+	BreachedPasswordsDir string
+}
+
+// PasswordViolation описує порушення одного правила політики
+type PasswordViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicyError повертається коли пароль не відповідає політиці
+type PasswordPolicyError struct {
+	Violations []PasswordViolation
+}
+
+// Error реалізує інтерфейс error
+func (e *PasswordPolicyError) Error() string {
+	rules := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		rules = append(rules, v.Rule)
+	}
+	return "password does not satisfy policy: " + strings.Join(rules, ", ")
+}
+
+// PasswordPolicy інтерфейс для перевірки паролів
+type PasswordPolicy interface {
+	Validate(password, email, name string) error
+}
+
+// passwordPolicy реалізація PasswordPolicy
+type passwordPolicy struct {
+	config PasswordPolicyConfig
+}
+
+// NewPasswordPolicy створює нову парольну політику
+func NewPasswordPolicy(config PasswordPolicyConfig) PasswordPolicy {
+	return &passwordPolicy{
+		config: config,
+	}
+}
+
+// Validate перевіряє пароль і повертає *PasswordPolicyError з усіма порушеннями
+func (p *passwordPolicy) Validate(password, email, name string) error {
+	var violations []PasswordViolation
+
+	length := utf8.RuneCountInString(password)
+	if p.config.MinLength > 0 && length < p.config.MinLength {
+		violations = append(violations, PasswordViolation{
+			Rule:    PasswordRuleMinLength,
+			Message: fmt.Sprintf("Password must be at least %d characters long", p.config.MinLength),
+		})
+	}
+	if p.config.MaxLength > 0 && length > p.config.MaxLength {
+		violations = append(violations, PasswordViolation{
+			Rule:    PasswordRuleMaxLength,
+			Message: fmt.Sprintf("Password must be at most %d characters long", p.config.MaxLength),
+		})
+	} else if p.config.MaxBytes > 0 && len(password) > p.config.MaxBytes {
+		violations = append(violations, PasswordViolation{
+			Rule:    PasswordRuleMaxLength,
+			Message: fmt.Sprintf("Password must be at most %d bytes long", p.config.MaxBytes),
+		})
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	if p.config.RequireUppercase && !hasUpper {
+		violations = append(violations, PasswordViolation{
+			Rule:    PasswordRuleUppercase,
+			Message: "Password must contain an uppercase letter",
+		})
+	}
+	if p.config.RequireLowercase && !hasLower {
+		violations = append(violations, PasswordViolation{
+			Rule:    PasswordRuleLowercase,
+			Message: "Password must contain a lowercase letter",
+		})
+	}
+	if p.config.RequireDigit && !hasDigit {
+		violations = append(violations, PasswordViolation{
+			Rule:    PasswordRuleDigit,
+			Message: "Password must contain a digit",
+		})
+	}
+	if p.config.RequireSymbol && !hasSymbol {
+		violations = append(violations, PasswordViolation{
+			Rule:    PasswordRuleSymbol,
+			Message: "Password must contain a symbol",
+		})
+	}
+
+	if !p.config.AllowPersonalInfo && containsPersonalInfo(password, email, name) {
+		violations = append(violations, PasswordViolation{
+			Rule:    PasswordRulePersonalInfo,
+			Message: "Password must not contain your name or email address",
+		})
+	}
+
+	if p.config.BreachedPasswordsDir != "" && p.isBreached(password) {
+		violations = append(violations, PasswordViolation{
+			Rule:    PasswordRuleBreached,
+			Message: "Password has appeared in a data breach, choose a different one",
+		})
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// isBreached шукає SHA-1 пароля у локальному файлі діапазону за 5-символьним префіксом
+func (p *passwordPolicy) isBreached(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	file, err := os.Open(filepath.Join(p.config.BreachedPasswordsDir, prefix+".txt"))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logrus.WithError(err).Warn("Failed to open breached passwords range file")
+		}
+		return false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		candidate, _, _ := strings.Cut(line, ":")
+		if strings.EqualFold(candidate, suffix) {
+			return true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		logrus.WithError(err).Warn("Failed to read breached passwords range file")
+	}
+
+	return false
+}
+
+// containsPersonalInfo перевіряє чи містить пароль частини email або імені
+func containsPersonalInfo(password, email, name string) bool {
+	lowered := strings.ToLower(password)
+
+	localPart, _, _ := strings.Cut(strings.ToLower(email), "@")
+	parts := []string{localPart}
+	parts = append(parts, strings.FieldsFunc(localPart, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})...)
+	parts = append(parts, strings.Fields(strings.ToLower(name))...)
+
+	for _, part := range parts {
+		if utf8.RuneCountInString(part) >= minPersonalInfoLength && strings.Contains(lowered, part) {
+			return true
+		}
+	}
+	return false
+}
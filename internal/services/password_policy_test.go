@@ -0,0 +1,125 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// violatedRules повертає правила, порушені паролем
+func violatedRules(t *testing.T, err error) []string {
+	t.Helper()
+
+	if err == nil {
+		return nil
+	}
+	var policyErr *PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("Validate returned %T, want *PasswordPolicyError", err)
+	}
+	rules := make([]string, 0, len(policyErr.Violations))
+	for _, v := range policyErr.Violations {
+		rules = append(rules, v.Rule)
+	}
+	return rules
+}
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	policy := NewPasswordPolicy(PasswordPolicyConfig{
+		MinLength:        8,
+		MaxLength:        16,
+		RequireUppercase: true,
+		RequireLowercase: true,
+		RequireDigit:     true,
+		RequireSymbol:    true,
+	})
+
+	tests := []struct {
+		name     string
+		password string
+		want     []string
+	}{
+		{"valid", "Str0ng!pass", nil},
+		{"too short", "S0!a", []string{PasswordRuleMinLength}},
+		{"too long", "Str0ng!password-too-long", []string{PasswordRuleMaxLength}},
+		{"no uppercase", "str0ng!pass", []string{PasswordRuleUppercase}},
+		{"no lowercase", "STR0NG!PASS", []string{PasswordRuleLowercase}},
+		{"no digit", "Strong!pass", []string{PasswordRuleDigit}},
+		{"no symbol", "Str0ngpass", []string{PasswordRuleSymbol}},
+		{"all missing", "aaaaaaaa", []string{PasswordRuleUppercase, PasswordRuleDigit, PasswordRuleSymbol}},
+		// Довжина рахується в символах, а не в байтах
+		{"multibyte within limit", "Пароль1!Пароль", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := violatedRules(t, policy.Validate(tt.password, "someone@example.com", "Someone"))
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("Validate(%q) violations = %v, want %v", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyMaxBytes(t *testing.T) {
+	policy := NewPasswordPolicy(PasswordPolicyConfig{
+		MinLength: 8,
+		MaxLength: 128,
+		MaxBytes:  BcryptMaxPasswordBytes,
+	})
+
+	// 40 кириличних символів — 80 байтів UTF-8: в межах max_length, але bcrypt обрізав би їх
+	password := strings.Repeat("ї", 40)
+	got := violatedRules(t, policy.Validate(password, "someone@example.com", "Someone"))
+	if len(got) != 1 || got[0] != PasswordRuleMaxLength {
+		t.Fatalf("violations = %v, want [%s]", got, PasswordRuleMaxLength)
+	}
+
+	if err := policy.Validate(strings.Repeat("ї", 36), "someone@example.com", "Someone"); err != nil {
+		t.Fatalf("72-byte password rejected: %v", err)
+	}
+}
+
+func TestPasswordPolicyPersonalInfo(t *testing.T) {
+	policy := NewPasswordPolicy(PasswordPolicyConfig{MinLength: 8})
+
+	tests := []struct {
+		name     string
+		password string
+		want     bool
+	}{
+		{"contains email local part", "xx-jane.doe-xx", true},
+		{"contains email fragment", "my-doe-password", true},
+		{"contains name", "Kowalski2024!", true},
+		{"unrelated", "correct horse battery", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := violatedRules(t, policy.Validate(tt.password, "jane.doe@example.com", "Anna Kowalski"))
+			violated := len(got) == 1 && got[0] == PasswordRulePersonalInfo
+			if violated != tt.want {
+				t.Fatalf("Validate(%q) violations = %v, want personal_info violation: %v", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyBreached(t *testing.T) {
+	dir := t.TempDir()
+	// SHA-1("password1") = E38AD214943DAAD1D64C102FAEC29DE4AFE9DA3D
+	if err := os.WriteFile(filepath.Join(dir, "E38AD.txt"), []byte("214943DAAD1D64C102FAEC29DE4AFE9DA3D:2413945\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	policy := NewPasswordPolicy(PasswordPolicyConfig{MinLength: 8, BreachedPasswordsDir: dir})
+
+	got := violatedRules(t, policy.Validate("password1", "someone@example.com", "Someone"))
+	if len(got) != 1 || got[0] != PasswordRuleBreached {
+		t.Fatalf("violations = %v, want [%s]", got, PasswordRuleBreached)
+	}
+	if err := policy.Validate("not-in-the-list", "someone@example.com", "Someone"); err != nil {
+		t.Fatalf("unbreached password rejected: %v", err)
+	}
+}
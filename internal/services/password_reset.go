@@ -0,0 +1,166 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidPasswordResetToken повертається коли токен скидання пароля не знайдено, використано або прострочено
+	ErrInvalidPasswordResetToken = errors.New("invalid or expired password reset token")
+	// ErrPasswordResetRequired повертається при вході, поки користувач не встановив новий пароль
+	ErrPasswordResetRequired = errors.New("password reset required")
+)
+
+// PasswordResetToken представляє одноразовий токен скидання пароля
+type PasswordResetToken struct {
+	ID        uint       `gorm:"primaryKey;autoIncrement"`
+	UserID    string     `gorm:"size:255;not null;index"`
+	TokenHash string     `gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt time.Time  `gorm:"not null;index"`
+	UsedAt    *time.Time `gorm:"index"`
+	CreatedAt time.Time
+}
+
+// TableName явно задає ім'я таблиці для GORM
+func (PasswordResetToken) TableName() string {
+	return "password_reset_tokens"
+}
+
+// PasswordResetService інтерфейс для скидання паролів
+type PasswordResetService interface {
+	SendReset(user *User) error
+	ResetPassword(token, newPassword string) (*User, error)
+}
+
+// passwordResetService реалізація PasswordResetService
+type passwordResetService struct {
+	db          *gorm.DB
+	userService UserService
+	mailer      Mailer
+	ttl         time.Duration
+	resetURL    string
+}
+
+// NewPasswordResetService створює новий PasswordResetService
+func NewPasswordResetService(db *gorm.DB, userService UserService, mailer Mailer, ttl time.Duration, resetURL string) PasswordResetService {
+	return &passwordResetService{
+		db:          db,
+		userService: userService,
+		mailer:      mailer,
+		ttl:         ttl,
+		resetURL:    resetURL,
+	}
+}
+
+// SendReset створює токен скидання і відправляє лист з посиланням
+func (s *passwordResetService) SendReset(user *User) error {
+	token, err := generateOpaqueToken("", 32)
+	if err != nil {
+		return fmt.Errorf("failed to generate password reset token: %w", err)
+	}
+
+	now := time.Now()
+	record := PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: now.Add(s.ttl),
+		CreatedAt: now,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Попередні невикористані токени більше не дійсні
+		if err := tx.Model(&PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", user.ID).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Create(&record).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store password reset token: %w", err)
+	}
+
+	link := s.resetURL + "?token=" + token
+	body := fmt.Sprintf("Hello %s,\n\nA password reset is required for your account. Set a new password by opening the link below:\n\n%s\n\nThe link expires in %s.\n",
+		user.Name, link, s.ttl)
+
+	if err := s.mailer.Send(user.Email, "Reset your password", body); err != nil {
+		return err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"user_id":    user.ID,
+		"expires_at": record.ExpiresAt,
+	}).Info("Password reset email sent")
+
+	return nil
+}
+
+// ResetPassword встановлює новий пароль за токеном і знімає вимогу скидання
+func (s *passwordResetService) ResetPassword(token, newPassword string) (*User, error) {
+	var record PasswordResetToken
+	err := s.db.Where("token_hash = ? AND used_at IS NULL", hashToken(token)).First(&record).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrInvalidPasswordResetToken
+		}
+		return nil, fmt.Errorf("failed to get password reset token: %w", err)
+	}
+
+	if time.Now().After(record.ExpiresAt) {
+		return nil, ErrInvalidPasswordResetToken
+	}
+
+	user, err := s.userService.GetUserByID(record.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := s.userService.HashNewPassword(user, newPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	// Токен погашається до зміни пароля в одній транзакції: з двох паралельних запитів з тим самим посиланням проходить лише один
+	now := time.Now()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL", record.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidPasswordResetToken
+		}
+
+		result = tx.Model(&User{}).Where("id = ? AND is_active = ?", user.ID, true).Updates(map[string]interface{}{
+			"password_hash":           hashedPassword,
+			"password_reset_required": false,
+			"updated_at":              now,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrUserNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrInvalidPasswordResetToken) || errors.Is(err, ErrUserNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to reset password: %w", err)
+	}
+
+	user.PasswordHash = hashedPassword
+	user.PasswordResetRequired = false
+	logrus.WithField("user_id", user.ID).Info("Password reset completed")
+	return user, nil
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// PersonalAccessTokenPrefix дозволяє відрізнити personal access token від JWT (і знайти його у витоках)
+const PersonalAccessTokenPrefix = "gpp_"
+
+// MaxPersonalAccessTokenTTL максимальний термін дії personal access token
+const MaxPersonalAccessTokenTTL = 365 * 24 * time.Hour
+
+// lastUsedUpdateInterval мінімальний інтервал між записами часу останнього використання
+const lastUsedUpdateInterval = time.Minute
+
+var (
+	// ErrInvalidPersonalAccessToken повертається коли токен не знайдено, відкликано або прострочено
+	ErrInvalidPersonalAccessToken = errors.New("invalid personal access token")
+	// ErrPersonalAccessTokenNotFound повертається коли токен не належить користувачу
+	ErrPersonalAccessTokenNotFound = errors.New("personal access token not found")
+)
+
+// PersonalAccessToken представляє довготривалий токен для скриптів та інтеграцій
+type PersonalAccessToken struct {
+	ID          string     `gorm:"primaryKey;size:255" json:"id"`
+	UserID      string     `gorm:"size:255;not null;index" json:"-"`
+	Name        string     `gorm:"size:255;not null" json:"name"`
+	TokenHash   string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	TokenPrefix string     `gorm:"size:16;not null" json:"token_prefix"` // перші символи токена для ідентифікації
+	Scopes      []string   `gorm:"serializer:json;type:text" json:"scopes"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	LastUsedIP  string     `gorm:"size:64" json:"last_used_ip"`
+	RevokedAt   *time.Time `json:"-"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// TableName явно задає ім'я таблиці для GORM
+func (PersonalAccessToken) TableName() string {
+	return "personal_access_tokens"
+}
+
+// IsPersonalAccessToken перевіряє чи рядок має формат personal access token
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, PersonalAccessTokenPrefix)
+}
+
+// PersonalAccessTokenService інтерфейс для керування personal access tokens
+type PersonalAccessTokenService interface {
+	CreateToken(userID, name string, scopes []string, ttl time.Duration) (*PersonalAccessToken, string, error)
+	ListTokens(userID string) ([]PersonalAccessToken, error)
+	RevokeToken(userID, tokenID string) error
+	Authenticate(token, ipAddress string) (*PersonalAccessToken, error)
+}
+
+// personalAccessTokenService реалізація PersonalAccessTokenService
+type personalAccessTokenService struct {
+	db *gorm.DB
+}
+
+// NewPersonalAccessTokenService створює новий PersonalAccessTokenService
+func NewPersonalAccessTokenService(db *gorm.DB) PersonalAccessTokenService {
+	return &personalAccessTokenService{
+		db: db,
+	}
+}
+
+// CreateToken створює токен і повертає його відкрите значення (показується лише один раз).
+// ttl = 0 означає токен без терміну дії.
+func (s *personalAccessTokenService) CreateToken(userID, name string, scopes []string, ttl time.Duration) (*PersonalAccessToken, string, error) {
+	if len(scopes) == 0 {
+		return nil, "", fmt.Errorf("%w: at least one scope is required", ErrInvalidScope)
+	}
+	for _, scope := range scopes {
+		if !containsScope(PersonalAccessTokenScopes, scope) {
+			return nil, "", fmt.Errorf("%w: %s", ErrInvalidScope, scope)
+		}
+	}
+	if ttl < 0 || ttl > MaxPersonalAccessTokenTTL {
+		return nil, "", fmt.Errorf("token lifetime must not exceed %s", MaxPersonalAccessTokenTTL)
+	}
+
+	token, err := generateOpaqueToken(PersonalAccessTokenPrefix, 32)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate personal access token: %w", err)
+	}
+	id, err := generateOpaqueToken("pat_", 8)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate token ID: %w", err)
+	}
+
+	now := time.Now()
+	record := &PersonalAccessToken{
+		ID:          id,
+		UserID:      userID,
+		Name:        name,
+		TokenHash:   hashToken(token),
+		TokenPrefix: token[:len(PersonalAccessTokenPrefix)+6],
+		Scopes:      scopes,
+		CreatedAt:   now,
+	}
+	if ttl > 0 {
+		expiresAt := now.Add(ttl)
+		record.ExpiresAt = &expiresAt
+	}
+
+	if err := s.db.Create(record).Error; err != nil {
+		return nil, "", fmt.Errorf("failed to store personal access token: %w", err)
+	}
+
+	logSecurityEvent("personal_access_token_created", logrus.Fields{
+		"user_id":  userID,
+		"token_id": record.ID,
+		"scopes":   scopes,
+	})
+	return record, token, nil
+}
+
+// ListTokens повертає активні токени користувача.
+// Токени, створені до tokens_valid_after (зміна пароля, примусовий вихід), відкликані і не показуються.
+func (s *personalAccessTokenService) ListTokens(userID string) ([]PersonalAccessToken, error) {
+	var tokens []PersonalAccessToken
+	err := s.db.Where("user_id = ? AND revoked_at IS NULL", userID).
+		Where("NOT EXISTS (SELECT 1 FROM users WHERE users.id = personal_access_tokens.user_id AND personal_access_tokens.created_at < users.tokens_valid_after)").
+		Order("created_at DESC").
+		Find(&tokens).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list personal access tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// RevokeToken відкликає токен користувача
+func (s *personalAccessTokenService) RevokeToken(userID, tokenID string) error {
+	result := s.db.Model(&PersonalAccessToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", tokenID, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke personal access token: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrPersonalAccessTokenNotFound
+	}
+
+	logSecurityEvent("personal_access_token_revoked", logrus.Fields{
+		"user_id":  userID,
+		"token_id": tokenID,
+	})
+	return nil
+}
+
+// Authenticate знаходить токен за хешем і фіксує час та IP використання
+func (s *personalAccessTokenService) Authenticate(token, ipAddress string) (*PersonalAccessToken, error) {
+	var record PersonalAccessToken
+	err := s.db.Where("token_hash = ? AND revoked_at IS NULL", hashToken(token)).First(&record).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrInvalidPersonalAccessToken
+		}
+		return nil, fmt.Errorf("failed to get personal access token: %w", err)
+	}
+
+	now := time.Now()
+	if record.ExpiresAt != nil && now.After(*record.ExpiresAt) {
+		return nil, ErrInvalidPersonalAccessToken
+	}
+
+	// Не пишемо в базу на кожен запит, лише при зміні IP або раз на інтервал
+	if record.LastUsedAt == nil || record.LastUsedIP != ipAddress || now.Sub(*record.LastUsedAt) > lastUsedUpdateInterval {
+		err := s.db.Model(&PersonalAccessToken{}).Where("id = ?", record.ID).Updates(map[string]interface{}{
+			"last_used_at": now,
+			"last_used_ip": ipAddress,
+		}).Error
+		if err != nil {
+			logrus.WithError(err).WithField("token_id", record.ID).Warn("Failed to update token last used time")
+		}
+		record.LastUsedAt = &now
+		record.LastUsedIP = ipAddress
+	}
+
+	return &record, nil
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// postgresListenRetryDelay пауза перед повторним підключенням слухача після помилки
+const postgresListenRetryDelay = 5 * time.Second
+
+// listenPostgres слухає канал PostgreSQL LISTEN/NOTIFY окремим з'єднанням і передає payload у dispatch.
+// Після помилки перепідключається; повідомлення, надіслані під час розриву, не доставляються.
+func listenPostgres(dsn, channel string, dispatch func(payload string)) {
+	for {
+		err := listenPostgresOnce(context.Background(), dsn, channel, dispatch)
+		logrus.WithError(err).WithField("channel", channel).Warn("Postgres listener disconnected, reconnecting")
+		time.Sleep(postgresListenRetryDelay)
+	}
+}
+
+// listenPostgresOnce відкриває з'єднання LISTEN і передає повідомлення в dispatch до першої помилки
+func listenPostgresOnce(ctx context.Context, dsn, channel string, dispatch func(payload string)) error {
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return fmt.Errorf("failed to connect listener: %w", err)
+	}
+	defer conn.Close(ctx)
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", channel, err)
+	}
+	logrus.WithField("channel", channel).Info("Listening for events from other replicas")
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		dispatch(notification.Payload)
+	}
+}
+
+// realtimeChannel єдиний канал LISTEN/NOTIFY для подій реального часу всіх видів
+const realtimeChannel = "realtime_events"
+
+// realtimeEnvelope корисне навантаження NOTIFY: вид події та її дані
+type realtimeEnvelope struct {
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
+}
+
+// PostgresEventBridge пересилає події реального часу (сповіщення, події розмов, присутність) між репліками
+// через один канал PostgreSQL LISTEN/NOTIFY: кожна репліка тримає одне з'єднання LISTEN на всі види подій.
+type PostgresEventBridge struct {
+	db       *gorm.DB
+	dsn      string
+	handlers map[string]func(data json.RawMessage)
+	mutex    sync.RWMutex
+	listen   sync.Once
+}
+
+// NewPostgresEventBridge створює bridge; слухач каналу окремим з'єднанням з dsn запускається з першим видом подій
+func NewPostgresEventBridge(db *gorm.DB, dsn string) *PostgresEventBridge {
+	return &PostgresEventBridge{
+		db:       db,
+		dsn:      dsn,
+		handlers: make(map[string]func(data json.RawMessage)),
+	}
+}
+
+// handle реєструє обробник виду подій і запускає спільного слухача, якщо він ще не працює
+func (b *PostgresEventBridge) handle(kind string, dispatch func(data json.RawMessage)) {
+	b.mutex.Lock()
+	b.handlers[kind] = dispatch
+	b.mutex.Unlock()
+
+	b.listen.Do(func() {
+		go listenPostgres(b.dsn, realtimeChannel, b.dispatch)
+	})
+}
+
+// dispatch передає подію обробнику її виду
+func (b *PostgresEventBridge) dispatch(payload string) {
+	var envelope realtimeEnvelope
+	if err := json.Unmarshal([]byte(payload), &envelope); err != nil {
+		logrus.WithError(err).Warn("Invalid realtime event payload")
+		return
+	}
+
+	b.mutex.RLock()
+	handler, ok := b.handlers[envelope.Kind]
+	b.mutex.RUnlock()
+	if !ok {
+		logrus.WithField("kind", envelope.Kind).Warn("Realtime event of unknown kind")
+		return
+	}
+	handler(envelope.Data)
+}
+
+// notify надсилає подію вказаного виду в канал; payload має вміщатися в 8000 байтів
+func (b *PostgresEventBridge) notify(kind string, event interface{}) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", kind, err)
+	}
+	payload, err := json.Marshal(realtimeEnvelope{Kind: kind, Data: data})
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", kind, err)
+	}
+	if err := b.db.Exec("SELECT pg_notify(?, ?)", realtimeChannel, string(payload)).Error; err != nil {
+		return fmt.Errorf("failed to notify replicas: %w", err)
+	}
+	return nil
+}
+
+// postgresBridge типізований вид подій E у спільному PostgresEventBridge
+type postgresBridge[E any] struct {
+	events *PostgresEventBridge
+	kind   string
+}
+
+// newPostgresBridge реєструє вид подій kind; кожна подія, надіслана будь-якою реплікою (включно з цією), передається в dispatch
+func newPostgresBridge[E any](events *PostgresEventBridge, kind string, dispatch func(event E)) *postgresBridge[E] {
+	events.handle(kind, func(data json.RawMessage) {
+		var event E
+		if err := json.Unmarshal(data, &event); err != nil {
+			logrus.WithError(err).WithField("kind", kind).Warn("Invalid event payload")
+			return
+		}
+		dispatch(event)
+	})
+
+	return &postgresBridge[E]{
+		events: events,
+		kind:   kind,
+	}
+}
+
+// notify надсилає подію в канал; доставку виконує слухач кожної репліки
+func (b *postgresBridge[E]) notify(event E) error {
+	return b.events.notify(b.kind, event)
+}
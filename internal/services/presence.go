@@ -0,0 +1,252 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	// presenceOnlineWindow скільки користувач вважається онлайн після останнього запиту
+	presenceOnlineWindow = 5 * time.Minute
+	// presenceWriteInterval як часто last_seen_at активного користувача записується в базу
+	presenceWriteInterval = time.Minute
+	// presenceSweepInterval як часто перевіряються користувачі, що могли перейти в офлайн
+	presenceSweepInterval = 30 * time.Second
+)
+
+// Presence стан присутності користувача. Для користувача, що приховав статус, Hidden = true і решта полів порожні.
+type Presence struct {
+	UserID     string     `json:"user_id"`
+	Online     bool       `json:"online"`
+	LastSeenAt *time.Time `json:"last_seen_at"`
+	Hidden     bool       `json:"hidden,omitempty"`
+}
+
+// PresenceService інтерфейс для відстеження присутності користувачів
+type PresenceService interface {
+	Touch(userID string)
+	Connect(userID string) func()
+	Presence(users []User) map[string]Presence
+	Announce(userID string) error
+	Subscribe(userID string) (<-chan Presence, func())
+}
+
+// presenceService реалізація PresenceService.
+// Активність у цій репліці відстежується в пам'яті, а last_seen_at періодично записується в базу,
+// тож користувач, активний в іншій репліці, теж вважається онлайн.
+type presenceService struct {
+	db        *gorm.DB
+	hub       *PresenceHub
+	publisher PresencePublisher
+
+	connections map[string]int       // відкриті realtime з'єднання користувача в цій репліці
+	lastActive  map[string]time.Time // користувачі, онлайн з погляду цієї репліки
+	lastWritten map[string]time.Time // коли last_seen_at користувача востаннє записано в базу
+	mutex       sync.Mutex
+}
+
+// NewPresenceService створює новий PresenceService.
+// Зміни присутності доставляються підписаним друзям через publisher: напряму або через іншу репліку.
+func NewPresenceService(db *gorm.DB, hub *PresenceHub, publisher PresencePublisher) PresenceService {
+	service := &presenceService{
+		db:          db,
+		hub:         hub,
+		publisher:   publisher,
+		connections: make(map[string]int),
+		lastActive:  make(map[string]time.Time),
+		lastWritten: make(map[string]time.Time),
+	}
+
+	// Запускаємо горутину, що оновлює last_seen_at з'єднаних користувачів і переводить неактивних в офлайн
+	go service.sweepLoop()
+
+	return service
+}
+
+// Touch позначає користувача активним; перший запит після офлайну повідомляє друзів
+func (s *presenceService) Touch(userID string) {
+	now := time.Now()
+
+	s.mutex.Lock()
+	_, online := s.lastActive[userID]
+	s.lastActive[userID] = now
+	write := now.Sub(s.lastWritten[userID]) >= presenceWriteInterval
+	if write {
+		s.lastWritten[userID] = now
+	}
+	s.mutex.Unlock()
+
+	if write {
+		s.saveLastSeen([]string{userID}, now)
+	}
+	if !online {
+		s.publishOnline(userID, now)
+	}
+}
+
+// Connect позначає користувача онлайн, поки відкрите realtime з'єднання; результат закриває з'єднання
+func (s *presenceService) Connect(userID string) func() {
+	s.mutex.Lock()
+	s.connections[userID]++
+	s.mutex.Unlock()
+
+	s.Touch(userID)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			now := time.Now()
+
+			s.mutex.Lock()
+			s.connections[userID]--
+			if s.connections[userID] <= 0 {
+				delete(s.connections, userID)
+			}
+			s.lastActive[userID] = now
+			s.lastWritten[userID] = now
+			s.mutex.Unlock()
+
+			s.saveLastSeen([]string{userID}, now)
+		})
+	}
+}
+
+// Presence повертає присутність користувачів, завантажених з бази, за їх ID
+func (s *presenceService) Presence(users []User) map[string]Presence {
+	now := time.Now()
+	result := make(map[string]Presence, len(users))
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, user := range users {
+		if user.HidePresence {
+			result[user.ID] = Presence{UserID: user.ID, Hidden: true}
+			continue
+		}
+
+		lastSeen := user.LastSeenAt
+		if lastActive, ok := s.lastActive[user.ID]; ok && (lastSeen == nil || lastActive.After(*lastSeen)) {
+			lastSeen = &lastActive
+		}
+		result[user.ID] = Presence{
+			UserID:     user.ID,
+			Online:     s.connections[user.ID] > 0 || (lastSeen != nil && now.Sub(*lastSeen) < presenceOnlineWindow),
+			LastSeenAt: lastSeen,
+		}
+	}
+	return result
+}
+
+// Announce повідомляє друзів про поточну присутність користувача, наприклад після зміни налаштувань приватності
+func (s *presenceService) Announce(userID string) error {
+	var user User
+	if err := s.db.Where("id = ?", userID).First(&user).Error; err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	presence := s.Presence([]User{user})[userID]
+	if err := s.publisher.Publish(presence); err != nil {
+		return fmt.Errorf("failed to publish presence: %w", err)
+	}
+	return nil
+}
+
+// Subscribe відкриває потік змін присутності друзів користувача в цій репліці; другий результат закриває потік
+func (s *presenceService) Subscribe(userID string) (<-chan Presence, func()) {
+	return s.hub.Subscribe(userID)
+}
+
+// sweepLoop періодично викликає sweep
+func (s *presenceService) sweepLoop() {
+	ticker := time.NewTicker(presenceSweepInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		s.sweep(now)
+	}
+}
+
+// sweep оновлює last_seen_at користувачів з відкритими з'єднаннями і переводить в офлайн тих,
+// хто не був активний протягом presenceOnlineWindow ні в цій, ні в інших репліках
+func (s *presenceService) sweep(now time.Time) {
+	var connected []string
+	idle := make(map[string]time.Time)
+
+	s.mutex.Lock()
+	for userID, lastActive := range s.lastActive {
+		switch {
+		case s.connections[userID] > 0:
+			s.lastActive[userID] = now
+			if now.Sub(s.lastWritten[userID]) >= presenceWriteInterval {
+				s.lastWritten[userID] = now
+				connected = append(connected, userID)
+			}
+		case now.Sub(lastActive) >= presenceOnlineWindow:
+			delete(s.lastActive, userID)
+			delete(s.lastWritten, userID)
+			idle[userID] = lastActive
+		}
+	}
+	s.mutex.Unlock()
+
+	if len(connected) > 0 {
+		s.saveLastSeen(connected, now)
+	}
+	if len(idle) == 0 {
+		return
+	}
+
+	idleIDs := make([]string, 0, len(idle))
+	for userID := range idle {
+		idleIDs = append(idleIDs, userID)
+	}
+
+	var users []User
+	if err := s.db.Select("id", "last_seen_at", "hide_presence").Where("id IN ?", idleIDs).Find(&users).Error; err != nil {
+		logrus.WithError(err).Warn("Failed to load idle users for presence")
+		return
+	}
+	for _, user := range users {
+		// Користувач активний в іншій репліці; вона й повідомить про офлайн
+		if user.LastSeenAt != nil && now.Sub(*user.LastSeenAt) < presenceOnlineWindow {
+			continue
+		}
+		if user.HidePresence {
+			continue
+		}
+		lastSeen := idle[user.ID]
+		s.publishPresence(Presence{UserID: user.ID, LastSeenAt: &lastSeen})
+	}
+}
+
+// publishOnline повідомляє друзів про перехід користувача онлайн, якщо він не приховав статус
+func (s *presenceService) publishOnline(userID string, lastSeen time.Time) {
+	var hidden bool
+	if err := s.db.Model(&User{}).Select("hide_presence").Where("id = ?", userID).Scan(&hidden).Error; err != nil {
+		logrus.WithError(err).WithField("user_id", userID).Warn("Failed to check presence visibility")
+		return
+	}
+	if hidden {
+		return
+	}
+	s.publishPresence(Presence{UserID: userID, Online: true, LastSeenAt: &lastSeen})
+}
+
+// publishPresence доставляє зміну присутності; помилка доставки лише логується
+func (s *presenceService) publishPresence(presence Presence) {
+	if err := s.publisher.Publish(presence); err != nil {
+		logrus.WithError(err).WithField("user_id", presence.UserID).Warn("Failed to publish presence")
+	}
+}
+
+// saveLastSeen записує last_seen_at користувачів без зміни updated_at
+func (s *presenceService) saveLastSeen(userIDs []string, lastSeen time.Time) {
+	if err := s.db.Model(&User{}).Where("id IN ?", userIDs).UpdateColumn("last_seen_at", lastSeen).Error; err != nil {
+		logrus.WithError(err).WithField("users", len(userIDs)).Warn("Failed to save last seen time")
+	}
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// presenceEventKind вид події зміни присутності в PostgresEventBridge
+const presenceEventKind = "presence"
+
+// presenceStreamBuffer скільки змін присутності чекає на повільного підписника, перш ніж нові відкидаються
+const presenceStreamBuffer = 32
+
+// PresencePublisher доставляє зміну присутності користувача потокам його друзів
+type PresencePublisher interface {
+	Publish(presence Presence) error
+}
+
+// PresenceHub розсилає зміни присутності потокам, відкритим у цій репліці
+type PresenceHub struct {
+	*hub[Presence]
+}
+
+// NewPresenceHub створює новий PresenceHub
+func NewPresenceHub() *PresenceHub {
+	return &PresenceHub{
+		hub: newHub[Presence](presenceStreamBuffer),
+	}
+}
+
+// Publish надсилає зміну присутності всім потокам користувачів без блокування.
+// Повільний підписник пропускає зміну — актуальний стан є в GET /api/v1/friends.
+func (h *PresenceHub) Publish(userIDs []string, presence Presence) {
+	h.publish(userIDs, presence, func(userID string) {
+		logrus.WithFields(logrus.Fields{
+			"presence_user_id": presence.UserID,
+			"user_id":          userID,
+		}).Warn("Presence stream is full, dropping event")
+	})
+}
+
+// presenceFanout доставляє зміну присутності користувача друзям, що мають відкриті потоки в цій репліці.
+// Сам по собі є PresencePublisher для розгортання з однією реплікою.
+type presenceFanout struct {
+	db  *gorm.DB
+	hub *PresenceHub
+}
+
+// NewLocalPresencePublisher створює PresencePublisher, що доставляє зміни лише потокам цієї репліки
+func NewLocalPresencePublisher(db *gorm.DB, hub *PresenceHub) PresencePublisher {
+	return &presenceFanout{
+		db:  db,
+		hub: hub,
+	}
+}
+
+// Publish доставляє зміну присутності друзям у цій репліці
+func (f *presenceFanout) Publish(presence Presence) error {
+	subscribers := f.hub.subscriberIDs()
+	if len(subscribers) == 0 {
+		return nil
+	}
+
+	var friendIDs []string
+	err := f.db.Table("friendships").
+		Where("friend_id = ? AND user_id IN ?", presence.UserID, subscribers).
+		Pluck("user_id", &friendIDs).Error
+	if err != nil {
+		return fmt.Errorf("failed to get friends for presence: %w", err)
+	}
+
+	f.hub.Publish(friendIDs, presence)
+	return nil
+}
+
+// pgPresenceBridge реалізація PresencePublisher через PostgreSQL LISTEN/NOTIFY:
+// зміна присутності з будь-якої репліки доходить до потоків друзів в усіх репліках
+type pgPresenceBridge struct {
+	bridge *postgresBridge[Presence]
+	fanout *presenceFanout
+}
+
+// NewPostgresPresenceBridge створює PresencePublisher, що розсилає зміни присутності між репліками.
+// Спільний слухач events передає отримані зміни друзям у цій репліці.
+func NewPostgresPresenceBridge(db *gorm.DB, events *PostgresEventBridge, hub *PresenceHub) PresencePublisher {
+	bridge := &pgPresenceBridge{
+		fanout: &presenceFanout{db: db, hub: hub},
+	}
+	bridge.bridge = newPostgresBridge(events, presenceEventKind, bridge.dispatch)
+
+	return bridge
+}
+
+// Publish надсилає зміну присутності в канал; доставку виконує слухач кожної репліки, включно з цією
+func (b *pgPresenceBridge) Publish(presence Presence) error {
+	return b.bridge.notify(presence)
+}
+
+// dispatch передає отриману зміну присутності друзям у цій репліці
+func (b *pgPresenceBridge) dispatch(presence Presence) {
+	if err := b.fanout.Publish(presence); err != nil {
+		logrus.WithError(err).WithField("user_id", presence.UserID).Warn("Failed to deliver presence")
+	}
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Вбудовані ролі
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user" // неявна роль кожного активного користувача
+)
+
+// Дозволи
+const (
+	PermissionProfileRead  = "profile.read"
+	PermissionProfileWrite = "profile.write"
+	PermissionUsersRead    = "users.read"
+	PermissionUsersList    = "users.list"
+	PermissionUsersManage  = "users.manage"
+	PermissionFriendsRead  = "friends.read"
+	PermissionFriendsWrite = "friends.write"
+	PermissionRolesManage  = "roles.manage"
+	PermissionImpersonate  = "users.impersonate"
+	PermissionAuditRead    = "audit.read"
+)
+
+// DefaultRolePermissions містить дозволи вбудованих ролей, які створюються командою seed
+var DefaultRolePermissions = map[string][]string{
+	RoleUser: {
+		PermissionProfileRead,
+		PermissionProfileWrite,
+		PermissionUsersRead,
+		PermissionFriendsRead,
+		PermissionFriendsWrite,
+	},
+	RoleAdmin: {
+		PermissionProfileRead,
+		PermissionProfileWrite,
+		PermissionUsersRead,
+		PermissionUsersList,
+		PermissionUsersManage,
+		PermissionFriendsRead,
+		PermissionFriendsWrite,
+		PermissionRolesManage,
+		PermissionImpersonate,
+		PermissionAuditRead,
+	},
+}
+
+// ErrRoleNotFound повертається для невідомої ролі
+var ErrRoleNotFound = errors.New("role not found")
+
+// Role представляє роль користувача
+type Role struct {
+	ID          uint         `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name        string       `gorm:"size:64;not null;uniqueIndex" json:"name"`
+	Description string       `gorm:"size:255" json:"description"`
+	Permissions []Permission `gorm:"many2many:role_permissions" json:"permissions,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+// TableName явно задає ім'я таблиці для GORM
+func (Role) TableName() string {
+	return "roles"
+}
+
+// Permission представляє окремий дозвіл
+type Permission struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name        string    `gorm:"size:64;not null;uniqueIndex" json:"name"`
+	Description string    `gorm:"size:255" json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName явно задає ім'я таблиці для GORM
+func (Permission) TableName() string {
+	return "permissions"
+}
+
+// UserRole зв'язує користувача з роллю
+type UserRole struct {
+	UserID    string `gorm:"primaryKey;size:255"`
+	RoleID    uint   `gorm:"primaryKey"`
+	CreatedAt time.Time
+}
+
+// TableName явно задає ім'я таблиці для GORM
+func (UserRole) TableName() string {
+	return "user_roles"
+}
+
+// RBACService інтерфейс для керування ролями та дозволами
+type RBACService interface {
+	GetUserRoles(userID string) ([]string, error)
+	GetUserPermissions(userID string) ([]string, error)
+	HasPermission(userID string, permissions ...string) (bool, error)
+	AssignRole(userID, roleName string) error
+	RemoveRole(userID, roleName string) error
+	SeedDefaultRoles() error
+}
+
+// rbacService реалізація RBACService
+type rbacService struct {
+	db *gorm.DB
+}
+
+// NewRBACService створює новий RBACService
+func NewRBACService(db *gorm.DB) RBACService {
+	return &rbacService{
+		db: db,
+	}
+}
+
+// GetUserRoles повертає ролі користувача, включно з неявною роллю user
+func (s *rbacService) GetUserRoles(userID string) ([]string, error) {
+	var roles []string
+	err := s.db.Table("user_roles").
+		Select("roles.name").
+		Joins("JOIN roles ON roles.id = user_roles.role_id").
+		Where("user_roles.user_id = ?", userID).
+		Pluck("roles.name", &roles).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user roles: %w", err)
+	}
+
+	if !containsScope(roles, RoleUser) {
+		roles = append(roles, RoleUser)
+	}
+	sort.Strings(roles)
+	return roles, nil
+}
+
+// GetUserPermissions повертає об'єднання дозволів усіх ролей користувача
+func (s *rbacService) GetUserPermissions(userID string) ([]string, error) {
+	var permissions []string
+	err := s.db.Table("permissions").
+		Distinct("permissions.name").
+		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Joins("JOIN roles ON roles.id = role_permissions.role_id").
+		Where("roles.name = ? OR roles.id IN (?)", RoleUser,
+			s.db.Table("user_roles").Select("role_id").Where("user_id = ?", userID)).
+		Pluck("permissions.name", &permissions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user permissions: %w", err)
+	}
+
+	sort.Strings(permissions)
+	return permissions, nil
+}
+
+// HasPermission перевіряє чи має користувач усі вказані дозволи
+func (s *rbacService) HasPermission(userID string, permissions ...string) (bool, error) {
+	granted, err := s.GetUserPermissions(userID)
+	if err != nil {
+		return false, err
+	}
+	return HasScopes(granted, permissions...), nil
+}
+
+// AssignRole призначає роль користувачу
+func (s *rbacService) AssignRole(userID, roleName string) error {
+	role, err := s.getRole(roleName)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&UserRole{
+		UserID:    userID,
+		RoleID:    role.ID,
+		CreatedAt: time.Now(),
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to assign role: %w", err)
+	}
+	s.markPrivilegesChanged(userID)
+
+	logSecurityEvent("role_assigned", logrus.Fields{
+		"user_id": userID,
+		"role":    roleName,
+	})
+	return nil
+}
+
+// RemoveRole забирає роль у користувача
+func (s *rbacService) RemoveRole(userID, roleName string) error {
+	role, err := s.getRole(roleName)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.Where("user_id = ? AND role_id = ?", userID, role.ID).Delete(&UserRole{}).Error; err != nil {
+		return fmt.Errorf("failed to remove role: %w", err)
+	}
+	s.markPrivilegesChanged(userID)
+
+	logSecurityEvent("role_removed", logrus.Fields{
+		"user_id": userID,
+		"role":    roleName,
+	})
+	return nil
+}
+
+// markPrivilegesChanged фіксує зміну ролей, щоб cookie сесії користувача отримали новий ID
+func (s *rbacService) markPrivilegesChanged(userID string) {
+	err := s.db.Model(&User{}).Where("id = ?", userID).Update("privileges_changed_at", time.Now()).Error
+	if err != nil {
+		logrus.WithError(err).WithField("user_id", userID).Error("Failed to mark privileges change")
+	}
+}
+
+// SeedDefaultRoles створює вбудовані ролі та їхні дозволи (ідемпотентно)
+func (s *rbacService) SeedDefaultRoles() error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for roleName, permissionNames := range DefaultRolePermissions {
+			role := Role{Name: roleName}
+			if err := tx.Where(Role{Name: roleName}).FirstOrCreate(&role).Error; err != nil {
+				return fmt.Errorf("failed to seed role %s: %w", roleName, err)
+			}
+
+			permissions := make([]Permission, 0, len(permissionNames))
+			for _, name := range permissionNames {
+				permission := Permission{Name: name}
+				if err := tx.Where(Permission{Name: name}).FirstOrCreate(&permission).Error; err != nil {
+					return fmt.Errorf("failed to seed permission %s: %w", name, err)
+				}
+				permissions = append(permissions, permission)
+			}
+
+			if err := tx.Model(&role).Association("Permissions").Append(permissions); err != nil {
+				return fmt.Errorf("failed to seed permissions for role %s: %w", roleName, err)
+			}
+		}
+
+		logrus.WithField("roles", len(DefaultRolePermissions)).Info("Default roles seeded")
+		return nil
+	})
+}
+
+// getRole знаходить роль за назвою
+func (s *rbacService) getRole(name string) (*Role, error) {
+	var role Role
+	if err := s.db.Where("name = ?", name).First(&role).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrRoleNotFound
+		}
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+	return &role, nil
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RevocationStore зберігає ідентифікатори (sid, jti) відкликаних токенів до закінчення їхнього терміну дії
+type RevocationStore interface {
+	Revoke(id string, expiresAt time.Time) error
+	IsRevoked(id string) (bool, error)
+	CleanupExpired()
+}
+
+// revocationStore реалізація RevocationStore (in-memory)
+type revocationStore struct {
+	revoked map[string]time.Time
+	mutex   sync.RWMutex
+}
+
+// NewRevocationStore створює новий RevocationStore
+func NewRevocationStore() RevocationStore {
+	store := &revocationStore{
+		revoked: make(map[string]time.Time),
+	}
+
+	// Запускаємо горутину для очищення записів, токени яких вже прострочені
+	go store.cleanupRoutine()
+
+	return store
+}
+
+// Revoke відкликає ідентифікатор до expiresAt; після цього токен відхиляється через власний термін дії
+func (s *revocationStore) Revoke(id string, expiresAt time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.revoked[id] = expiresAt
+	return nil
+}
+
+// IsRevoked перевіряє чи ідентифікатор відкликано
+func (s *revocationStore) IsRevoked(id string) (bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	expiresAt, exists := s.revoked[id]
+	return exists && time.Now().Before(expiresAt), nil
+}
+
+// CleanupExpired видаляє записи, термін дії яких минув
+func (s *revocationStore) CleanupExpired() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	cleaned := 0
+	for id, expiresAt := range s.revoked {
+		if now.After(expiresAt) {
+			delete(s.revoked, id)
+			cleaned++
+		}
+	}
+
+	if cleaned > 0 {
+		logrus.WithField("cleaned_count", cleaned).Debug("Cleaned up expired revocations")
+	}
+}
+
+// cleanupRoutine періодично очищає прострочені записи
+func (s *revocationStore) cleanupRoutine() {
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.CleanupExpired()
+	}
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RevokedToken представляє відкликаний ідентифікатор токена або сесії
+type RevokedToken struct {
+	ID        string    `gorm:"primaryKey;size:255"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+}
+
+// TableName явно задає ім'я таблиці для GORM
+func (RevokedToken) TableName() string {
+	return "revoked_tokens"
+}
+
+// pgRevocationStore реалізація RevocationStore (PostgreSQL), спільна для всіх реплік
+type pgRevocationStore struct {
+	db *gorm.DB
+}
+
+// NewPostgresRevocationStore створює RevocationStore, що зберігає відкликання в таблиці revoked_tokens
+func NewPostgresRevocationStore(db *gorm.DB) RevocationStore {
+	store := &pgRevocationStore{
+		db: db,
+	}
+
+	// Запускаємо горутину для очищення записів, токени яких вже прострочені
+	go store.cleanupRoutine()
+
+	return store
+}
+
+// Revoke відкликає ідентифікатор до expiresAt
+func (s *pgRevocationStore) Revoke(id string, expiresAt time.Time) error {
+	err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"expires_at"}),
+	}).Create(&RevokedToken{ID: id, ExpiresAt: expiresAt}).Error
+	if err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return nil
+}
+
+// IsRevoked перевіряє чи ідентифікатор відкликано
+func (s *pgRevocationStore) IsRevoked(id string) (bool, error) {
+	var count int64
+	err := s.db.Model(&RevokedToken{}).
+		Where("id = ? AND expires_at > ?", id, time.Now()).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	return count > 0, nil
+}
+
+// CleanupExpired видаляє записи, термін дії яких минув
+func (s *pgRevocationStore) CleanupExpired() {
+	cleaned, err := deleteExpiredRows(s.db, "revoked_tokens", "id", "expires_at < ?", time.Now())
+	if err != nil {
+		logrus.WithError(err).Error("Failed to clean up expired revocations")
+		return
+	}
+
+	if cleaned > 0 {
+		logrus.WithField("cleaned_count", cleaned).Debug("Cleaned up expired revocations")
+	}
+}
+
+// cleanupRoutine періодично очищає прострочені записи
+func (s *pgRevocationStore) cleanupRoutine() {
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.CleanupExpired()
+	}
+}
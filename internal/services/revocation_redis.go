@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const redisRevokedPrefix = "revoked:"
+
+// redisRevocationStore реалізація RevocationStore (Redis), спільна для всіх реплік
+type redisRevocationStore struct {
+	client *redis.Client
+}
+
+// NewRedisRevocationStore створює RevocationStore, що зберігає відкликання в Redis з TTL до expiresAt
+func NewRedisRevocationStore(client *redis.Client) RevocationStore {
+	return &redisRevocationStore{
+		client: client,
+	}
+}
+
+// Revoke відкликає ідентифікатор до expiresAt
+func (s *redisRevocationStore) Revoke(id string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		// Токени вже прострочені, відкликати нічого
+		return nil
+	}
+
+	if err := s.client.Set(context.Background(), redisRevokedPrefix+id, 1, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return nil
+}
+
+// IsRevoked перевіряє чи ідентифікатор відкликано
+func (s *redisRevocationStore) IsRevoked(id string) (bool, error) {
+	count, err := s.client.Exists(context.Background(), redisRevokedPrefix+id).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	return count > 0, nil
+}
+
+// CleanupExpired нічого не робить: Redis сам видаляє записи за TTL
+func (s *redisRevocationStore) CleanupExpired() {}
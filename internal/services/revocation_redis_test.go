@@ -0,0 +1,45 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRedisRevocationStore(t *testing.T) {
+	server, client := newTestRedis(t)
+	store := NewRedisRevocationStore(client)
+
+	if err := store.Revoke("sess_1", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+
+	revoked, err := store.IsRevoked("sess_1")
+	if err != nil {
+		t.Fatalf("IsRevoked: %v", err)
+	}
+	if !revoked {
+		t.Fatal("revoked session reported as not revoked")
+	}
+
+	if revoked, _ := store.IsRevoked("sess_2"); revoked {
+		t.Fatal("unrelated session reported as revoked")
+	}
+
+	// Відкликання зникає разом із терміном дії токенів
+	server.FastForward(time.Hour + time.Second)
+	if revoked, _ := store.IsRevoked("sess_1"); revoked {
+		t.Fatal("revocation outlived its expiry")
+	}
+}
+
+func TestRedisRevocationStoreSkipsExpired(t *testing.T) {
+	server, client := newTestRedis(t)
+	store := NewRedisRevocationStore(client)
+
+	if err := store.Revoke("sess_1", time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if server.Exists(redisRevokedPrefix + "sess_1") {
+		t.Fatal("already expired revocation was stored")
+	}
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidScope повертається для невідомих або недозволених scopes
+var ErrInvalidScope = errors.New("invalid scope")
+
+// identityScopes стандартні OIDC scopes, які завжди присутні в токенах
+var identityScopes = []string{"openid", "profile", "email"}
+
+// API scopes, якими обмежується доступ токенів до /api/v1
+const (
+	ScopeProfileRead        = "profile:read"
+	ScopeProfileWrite       = "profile:write"
+	ScopeUsersRead          = "users:read"
+	ScopeFriendsRead        = "friends:read"
+	ScopeFriendsWrite       = "friends:write"
+	ScopeNotificationsRead  = "notifications:read"
+	ScopeNotificationsWrite = "notifications:write"
+	ScopeMessagesRead       = "messages:read"
+	ScopeMessagesWrite      = "messages:write"
+	ScopeAccountManage      = "account:manage"
+	ScopeAdmin              = "admin"
+)
+
+// APIScopes містить усі відомі API scopes
+var APIScopes = []string{
+	ScopeProfileRead,
+	ScopeProfileWrite,
+	ScopeUsersRead,
+	ScopeFriendsRead,
+	ScopeFriendsWrite,
+	ScopeNotificationsRead,
+	ScopeNotificationsWrite,
+	ScopeMessagesRead,
+	ScopeMessagesWrite,
+	ScopeAccountManage,
+	ScopeAdmin,
+}
+
+// PersonalAccessTokenScopes містить scopes, які можна видати personal access token.
+// Керування обліковими даними доступне лише з інтерактивної сесії.
+var PersonalAccessTokenScopes = []string{
+	ScopeProfileRead,
+	ScopeProfileWrite,
+	ScopeUsersRead,
+	ScopeFriendsRead,
+	ScopeFriendsWrite,
+	ScopeNotificationsRead,
+	ScopeNotificationsWrite,
+	ScopeMessagesRead,
+	ScopeMessagesWrite,
+}
+
+// ImpersonationScopes містить scopes токенів імперсонації.
+// Адміністратор не може керувати обліковими даними користувача чи виконувати адмін-дії від його імені.
+var ImpersonationScopes = PersonalAccessTokenScopes
+
+// containsScope перевіряє чи є scope у списку
+func containsScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScopes перевіряє чи granted містить усі required scopes
+func HasScopes(granted []string, required ...string) bool {
+	for _, scope := range required {
+		if !containsScope(granted, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseScopes розбирає scope параметр (через пробіл) у список API scopes.
+// Порожній параметр означає всі scopes з allowed; OIDC scopes ігноруються.
+func ParseScopes(scope string, allowed []string) ([]string, error) {
+	requested := strings.Fields(scope)
+	if len(requested) == 0 {
+		return append([]string(nil), allowed...), nil
+	}
+
+	var scopes []string
+	for _, s := range requested {
+		if containsScope(identityScopes, s) {
+			continue
+		}
+		if !containsScope(allowed, s) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidScope, s)
+		}
+		if !containsScope(scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+
+	if len(scopes) == 0 {
+		return append([]string(nil), allowed...), nil
+	}
+	return scopes, nil
+}
@@ -6,25 +6,59 @@ import (
 	"sync"
 	"time"
 
+	"go-practice/internal/models"
+
 	"github.com/sirupsen/logrus"
 )
 
+// pendingSessionTTL скільки живе сесія незавершеного OIDC входу (без користувача)
+const pendingSessionTTL = 10 * time.Minute
+
 // SessionData представляє дані сесії користувача
 type SessionData struct {
-	SessionID string
-	UserID    string
-	CreatedAt time.Time
-	ExpiresAt time.Time
-	IPAddress string
-	UserAgent string
-	State     string // OIDC state parameter
+	SessionID  string
+	UserID     string
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+	LastSeenAt time.Time // останнє використання сесії (вхід, оновлення токенів, запит з cookie)
+	IPAddress  string
+	UserAgent  string
+	State      string   // OIDC state parameter
+	Scopes     []string // API scopes, запитані клієнтом при вході
+
+	// IdleExpiresAt момент завершення сесії без активності (LastSeenAt + idle timeout); нуль — idle timeout вимкнений
+	IdleExpiresAt time.Time
+}
+
+// Deadline повертає момент, коли сесія завершиться, якщо нею більше не користуватись
+func (s *SessionData) Deadline() time.Time {
+	if !s.IdleExpiresAt.IsZero() && s.IdleExpiresAt.Before(s.ExpiresAt) {
+		return s.IdleExpiresAt
+	}
+	return s.ExpiresAt
+}
+
+// IsExpired перевіряє чи минув абсолютний термін дії сесії або idle timeout
+func (s *SessionData) IsExpired(now time.Time) bool {
+	return now.After(s.Deadline())
+}
+
+// idleDeadline повертає момент завершення сесії без активності; нуль, якщо idle timeout вимкнений
+func idleDeadline(lastSeenAt time.Time, idleTimeout time.Duration) time.Time {
+	if idleTimeout <= 0 {
+		return time.Time{}
+	}
+	return lastSeenAt.Add(idleTimeout)
 }
 
 // SessionManager інтерфейс для управління сесіями
 type SessionManager interface {
 	CreateSession(userID, ipAddress, userAgent string) (*SessionData, error)
 	GetSession(sessionID string) (*SessionData, error)
-	UpdateSessionUser(sessionID, userID string) error
+	UpdateSessionUser(sessionID, userID string, client models.ClientInfo) error
+	UpdateSessionScopes(sessionID string, scopes []string) error
+	TouchSession(sessionID, ipAddress string) (*SessionData, error)
+	RotateSession(sessionID string) (*SessionData, error)
 	DeleteSession(sessionID string) error
 	CleanupExpiredSessions()
 	GetUserSessions(userID string) ([]*SessionData, error)
@@ -32,16 +66,19 @@ type SessionManager interface {
 
 // sessionManager реалізація SessionManager (in-memory)
 type sessionManager struct {
-	sessions map[string]*SessionData
-	mutex    sync.RWMutex
-	ttl      time.Duration
+	sessions    map[string]*SessionData
+	mutex       sync.RWMutex
+	ttl         time.Duration
+	idleTimeout time.Duration
 }
 
-// NewSessionManager створює новий Session Manager
-func NewSessionManager(ttl time.Duration) SessionManager {
+// NewSessionManager створює новий Session Manager.
+// ttl — абсолютний термін життя сесії, idleTimeout — час без активності до завершення (0 вимикає).
+func NewSessionManager(ttl, idleTimeout time.Duration) SessionManager {
 	manager := &sessionManager{
-		sessions: make(map[string]*SessionData),
-		ttl:      ttl,
+		sessions:    make(map[string]*SessionData),
+		ttl:         ttl,
+		idleTimeout: idleTimeout,
 	}
 
 	// Запускаємо горутину для очищення застарілих сесій
@@ -59,12 +96,14 @@ func (sm *sessionManager) CreateSession(userID, ipAddress, userAgent string) (*S
 
 	now := time.Now()
 	session := &SessionData{
-		SessionID: sessionID,
-		UserID:    userID,
-		CreatedAt: now,
-		ExpiresAt: now.Add(sm.ttl),
-		IPAddress: ipAddress,
-		UserAgent: userAgent,
+		SessionID:     sessionID,
+		UserID:        userID,
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(sm.ttl),
+		LastSeenAt:    now,
+		IdleExpiresAt: idleDeadline(now, sm.idleTimeout),
+		IPAddress:     ipAddress,
+		UserAgent:     userAgent,
 	}
 
 	sm.mutex.Lock()
@@ -91,8 +130,8 @@ func (sm *sessionManager) GetSession(sessionID string) (*SessionData, error) {
 		return nil, nil // Session not found
 	}
 
-	// Перевіряємо чи не прострочена сесія
-	if time.Now().After(session.ExpiresAt) {
+	// Перевіряємо чи не прострочена сесія (абсолютний термін або idle timeout)
+	if session.IsExpired(time.Now()) {
 		sm.DeleteSession(sessionID)
 		return nil, nil // Session expired
 	}
@@ -100,8 +139,8 @@ func (sm *sessionManager) GetSession(sessionID string) (*SessionData, error) {
 	return session, nil
 }
 
-// UpdateSessionUser оновлює user_id для сесії (після успішної автентифікації)
-func (sm *sessionManager) UpdateSessionUser(sessionID, userID string) error {
+// UpdateSessionUser прив'язує сесію до користувача та його клієнта (після успішної автентифікації)
+func (sm *sessionManager) UpdateSessionUser(sessionID, userID string, client models.ClientInfo) error {
 	sm.mutex.Lock()
 	defer sm.mutex.Unlock()
 
@@ -111,6 +150,10 @@ func (sm *sessionManager) UpdateSessionUser(sessionID, userID string) error {
 	}
 
 	session.UserID = userID
+	session.IPAddress = client.IPAddress
+	session.UserAgent = client.UserAgent
+	session.LastSeenAt = time.Now()
+	session.IdleExpiresAt = idleDeadline(session.LastSeenAt, sm.idleTimeout)
 
 	logrus.WithFields(logrus.Fields{
 		"session_id": sessionID,
@@ -120,6 +163,70 @@ func (sm *sessionManager) UpdateSessionUser(sessionID, userID string) error {
 	return nil
 }
 
+// UpdateSessionScopes зберігає запитані API scopes у сесії
+func (sm *sessionManager) UpdateSessionScopes(sessionID string, scopes []string) error {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	session, exists := sm.sessions[sessionID]
+	if !exists {
+		return nil // Session not found
+	}
+
+	session.Scopes = scopes
+	return nil
+}
+
+// TouchSession фіксує використання сесії з вказаної IP адреси і продовжує idle timeout.
+// nil означає, що сесії немає.
+func (sm *sessionManager) TouchSession(sessionID, ipAddress string) (*SessionData, error) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	session, exists := sm.sessions[sessionID]
+	if !exists {
+		return nil, nil // Session not found
+	}
+
+	session.LastSeenAt = time.Now()
+	session.IdleExpiresAt = idleDeadline(session.LastSeenAt, sm.idleTimeout)
+	if ipAddress != "" {
+		session.IPAddress = ipAddress
+	}
+	return session, nil
+}
+
+// RotateSession переносить сесію під новий ID (захист від фіксації сесії); термін дії не подовжується.
+// nil означає, що сесії немає або вона прострочена.
+func (sm *sessionManager) RotateSession(sessionID string) (*SessionData, error) {
+	newID, err := generateSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	session, exists := sm.sessions[sessionID]
+	if !exists || session.IsExpired(time.Now()) {
+		return nil, nil // Session not found
+	}
+
+	rotated := *session
+	rotated.SessionID = newID
+	rotated.CreatedAt = time.Now()
+	sm.sessions[newID] = &rotated
+	delete(sm.sessions, sessionID)
+
+	logrus.WithFields(logrus.Fields{
+		"session_id":     newID,
+		"old_session_id": sessionID,
+		"user_id":        session.UserID,
+	}).Info("Session rotated")
+
+	return &rotated, nil
+}
+
 // DeleteSession видаляє сесію
 func (sm *sessionManager) DeleteSession(sessionID string) error {
 	sm.mutex.Lock()
@@ -145,7 +252,9 @@ func (sm *sessionManager) CleanupExpiredSessions() {
 	cleaned := 0
 
 	for sessionID, session := range sm.sessions {
-		if now.After(session.ExpiresAt) {
+		// Незавершені OIDC входи не чекають повного TTL сесії
+		abandoned := session.UserID == "" && now.Sub(session.CreatedAt) > pendingSessionTTL
+		if session.IsExpired(now) || abandoned {
 			delete(sm.sessions, sessionID)
 			cleaned++
 		}
@@ -165,7 +274,7 @@ func (sm *sessionManager) GetUserSessions(userID string) ([]*SessionData, error)
 	now := time.Now()
 
 	for _, session := range sm.sessions {
-		if session.UserID == userID && now.Before(session.ExpiresAt) {
+		if session.UserID == userID && !session.IsExpired(now) {
 			userSessions = append(userSessions, session)
 		}
 	}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// ErrInvalidSessionCookie повертається коли підпис cookie сесії не збігається
+var ErrInvalidSessionCookie = errors.New("invalid session cookie")
+
+// SessionCookieCodec підписує ID сесії для cookie та видає CSRF токени, прив'язані до сесії
+type SessionCookieCodec interface {
+	Encode(sessionID string) string
+	Decode(value string) (string, error)
+	CSRFToken(sessionID string) string
+	ValidCSRFToken(sessionID, token string) bool
+}
+
+// sessionCookieCodec реалізація SessionCookieCodec (HMAC-SHA256)
+type sessionCookieCodec struct {
+	cookieKey []byte
+	csrfKey   []byte
+}
+
+// NewSessionCookieCodec створює SessionCookieCodec; окремі ключі для cookie та CSRF виводяться з секрету сесії
+func NewSessionCookieCodec(secret string) SessionCookieCodec {
+	return &sessionCookieCodec{
+		cookieKey: deriveKey(secret, "session-cookie"),
+		csrfKey:   deriveKey(secret, "csrf-token"),
+	}
+}
+
+// Encode повертає значення cookie у форматі <session_id>.<підпис>
+func (c *sessionCookieCodec) Encode(sessionID string) string {
+	return sessionID + "." + hmacSign(c.cookieKey, sessionID)
+}
+
+// Decode перевіряє підпис cookie і повертає ID сесії
+func (c *sessionCookieCodec) Decode(value string) (string, error) {
+	sessionID, signature, found := strings.Cut(value, ".")
+	if !found || sessionID == "" {
+		return "", ErrInvalidSessionCookie
+	}
+	if !hmac.Equal([]byte(signature), []byte(hmacSign(c.cookieKey, sessionID))) {
+		return "", ErrInvalidSessionCookie
+	}
+	return sessionID, nil
+}
+
+// CSRFToken повертає synchronizer token сесії; після ротації сесії попередній токен стає недійсним
+func (c *sessionCookieCodec) CSRFToken(sessionID string) string {
+	return hmacSign(c.csrfKey, sessionID)
+}
+
+// ValidCSRFToken перевіряє CSRF токен запиту за постійний час
+func (c *sessionCookieCodec) ValidCSRFToken(sessionID, token string) bool {
+	return token != "" && hmac.Equal([]byte(token), []byte(c.CSRFToken(sessionID)))
+}
+
+// hmacSign повертає base64url HMAC-SHA256 підпис значення
+func hmacSign(key []byte, value string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// deriveKey виводить ключ для окремого призначення, щоб підпис cookie не можна було використати як CSRF токен
+func deriveKey(secret, purpose string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,305 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"go-practice/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// cleanupBatchSize скільки прострочених рядків видаляється за один запит
+const cleanupBatchSize = 1000
+
+// UserSession представляє сесію в таблиці user_sessions
+type UserSession struct {
+	SessionID      string    `gorm:"primaryKey;size:255"`
+	UserID         string    `gorm:"size:255;index"`
+	IPAddress      string    `gorm:"size:64"`
+	UserAgent      string    `gorm:"type:text"`
+	State          string    `gorm:"size:255"`
+	Scopes         []string  `gorm:"serializer:json;type:text"`
+	ExpiresAt      time.Time `gorm:"not null;index"`
+	CreatedAt      time.Time `gorm:"index"`
+	LastAccessedAt time.Time `gorm:"index"`
+}
+
+// TableName явно задає ім'я таблиці для GORM
+func (UserSession) TableName() string {
+	return "user_sessions"
+}
+
+// toSessionData конвертує рядок таблиці в SessionData
+func (s *UserSession) toSessionData(idleTimeout time.Duration) *SessionData {
+	return &SessionData{
+		SessionID:     s.SessionID,
+		UserID:        s.UserID,
+		CreatedAt:     s.CreatedAt,
+		ExpiresAt:     s.ExpiresAt,
+		LastSeenAt:    s.LastAccessedAt,
+		IdleExpiresAt: idleDeadline(s.LastAccessedAt, idleTimeout),
+		IPAddress:     s.IPAddress,
+		UserAgent:     s.UserAgent,
+		State:         s.State,
+		Scopes:        s.Scopes,
+	}
+}
+
+// pgSessionManager реалізація SessionManager (PostgreSQL), спільна для всіх реплік
+type pgSessionManager struct {
+	db          *gorm.DB
+	ttl         time.Duration
+	idleTimeout time.Duration
+}
+
+// NewPostgresSessionManager створює SessionManager, що зберігає сесії в таблиці user_sessions.
+// ttl — абсолютний термін життя сесії, idleTimeout — час без активності до завершення (0 вимикає).
+func NewPostgresSessionManager(db *gorm.DB, ttl, idleTimeout time.Duration) SessionManager {
+	manager := &pgSessionManager{
+		db:          db,
+		ttl:         ttl,
+		idleTimeout: idleTimeout,
+	}
+
+	// Запускаємо горутину для очищення застарілих сесій
+	go manager.cleanupRoutine()
+
+	return manager
+}
+
+// CreateSession створює нову сесію
+func (sm *pgSessionManager) CreateSession(userID, ipAddress, userAgent string) (*SessionData, error) {
+	sessionID, err := generateSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	record := &UserSession{
+		SessionID:      sessionID,
+		UserID:         userID,
+		IPAddress:      ipAddress,
+		UserAgent:      userAgent,
+		ExpiresAt:      now.Add(sm.ttl),
+		CreatedAt:      now,
+		LastAccessedAt: now,
+	}
+	if err := sm.db.Create(record).Error; err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"session_id": sessionID,
+		"user_id":    userID,
+		"ip_address": ipAddress,
+		"expires_at": record.ExpiresAt,
+	}).Info("Session created")
+
+	return record.toSessionData(sm.idleTimeout), nil
+}
+
+// GetSession отримує сесію за ID; nil означає, що сесії немає або вона прострочена
+func (sm *pgSessionManager) GetSession(sessionID string) (*SessionData, error) {
+	var record UserSession
+	err := sm.active(sm.db).Where("session_id = ?", sessionID).First(&record).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil // Session not found
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return record.toSessionData(sm.idleTimeout), nil
+}
+
+// UpdateSessionUser прив'язує сесію до користувача та його клієнта (після успішної автентифікації)
+func (sm *pgSessionManager) UpdateSessionUser(sessionID, userID string, client models.ClientInfo) error {
+	err := sm.db.Model(&UserSession{}).
+		Where("session_id = ?", sessionID).
+		Updates(map[string]interface{}{
+			"user_id":          userID,
+			"ip_address":       client.IPAddress,
+			"user_agent":       client.UserAgent,
+			"last_accessed_at": time.Now(),
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update session user: %w", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"session_id": sessionID,
+		"user_id":    userID,
+	}).Info("Session updated with user ID")
+
+	return nil
+}
+
+// UpdateSessionScopes зберігає запитані API scopes у сесії
+func (sm *pgSessionManager) UpdateSessionScopes(sessionID string, scopes []string) error {
+	// Оновлення через структуру, щоб GORM застосував JSON серіалізатор
+	err := sm.db.Model(&UserSession{}).
+		Where("session_id = ?", sessionID).
+		Select("scopes").
+		Updates(&UserSession{Scopes: scopes}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update session scopes: %w", err)
+	}
+	return nil
+}
+
+// TouchSession фіксує використання сесії з вказаної IP адреси і продовжує idle timeout.
+// nil означає, що сесії немає.
+func (sm *pgSessionManager) TouchSession(sessionID, ipAddress string) (*SessionData, error) {
+	updates := map[string]interface{}{"last_accessed_at": time.Now()}
+	if ipAddress != "" {
+		updates["ip_address"] = ipAddress
+	}
+
+	var records []UserSession
+	result := sm.db.Model(&records).
+		Clauses(clause.Returning{}).
+		Where("session_id = ?", sessionID).
+		Updates(updates)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to touch session: %w", result.Error)
+	}
+	if len(records) == 0 {
+		return nil, nil // Session not found
+	}
+	return records[0].toSessionData(sm.idleTimeout), nil
+}
+
+// RotateSession переносить сесію під новий ID (захист від фіксації сесії); термін дії не подовжується.
+// nil означає, що сесії немає або вона прострочена.
+func (sm *pgSessionManager) RotateSession(sessionID string) (*SessionData, error) {
+	newID, err := generateSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	var rotated *UserSession
+	err = sm.db.Transaction(func(tx *gorm.DB) error {
+		var records []UserSession
+		err := sm.active(tx).
+			Clauses(clause.Returning{}).
+			Where("session_id = ?", sessionID).
+			Delete(&records).Error
+		if err != nil || len(records) == 0 {
+			return err
+		}
+
+		rotated = &records[0]
+		rotated.SessionID = newID
+		rotated.CreatedAt = time.Now()
+		return tx.Create(rotated).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate session: %w", err)
+	}
+	if rotated == nil {
+		return nil, nil // Session not found
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"session_id":     newID,
+		"old_session_id": sessionID,
+		"user_id":        rotated.UserID,
+	}).Info("Session rotated")
+
+	return rotated.toSessionData(sm.idleTimeout), nil
+}
+
+// DeleteSession видаляє сесію
+func (sm *pgSessionManager) DeleteSession(sessionID string) error {
+	result := sm.db.Where("session_id = ?", sessionID).Delete(&UserSession{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete session: %w", result.Error)
+	}
+
+	if result.RowsAffected > 0 {
+		logrus.WithField("session_id", sessionID).Info("Session deleted")
+	}
+	return nil
+}
+
+// CleanupExpiredSessions видаляє застарілі сесії та незавершені OIDC входи
+func (sm *pgSessionManager) CleanupExpiredSessions() {
+	now := time.Now()
+	condition := "expires_at < ? OR (COALESCE(user_id, '') = '' AND created_at < ?)"
+	args := []interface{}{now, now.Add(-pendingSessionTTL)}
+	if sm.idleTimeout > 0 {
+		condition += " OR last_accessed_at < ?"
+		args = append(args, now.Add(-sm.idleTimeout))
+	}
+
+	cleaned, err := deleteExpiredRows(sm.db, "user_sessions", "session_id", condition, args...)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to clean up expired sessions")
+		return
+	}
+
+	if cleaned > 0 {
+		logrus.WithField("cleaned_count", cleaned).Info("Cleaned up expired sessions")
+	}
+}
+
+// GetUserSessions повертає всі активні сесії користувача
+func (sm *pgSessionManager) GetUserSessions(userID string) ([]*SessionData, error) {
+	var records []UserSession
+	err := sm.active(sm.db).Where("user_id = ?", userID).
+		Order("last_accessed_at DESC").
+		Find(&records).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user sessions: %w", err)
+	}
+
+	sessions := make([]*SessionData, 0, len(records))
+	for i := range records {
+		sessions = append(sessions, records[i].toSessionData(sm.idleTimeout))
+	}
+	return sessions, nil
+}
+
+// active обмежує запит сесіями, у яких не минув ні абсолютний термін, ні idle timeout
+func (sm *pgSessionManager) active(db *gorm.DB) *gorm.DB {
+	now := time.Now()
+	db = db.Where("expires_at > ?", now)
+	if sm.idleTimeout > 0 {
+		db = db.Where("last_accessed_at > ?", now.Add(-sm.idleTimeout))
+	}
+	return db
+}
+
+// cleanupRoutine періодично очищає застарілі сесії
+func (sm *pgSessionManager) cleanupRoutine() {
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		sm.CleanupExpiredSessions()
+	}
+}
+
+// deleteExpiredRows видаляє рядки, що відповідають condition, пакетами.
+// FOR UPDATE SKIP LOCKED дозволяє кільком реплікам чистити таблицю одночасно, не блокуючи одна одну.
+func deleteExpiredRows(db *gorm.DB, table, keyColumn, condition string, args ...interface{}) (int64, error) {
+	query := fmt.Sprintf(
+		"DELETE FROM %[1]s WHERE %[2]s IN (SELECT %[2]s FROM %[1]s WHERE %[3]s LIMIT %[4]d FOR UPDATE SKIP LOCKED)",
+		table, keyColumn, condition, cleanupBatchSize,
+	)
+
+	var total int64
+	for {
+		result := db.Exec(query, args...)
+		if result.Error != nil {
+			return total, result.Error
+		}
+		total += result.RowsAffected
+		if result.RowsAffected < cleanupBatchSize {
+			return total, nil
+		}
+	}
+}
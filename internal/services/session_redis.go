@@ -0,0 +1,332 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-practice/internal/models"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	redisSessionPrefix      = "session:"
+	redisUserSessionsPrefix = "user_sessions:"
+	// redisUpdateRetries скільки разів повторюється оновлення сесії при конкурентному записі
+	redisUpdateRetries = 5
+)
+
+// redisSessionManager реалізація SessionManager (Redis), спільна для всіх реплік.
+// Сесія зберігається як JSON з нативним TTL; множина user_sessions:<id> індексує сесії користувача.
+type redisSessionManager struct {
+	client      *redis.Client
+	ttl         time.Duration
+	idleTimeout time.Duration
+}
+
+// NewRedisSessionManager створює SessionManager, що зберігає сесії в Redis.
+// ttl — абсолютний термін життя сесії, idleTimeout — час без активності до завершення (0 вимикає).
+func NewRedisSessionManager(client *redis.Client, ttl, idleTimeout time.Duration) SessionManager {
+	return &redisSessionManager{
+		client:      client,
+		ttl:         ttl,
+		idleTimeout: idleTimeout,
+	}
+}
+
+// CreateSession створює нову сесію
+func (sm *redisSessionManager) CreateSession(userID, ipAddress, userAgent string) (*SessionData, error) {
+	sessionID, err := generateSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &SessionData{
+		SessionID:  sessionID,
+		UserID:     userID,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(sm.ttl),
+		LastSeenAt: now,
+		IPAddress:  ipAddress,
+		UserAgent:  userAgent,
+	}
+
+	ctx := context.Background()
+	_, err = sm.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		return sm.writeSession(ctx, pipe, session)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"session_id": sessionID,
+		"user_id":    userID,
+		"ip_address": ipAddress,
+		"expires_at": session.ExpiresAt,
+	}).Info("Session created")
+
+	return session, nil
+}
+
+// GetSession отримує сесію за ID; nil означає, що сесії немає або вона прострочена
+func (sm *redisSessionManager) GetSession(sessionID string) (*SessionData, error) {
+	session, err := sm.readSession(context.Background(), sm.client, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	return session, nil
+}
+
+// UpdateSessionUser прив'язує сесію до користувача та його клієнта (після успішної автентифікації)
+func (sm *redisSessionManager) UpdateSessionUser(sessionID, userID string, client models.ClientInfo) error {
+	_, err := sm.updateSession(sessionID, func(session *SessionData) {
+		session.UserID = userID
+		session.IPAddress = client.IPAddress
+		session.UserAgent = client.UserAgent
+		session.LastSeenAt = time.Now()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update session user: %w", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"session_id": sessionID,
+		"user_id":    userID,
+	}).Info("Session updated with user ID")
+
+	return nil
+}
+
+// UpdateSessionScopes зберігає запитані API scopes у сесії
+func (sm *redisSessionManager) UpdateSessionScopes(sessionID string, scopes []string) error {
+	_, err := sm.updateSession(sessionID, func(session *SessionData) {
+		session.Scopes = scopes
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update session scopes: %w", err)
+	}
+	return nil
+}
+
+// TouchSession фіксує використання сесії з вказаної IP адреси і продовжує idle timeout.
+// nil означає, що сесії немає.
+func (sm *redisSessionManager) TouchSession(sessionID, ipAddress string) (*SessionData, error) {
+	session, err := sm.updateSession(sessionID, func(session *SessionData) {
+		session.LastSeenAt = time.Now()
+		if ipAddress != "" {
+			session.IPAddress = ipAddress
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to touch session: %w", err)
+	}
+	return session, nil
+}
+
+// RotateSession переносить сесію під новий ID (захист від фіксації сесії); термін дії не подовжується.
+// nil означає, що сесії немає або вона прострочена.
+func (sm *redisSessionManager) RotateSession(sessionID string) (*SessionData, error) {
+	newID, err := generateSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	key := redisSessionPrefix + sessionID
+
+	var rotated *SessionData
+	err = sm.client.Watch(ctx, func(tx *redis.Tx) error {
+		session, err := sm.readSession(ctx, tx, sessionID)
+		if err != nil || session == nil {
+			return err
+		}
+
+		rotated = session
+		rotated.SessionID = newID
+		rotated.CreatedAt = time.Now()
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, key)
+			if rotated.UserID != "" {
+				pipe.SRem(ctx, redisUserSessionsPrefix+rotated.UserID, sessionID)
+			}
+			return sm.writeSession(ctx, pipe, rotated)
+		})
+		return err
+	}, key)
+	if err != nil {
+		// Конкурентна зміна (TxFailedErr) означає, що сесію вже змінив інший запит; повторювати не варто
+		return nil, fmt.Errorf("failed to rotate session: %w", err)
+	}
+	if rotated == nil {
+		return nil, nil // Session not found
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"session_id":     newID,
+		"old_session_id": sessionID,
+		"user_id":        rotated.UserID,
+	}).Info("Session rotated")
+
+	return rotated, nil
+}
+
+// DeleteSession видаляє сесію
+func (sm *redisSessionManager) DeleteSession(sessionID string) error {
+	ctx := context.Background()
+	session, err := sm.readSession(ctx, sm.client, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+
+	_, err = sm.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, redisSessionPrefix+sessionID)
+		if session != nil && session.UserID != "" {
+			pipe.SRem(ctx, redisUserSessionsPrefix+session.UserID, sessionID)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+
+	if session != nil {
+		logrus.WithField("session_id", sessionID).Info("Session deleted")
+	}
+	return nil
+}
+
+// CleanupExpiredSessions нічого не робить: Redis сам видаляє сесії за TTL,
+// а застарілі записи індексу користувача прибираються в GetUserSessions
+func (sm *redisSessionManager) CleanupExpiredSessions() {}
+
+// GetUserSessions повертає всі активні сесії користувача
+func (sm *redisSessionManager) GetUserSessions(userID string) ([]*SessionData, error) {
+	ctx := context.Background()
+	indexKey := redisUserSessionsPrefix + userID
+
+	ids, err := sm.client.SMembers(ctx, indexKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user sessions: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = redisSessionPrefix + id
+	}
+	values, err := sm.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user sessions: %w", err)
+	}
+
+	var sessions []*SessionData
+	var stale []interface{}
+	for i, value := range values {
+		raw, ok := value.(string)
+		if !ok {
+			stale = append(stale, ids[i])
+			continue
+		}
+
+		var session SessionData
+		if err := json.Unmarshal([]byte(raw), &session); err != nil {
+			return nil, fmt.Errorf("failed to decode session: %w", err)
+		}
+		if session.UserID != userID {
+			stale = append(stale, ids[i])
+			continue
+		}
+		sessions = append(sessions, &session)
+	}
+
+	// Сесії, що вже зникли за TTL, видаляємо з індексу
+	if len(stale) > 0 {
+		if err := sm.client.SRem(ctx, indexKey, stale...).Err(); err != nil {
+			logrus.WithError(err).Warn("Failed to prune user session index")
+		}
+	}
+
+	return sessions, nil
+}
+
+// updateSession атомарно змінює сесію через WATCH/MULTI і повертає оновлену; відсутня сесія (nil) не є помилкою
+func (sm *redisSessionManager) updateSession(sessionID string, update func(session *SessionData)) (*SessionData, error) {
+	ctx := context.Background()
+	key := redisSessionPrefix + sessionID
+
+	for i := 0; i < redisUpdateRetries; i++ {
+		var session *SessionData
+		err := sm.client.Watch(ctx, func(tx *redis.Tx) error {
+			var err error
+			session, err = sm.readSession(ctx, tx, sessionID)
+			if err != nil || session == nil {
+				return err
+			}
+
+			update(session)
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				return sm.writeSession(ctx, pipe, session)
+			})
+			return err
+		}, key)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return session, err
+		}
+	}
+
+	return nil, fmt.Errorf("session %s was modified concurrently", sessionID)
+}
+
+// readSession читає сесію з Redis; nil означає, що ключа немає (TTL минув або сесію видалено)
+func (sm *redisSessionManager) readSession(ctx context.Context, cmd redis.Cmdable, sessionID string) (*SessionData, error) {
+	raw, err := cmd.Get(ctx, redisSessionPrefix+sessionID).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var session SessionData
+	if err := json.Unmarshal(raw, &session); err != nil {
+		return nil, fmt.Errorf("failed to decode session: %w", err)
+	}
+	return &session, nil
+}
+
+// writeSession записує сесію з TTL до її Deadline і додає її в індекс користувача.
+// Сесія незавершеного OIDC входу (без користувача) живе лише pendingSessionTTL.
+func (sm *redisSessionManager) writeSession(ctx context.Context, pipe redis.Pipeliner, session *SessionData) error {
+	session.IdleExpiresAt = idleDeadline(session.LastSeenAt, sm.idleTimeout)
+	raw, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+
+	expiresAt := session.Deadline()
+	if session.UserID == "" {
+		if pending := session.CreatedAt.Add(pendingSessionTTL); pending.Before(expiresAt) {
+			expiresAt = pending
+		}
+	}
+
+	key := redisSessionPrefix + session.SessionID
+	pipe.Set(ctx, key, raw, 0)
+	pipe.ExpireAt(ctx, key, expiresAt)
+
+	if session.UserID != "" {
+		indexKey := redisUserSessionsPrefix + session.UserID
+		pipe.SAdd(ctx, indexKey, session.SessionID)
+		// Індекс живе не менше за найновішу сесію користувача
+		pipe.Expire(ctx, indexKey, sm.ttl)
+	}
+	return nil
+}
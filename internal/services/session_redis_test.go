@@ -0,0 +1,155 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestRedis запускає in-process Redis і повертає клієнт до нього
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return server, client
+}
+
+func TestRedisSessionManagerTTL(t *testing.T) {
+	server, client := newTestRedis(t)
+	sm := NewRedisSessionManager(client, time.Hour, 0)
+
+	session, err := sm.CreateSession("usr_1", "10.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+
+	ttl := server.TTL(redisSessionPrefix + session.SessionID)
+	if ttl <= 59*time.Minute || ttl > time.Hour {
+		t.Fatalf("session TTL = %v, want about 1h", ttl)
+	}
+
+	server.FastForward(time.Hour + time.Second)
+	got, err := sm.GetSession(session.SessionID)
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if got != nil {
+		t.Fatalf("GetSession after TTL = %+v, want nil", got)
+	}
+}
+
+func TestRedisSessionManagerIdleTimeout(t *testing.T) {
+	server, client := newTestRedis(t)
+	sm := NewRedisSessionManager(client, time.Hour, 10*time.Minute)
+
+	session, err := sm.CreateSession("usr_1", "10.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+
+	ttl := server.TTL(redisSessionPrefix + session.SessionID)
+	if ttl <= 9*time.Minute || ttl > 10*time.Minute {
+		t.Fatalf("session TTL = %v, want idle timeout of 10m", ttl)
+	}
+
+	server.FastForward(11 * time.Minute)
+	got, err := sm.GetSession(session.SessionID)
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if got != nil {
+		t.Fatal("session survived idle timeout")
+	}
+}
+
+func TestRedisSessionManagerPendingSessionTTL(t *testing.T) {
+	server, client := newTestRedis(t)
+	sm := NewRedisSessionManager(client, time.Hour, 0)
+
+	session, err := sm.CreateSession("", "10.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+
+	if ttl := server.TTL(redisSessionPrefix + session.SessionID); ttl > pendingSessionTTL {
+		t.Fatalf("pending session TTL = %v, want at most %v", ttl, pendingSessionTTL)
+	}
+}
+
+func TestRedisSessionManagerPrunesUserIndex(t *testing.T) {
+	server, client := newTestRedis(t)
+	sm := NewRedisSessionManager(client, time.Hour, 0)
+
+	first, err := sm.CreateSession("usr_1", "10.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	second, err := sm.CreateSession("usr_1", "10.0.0.2", "test")
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+
+	// Ключ сесії зникає сам (як за TTL), індекс користувача лишається
+	server.Del(redisSessionPrefix + first.SessionID)
+
+	sessions, err := sm.GetUserSessions("usr_1")
+	if err != nil {
+		t.Fatalf("GetUserSessions: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].SessionID != second.SessionID {
+		t.Fatalf("GetUserSessions = %v, want only %s", sessions, second.SessionID)
+	}
+
+	members, err := server.Members(redisUserSessionsPrefix + "usr_1")
+	if err != nil {
+		t.Fatalf("Members: %v", err)
+	}
+	if len(members) != 1 || members[0] != second.SessionID {
+		t.Fatalf("user session index = %v, want [%s]", members, second.SessionID)
+	}
+}
+
+func TestRedisSessionManagerRotate(t *testing.T) {
+	server, client := newTestRedis(t)
+	sm := NewRedisSessionManager(client, time.Hour, 0)
+
+	session, err := sm.CreateSession("usr_1", "10.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+
+	rotated, err := sm.RotateSession(session.SessionID)
+	if err != nil {
+		t.Fatalf("RotateSession: %v", err)
+	}
+	if rotated == nil || rotated.SessionID == session.SessionID {
+		t.Fatalf("RotateSession = %+v, want session with new ID", rotated)
+	}
+	if !rotated.ExpiresAt.Equal(session.ExpiresAt) {
+		t.Fatalf("rotated ExpiresAt = %v, want unchanged %v", rotated.ExpiresAt, session.ExpiresAt)
+	}
+
+	if old, _ := sm.GetSession(session.SessionID); old != nil {
+		t.Fatal("old session ID still resolves after rotation")
+	}
+	if got, _ := sm.GetSession(rotated.SessionID); got == nil || got.UserID != "usr_1" {
+		t.Fatalf("GetSession(rotated) = %+v, want session of usr_1", got)
+	}
+
+	members, err := server.Members(redisUserSessionsPrefix + "usr_1")
+	if err != nil {
+		t.Fatalf("Members: %v", err)
+	}
+	if len(members) != 1 || members[0] != rotated.SessionID {
+		t.Fatalf("user session index = %v, want [%s]", members, rotated.SessionID)
+	}
+
+	missing, err := sm.RotateSession("unknown")
+	if err != nil || missing != nil {
+		t.Fatalf("RotateSession(unknown) = %v, %v, want nil, nil", missing, err)
+	}
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OIDCState представляє одноразовий state параметр незавершеного OIDC входу
+type OIDCState struct {
+	State     string    `gorm:"primaryKey;size:64"`
+	SessionID string    `gorm:"size:255;not null"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	CreatedAt time.Time
+}
+
+// TableName явно задає ім'я таблиці для GORM
+func (OIDCState) TableName() string {
+	return "oidc_states"
+}
+
+// pgStateService реалізація StateService (PostgreSQL), спільна для всіх реплік
+type pgStateService struct {
+	db  *gorm.DB
+	ttl time.Duration
+}
+
+// NewPostgresStateService створює StateService, що зберігає state в таблиці oidc_states
+func NewPostgresStateService(db *gorm.DB, ttl time.Duration) StateService {
+	service := &pgStateService{
+		db:  db,
+		ttl: ttl,
+	}
+
+	// Запускаємо горутину для очищення застарілих state
+	go service.cleanupRoutine()
+
+	return service
+}
+
+// GenerateState генерує новий state параметр для CSRF захисту
+func (s *pgStateService) GenerateState(sessionID string) (string, error) {
+	// Генеруємо криптографічно стійкий випадковий state
+	randomBytes := make([]byte, 32)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", fmt.Errorf("failed to generate random state: %w", err)
+	}
+
+	state := hex.EncodeToString(randomBytes)
+	now := time.Now()
+	entry := &OIDCState{
+		State:     state,
+		SessionID: sessionID,
+		ExpiresAt: now.Add(s.ttl),
+		CreatedAt: now,
+	}
+	if err := s.db.Create(entry).Error; err != nil {
+		return "", fmt.Errorf("failed to store state: %w", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"state":      state[:10] + "...",
+		"session_id": sessionID,
+		"expires_at": entry.ExpiresAt,
+	}).Debug("Generated new state parameter")
+
+	return state, nil
+}
+
+// ValidateState валідує state параметр і повертає session_id.
+// DELETE ... RETURNING гарантує одноразове використання навіть при паралельних запитах на різні репліки.
+func (s *pgStateService) ValidateState(state string) (string, error) {
+	var entries []OIDCState
+	err := s.db.Clauses(clause.Returning{}).
+		Where("state = ?", state).
+		Delete(&entries).Error
+	if err != nil {
+		return "", fmt.Errorf("failed to validate state: %w", err)
+	}
+	if len(entries) == 0 {
+		return "", fmt.Errorf("invalid state parameter")
+	}
+
+	entry := entries[0]
+	if time.Now().After(entry.ExpiresAt) {
+		return "", fmt.Errorf("state parameter expired")
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"state":      state[:10] + "...",
+		"session_id": entry.SessionID,
+	}).Debug("State parameter validated successfully")
+
+	return entry.SessionID, nil
+}
+
+// CleanupExpiredStates видаляє застарілі state параметри
+func (s *pgStateService) CleanupExpiredStates() {
+	cleaned, err := deleteExpiredRows(s.db, "oidc_states", "state", "expires_at < ?", time.Now())
+	if err != nil {
+		logrus.WithError(err).Error("Failed to clean up expired state parameters")
+		return
+	}
+
+	if cleaned > 0 {
+		logrus.WithField("cleaned_count", cleaned).Debug("Cleaned up expired state parameters")
+	}
+}
+
+// cleanupRoutine періодично очищує застарілі state параметри
+func (s *pgStateService) cleanupRoutine() {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.CleanupExpiredStates()
+	}
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+const redisStatePrefix = "oidc_state:"
+
+// redisStateService реалізація StateService (Redis), спільна для всіх реплік
+type redisStateService struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewRedisStateService створює StateService, що зберігає state в Redis з нативним TTL
+func NewRedisStateService(client *redis.Client, ttl time.Duration) StateService {
+	return &redisStateService{
+		client: client,
+		ttl:    ttl,
+	}
+}
+
+// GenerateState генерує новий state параметр для CSRF захисту
+func (s *redisStateService) GenerateState(sessionID string) (string, error) {
+	// Генеруємо криптографічно стійкий випадковий state
+	randomBytes := make([]byte, 32)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", fmt.Errorf("failed to generate random state: %w", err)
+	}
+
+	state := hex.EncodeToString(randomBytes)
+	if err := s.client.Set(context.Background(), redisStatePrefix+state, sessionID, s.ttl).Err(); err != nil {
+		return "", fmt.Errorf("failed to store state: %w", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"state":      state[:10] + "...",
+		"session_id": sessionID,
+		"expires_in": s.ttl,
+	}).Debug("Generated new state parameter")
+
+	return state, nil
+}
+
+// ValidateState валідує state параметр і повертає session_id.
+// GETDEL гарантує одноразове використання навіть при паралельних запитах на різні репліки.
+func (s *redisStateService) ValidateState(state string) (string, error) {
+	sessionID, err := s.client.GetDel(context.Background(), redisStatePrefix+state).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", fmt.Errorf("invalid state parameter")
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to validate state: %w", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"state":      state[:10] + "...",
+		"session_id": sessionID,
+	}).Debug("State parameter validated successfully")
+
+	return sessionID, nil
+}
+
+// CleanupExpiredStates нічого не робить: Redis сам видаляє state за TTL
+func (s *redisStateService) CleanupExpiredStates() {}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRedisStateServiceSingleUse(t *testing.T) {
+	_, client := newTestRedis(t)
+	states := NewRedisStateService(client, 10*time.Minute)
+
+	state, err := states.GenerateState("sess_1")
+	if err != nil {
+		t.Fatalf("GenerateState: %v", err)
+	}
+
+	sessionID, err := states.ValidateState(state)
+	if err != nil {
+		t.Fatalf("ValidateState: %v", err)
+	}
+	if sessionID != "sess_1" {
+		t.Fatalf("ValidateState = %q, want sess_1", sessionID)
+	}
+
+	if _, err := states.ValidateState(state); err == nil {
+		t.Fatal("state accepted twice")
+	}
+}
+
+func TestRedisStateServiceExpires(t *testing.T) {
+	server, client := newTestRedis(t)
+	states := NewRedisStateService(client, 10*time.Minute)
+
+	state, err := states.GenerateState("sess_1")
+	if err != nil {
+		t.Fatalf("GenerateState: %v", err)
+	}
+
+	server.FastForward(11 * time.Minute)
+	if _, err := states.ValidateState(state); err == nil {
+		t.Fatal("expired state accepted")
+	}
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"sync"
+)
+
+// hub розсилає події потокам користувачів, відкритим у цій репліці.
+// Спільна основа NotificationHub, MessageHub та PresenceHub.
+type hub[T any] struct {
+	subscribers map[string]map[chan T]struct{}
+	buffer      int
+	mutex       sync.RWMutex
+}
+
+// newHub створює hub; buffer — скільки подій чекає на повільного підписника, перш ніж нові відкидаються
+func newHub[T any](buffer int) *hub[T] {
+	return &hub[T]{
+		subscribers: make(map[string]map[chan T]struct{}),
+		buffer:      buffer,
+	}
+}
+
+// Subscribe відкриває потік подій користувача; другий результат закриває потік
+func (h *hub[T]) Subscribe(userID string) (<-chan T, func()) {
+	ch := make(chan T, h.buffer)
+
+	h.mutex.Lock()
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[chan T]struct{})
+	}
+	h.subscribers[userID][ch] = struct{}{}
+	h.mutex.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			h.mutex.Lock()
+			defer h.mutex.Unlock()
+
+			delete(h.subscribers[userID], ch)
+			if len(h.subscribers[userID]) == 0 {
+				delete(h.subscribers, userID)
+			}
+			close(ch)
+		})
+	}
+	return ch, unsubscribe
+}
+
+// hasAnySubscriber перевіряє чи хтось з користувачів має відкриті потоки в цій репліці
+func (h *hub[T]) hasAnySubscriber(userIDs ...string) bool {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	for _, userID := range userIDs {
+		if len(h.subscribers[userID]) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// subscriberIDs повертає користувачів з відкритими потоками в цій репліці
+func (h *hub[T]) subscriberIDs() []string {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	ids := make([]string, 0, len(h.subscribers))
+	for userID := range h.subscribers {
+		ids = append(ids, userID)
+	}
+	return ids
+}
+
+// publish надсилає подію всім потокам користувачів без блокування.
+// Повільний підписник пропускає подію, про що повідомляється в dropped.
+func (h *hub[T]) publish(userIDs []string, event T, dropped func(userID string)) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	for _, userID := range userIDs {
+		for ch := range h.subscribers[userID] {
+			select {
+			case ch <- event:
+			default:
+				dropped(userID)
+			}
+		}
+	}
+}
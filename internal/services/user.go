@@ -4,25 +4,36 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
-	"strings"
 	"time"
 
 	"go-practice/internal/models"
 
 	"github.com/sirupsen/logrus"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
 // userService реалізація UserService
 type userService struct {
-	db *gorm.DB
+	db             *gorm.DB
+	passwordPolicy PasswordPolicy
+	passwordHasher PasswordHasher
+	// dummyHash перевіряється замість хешу неіснуючого користувача чи акаунта без пароля,
+	// щоб час відповіді не видавав, чи зареєстровано email
+	dummyHash string
 }
 
 // NewUserService створює новий UserService
-func NewUserService(db *gorm.DB) UserService {
+func NewUserService(db *gorm.DB, passwordPolicy PasswordPolicy, passwordHasher PasswordHasher) UserService {
+	dummyHash, err := passwordHasher.Hash("dummy-password")
+	if err != nil {
+		logrus.WithError(err).Error("Failed to create dummy password hash")
+	}
+
 	return &userService{
-		db: db,
+		db:             db,
+		passwordPolicy: passwordPolicy,
+		passwordHasher: passwordHasher,
+		dummyHash:      dummyHash,
 	}
 }
 
@@ -46,8 +57,13 @@ func (s *userService) RegisterUser(req models.RegisterRequest) (*models.Register
 		return nil, fmt.Errorf("failed to check existing user: %w", err)
 	}
 
+	// Перевіряємо пароль на відповідність політиці
+	if err := s.passwordPolicy.Validate(req.Password, req.Email, req.Name); err != nil {
+		return nil, err
+	}
+
 	// Хешуємо пароль
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashedPassword, err := s.passwordHasher.Hash(req.Password)
 	if err != nil {
 		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
@@ -63,7 +79,7 @@ func (s *userService) RegisterUser(req models.RegisterRequest) (*models.Register
 		ID:           userID,
 		Email:        req.Email,
 		Name:         req.Name,
-		PasswordHash: string(hashedPassword),
+		PasswordHash: hashedPassword,
 		IsActive:     true,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
@@ -85,10 +101,10 @@ func (s *userService) RegisterUser(req models.RegisterRequest) (*models.Register
 	return response, nil
 }
 
-// SearchUsers
-func (s *userService) SearchUsers(query string) ([]User, error) {
+// SearchUsers шукає користувачів за ім'ям або email; заблоковані viewerID користувачі не повертаються
+func (s *userService) SearchUsers(viewerID, query string) ([]User, error) {
 	var users []User
-	if err := s.db.Where(
+	if err := s.db.Scopes(notBlockedBy(viewerID)).Where(
 		"is_active = ? AND (LOWER(name) LIKE LOWER(?) OR LOWER(email) LIKE LOWER(?))",
 		true, "%"+query+"%", "%"+query+"%",
 	).Find(&users).Error; err != nil {
@@ -123,21 +139,105 @@ func (s *userService) GetUserByID(id string) (*User, error) {
 	return &user, nil
 }
 
+// GetVisibleUser отримує користувача за ID так, як його бачить viewerID: заблокованих viewerID користувачів не знаходить
+func (s *userService) GetVisibleUser(viewerID, id string) (*User, error) {
+	var user User
+	err := s.db.Scopes(notBlockedBy(viewerID)).Where("id = ? AND is_active = ?", id, true).First(&user).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return &user, nil
+}
+
+// notBlockedBy виключає з вибірки users користувачів, заблокованих viewerID
+func notBlockedBy(viewerID string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("NOT EXISTS (SELECT 1 FROM user_blocks b WHERE b.blocker_id = ? AND b.blocked_id = users.id)", viewerID)
+	}
+}
+
 // ValidatePassword перевіряє пароль користувача
 func (s *userService) ValidatePassword(email, password string) (*User, error) {
 	user, err := s.GetUserByEmail(email)
 	if err != nil {
+		_, _ = s.passwordHasher.Verify(password, s.dummyHash)
 		return nil, err
 	}
+	if user.PasswordHash == "" {
+		_, _ = s.passwordHasher.Verify(password, s.dummyHash)
+		return nil, fmt.Errorf("invalid password")
+	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
+	valid, err := s.passwordHasher.Verify(password, user.PasswordHash)
 	if err != nil {
+		logrus.WithError(err).WithField("user_id", user.ID).Error("Failed to verify password hash")
+	}
+	if !valid {
 		return nil, fmt.Errorf("invalid password")
 	}
 
+	// Прозоро оновлюємо хеш до актуального алгоритму та параметрів
+	if s.passwordHasher.NeedsRehash(user.PasswordHash) {
+		if err := s.rehashPassword(user, password); err != nil {
+			logrus.WithError(err).WithField("user_id", user.ID).Warn("Failed to upgrade password hash")
+		}
+	}
+
 	return user, nil
 }
 
+// rehashPassword перезаписує хеш пароля без зміни updated_at
+func (s *userService) rehashPassword(user *User, password string) error {
+	hashedPassword, err := s.passwordHasher.Hash(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	err = s.db.Model(&User{}).
+		Where("id = ? AND password_hash = ?", user.ID, user.PasswordHash).
+		UpdateColumn("password_hash", hashedPassword).Error
+	if err != nil {
+		return fmt.Errorf("failed to store upgraded password hash: %w", err)
+	}
+
+	user.PasswordHash = hashedPassword
+	logrus.WithField("user_id", user.ID).Info("Password hash upgraded")
+	return nil
+}
+
+// SetPassword встановлює новий пароль користувача після перевірки парольної політики
+func (s *userService) SetPassword(userID, password string) error {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.HashNewPassword(user, password)
+	if err != nil {
+		return err
+	}
+
+	return s.UpdateUser(userID, map[string]interface{}{
+		"password_hash": hashedPassword,
+	})
+}
+
+// HashNewPassword перевіряє новий пароль користувача парольною політикою і повертає його хеш без збереження
+func (s *userService) HashNewPassword(user *User, password string) (string, error) {
+	if err := s.passwordPolicy.Validate(password, user.Email, user.Name); err != nil {
+		return "", err
+	}
+
+	hashedPassword, err := s.passwordHasher.Hash(password)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return hashedPassword, nil
+}
+
 // GetIDByUserID отримує ID користувача за його userID
 func (s *userService) GetIDByUserID(userID string) (string, error) {
 	var user User
@@ -166,66 +266,6 @@ func (s *userService) AreFriends(userID, friendID string) (bool, error) {
 	return exists, nil
 }
 
-// AddFriend додає користувача в друзі
-func (s *userService) AddFriend(userID, friendID string) error {
-	logrus.WithFields(logrus.Fields{
-		"userID":   userID,
-		"friendID": friendID,
-	}).Info("AddFriend called")
-
-	type Friendship struct {
-		UserID    string `gorm:"type:text;not null;index"`
-		FriendID  string `gorm:"type:text;not null;index"`
-		CreatedAt time.Time
-		UpdatedAt time.Time
-	}
-
-	friendship := Friendship{
-		UserID:    userID,
-		FriendID:  friendID,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	}
-
-	// Спочатку перевіряємо чи вже існує такий зв'язок
-	var count int64
-	err := s.db.Model(&Friendship{}).Where("user_id = ? AND friend_id = ?", friendship.UserID, friendship.FriendID).Count(&count).Error
-	if err != nil {
-		logrus.WithError(err).Error("Failed to check existing friendship")
-		return err
-	}
-
-	logrus.WithFields(logrus.Fields{
-		"userID":   userID,
-		"friendID": friendID,
-		"count":    count,
-	}).Info("Checked existing friendship")
-
-	// Якщо вже існує - нічого не робимо
-	if count > 0 {
-		logrus.Info("Friendship already exists")
-		return nil
-	}
-
-	// Інакше додаємо новий зв'язок
-	err = s.db.Exec(`
-		INSERT INTO friendships (user_id, friend_id, created_at, updated_at)
-		VALUES (?, ?, ?, ?)
-	`, friendship.UserID, friendship.FriendID, friendship.CreatedAt, friendship.UpdatedAt).Error
-
-	if err != nil {
-		logrus.WithError(err).Error("Failed to insert friendship")
-		return err
-	}
-
-	logrus.WithFields(logrus.Fields{
-		"userID":   userID,
-		"friendID": friendID,
-	}).Info("Friendship added successfully")
-
-	return err
-}
-
 // UpdateUser оновлює дані користувача
 func (s *userService) UpdateUser(userID string, updates map[string]interface{}) error {
 	updates["updated_at"] = time.Now()
@@ -259,16 +299,25 @@ func (s *userService) GetProfile(userID string) (*models.UserProfile, error) {
 		return nil, err
 	}
 
+	followers, following, err := s.GetFollowCounts(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
 	return &models.UserProfile{
-		ID:      user.ID,
-		Email:   user.Email,
-		Name:    user.Name,
-		Picture: user.Picture,
+		ID:                     user.ID,
+		Email:                  user.Email,
+		Name:                   user.Name,
+		Picture:                user.Picture,
+		FollowersCount:         followers,
+		FollowingCount:         following,
+		FollowApprovalRequired: user.FollowApprovalRequired,
+		HidePresence:           user.HidePresence,
 	}, nil
 }
 
 // CreateOrUpdateFromOIDC створює нового користувача або оновлює існуючого на основі даних від OIDC провайдера
-func (s *userService) CreateOrUpdateFromOIDC(sub, email, name, picture string) (*User, error) {
+func (s *userService) CreateOrUpdateFromOIDC(sub, email, name, picture string, emailVerified bool) (*User, error) {
 	logrus.WithFields(logrus.Fields{
 		"sub":   sub,
 		"email": email,
@@ -278,10 +327,22 @@ func (s *userService) CreateOrUpdateFromOIDC(sub, email, name, picture string) (
 	// Спробуємо знайти користувача за email
 	existingUser, err := s.GetUserByEmail(email)
 	if err == nil {
-		// Користувач існує, оновлюємо дані
+		// Не підтверджений провайдером email не доводить володіння адресою — до існуючого акаунта не прив'язуємо
+		if !emailVerified {
+			return nil, ErrProviderEmailNotVerified
+		}
+
+		// Користувач існує, оновлюємо дані; провайдер підтвердив email — знімаємо вимогу верифікації
 		updates := map[string]interface{}{
-			"name":    name,
-			"picture": picture,
+			"name":              name,
+			"picture":           picture,
+			"is_email_verified": true,
+		}
+
+		// Пароль акаунта з непідтвердженим email міг встановити не власник адреси — скидаємо його
+		if !existingUser.IsEmailVerified && existingUser.PasswordHash != "" {
+			updates["password_hash"] = ""
+			logrus.WithField("user_id", existingUser.ID).Warn("Local password cleared: email verified via OIDC provider")
 		}
 
 		if err := s.UpdateUser(existingUser.ID, updates); err != nil {
@@ -299,14 +360,15 @@ func (s *userService) CreateOrUpdateFromOIDC(sub, email, name, picture string) (
 	}
 
 	newUser := User{
-		ID:           userID,
-		Email:        email,
-		Name:         name,
-		Picture:      picture,
-		PasswordHash: "", // Для OIDC користувачів пароль не потрібен
-		IsActive:     true,
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
+		ID:              userID,
+		Email:           email,
+		Name:            name,
+		Picture:         picture,
+		PasswordHash:    "", // Для OIDC користувачів пароль не потрібен
+		IsActive:        true,
+		IsEmailVerified: emailVerified,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
 	}
 
 	if err := s.db.Create(&newUser).Error; err != nil {
@@ -328,50 +390,14 @@ func generateUserID() (string, error) {
 
 // GetFriends повертає список друзів користувача
 func (s *userService) GetFriends(userID string) ([]User, error) {
-	logrus.WithField("userID", userID).Info("GetFriends called")
-
-	var friendIDs []string
-	err := s.db.Table("friendships").
-		Select("friend_id").
-		Where("user_id = ?", userID).
-		Scan(&friendIDs).Error
-	if err != nil {
-		logrus.WithError(err).Error("Failed to get friend ids from database")
-		return nil, fmt.Errorf("failed to get friend ids: %w", err)
-	}
-
-	logrus.WithFields(logrus.Fields{
-		"userID":    userID,
-		"friendIDs": friendIDs,
-		"count":     len(friendIDs),
-	}).Info("Found friend IDs")
-
-	if len(friendIDs) == 0 {
-		return []User{}, nil
-	}
-
-	// Перевіряємо чи потрібно додавати префікс
-	var processedFriendIDs []string
-	for _, id := range friendIDs {
-		id = strings.TrimSpace(id)
-		// Якщо ID не має префікса usr_, додаємо його
-		if !strings.HasPrefix(id, "usr_") {
-			id = "usr_" + id
-		}
-		processedFriendIDs = append(processedFriendIDs, id)
-	}
-
-	logrus.WithField("processedFriendIDs", processedFriendIDs).Info("Processed friend IDs")
-
 	var friends []User
-	err = s.db.
-		Where("id IN ? AND is_active = ?", processedFriendIDs, true).
+	err := s.db.
+		Where("id IN (?) AND is_active = ?", s.db.Table("friendships").Select("friend_id").Where("user_id = ?", userID), true).
+		Order("name").
 		Find(&friends).Error
 	if err != nil {
-		logrus.WithError(err).Error("Failed to get friends from database")
+		logrus.WithError(err).WithField("user_id", userID).Error("Failed to get friends from database")
 		return nil, fmt.Errorf("failed to get friends: %w", err)
 	}
-
-	logrus.WithField("friends_count", len(friends)).Info("Friends found in database")
 	return friends, nil
 }
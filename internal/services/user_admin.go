@@ -0,0 +1,359 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrUserNotFound повертається коли користувача з таким ID не існує
+	ErrUserNotFound = errors.New("user not found")
+	// ErrFieldNotEditable повертається при спробі змінити поле, недоступне для редагування
+	ErrFieldNotEditable = errors.New("field is not editable")
+	// ErrCannotModifySelf повертається коли адміністратор намагається деактивувати чи видалити себе
+	ErrCannotModifySelf = errors.New("administrators cannot deactivate or delete their own account")
+)
+
+// adminEditableUserFields поля користувача, які може змінювати адміністратор
+var adminEditableUserFields = map[string]bool{
+	"name":              true,
+	"email":             true,
+	"picture":           true,
+	"is_email_verified": true,
+}
+
+// Межі пагінації списку користувачів
+const (
+	defaultUserListLimit = 50
+	maxUserListLimit     = 200
+)
+
+// UserListFilter містить фільтри списку користувачів для адміністратора
+type UserListFilter struct {
+	IsActive      *bool
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	EmailDomain   string
+	Limit         int
+	Offset        int
+}
+
+// UserAdminService інтерфейс для адміністрування користувачів
+type UserAdminService interface {
+	ListUsers(filter UserListFilter) ([]User, int64, error)
+	GetUser(userID string) (*User, error)
+	UpdateUser(adminID, userID string, updates map[string]interface{}) (*User, error)
+	DeactivateUser(adminID, userID string) error
+	ReactivateUser(adminID, userID string) error
+	ForceLogout(adminID, userID string) error
+	ForcePasswordReset(adminID, userID string) error
+	HardDeleteUser(adminID, userID string) error
+}
+
+// userAdminService реалізація UserAdminService
+type userAdminService struct {
+	db                   *gorm.DB
+	authService          AuthService
+	passwordResetService PasswordResetService
+}
+
+// NewUserAdminService створює новий UserAdminService
+func NewUserAdminService(db *gorm.DB, authService AuthService, passwordResetService PasswordResetService) UserAdminService {
+	return &userAdminService{
+		db:                   db,
+		authService:          authService,
+		passwordResetService: passwordResetService,
+	}
+}
+
+// ListUsers повертає сторінку користувачів (включно з неактивними) і загальну кількість
+func (s *userAdminService) ListUsers(filter UserListFilter) ([]User, int64, error) {
+	query := s.db.Model(&User{})
+	if filter.IsActive != nil {
+		query = query.Where("is_active = ?", *filter.IsActive)
+	}
+	if filter.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("created_at < ?", *filter.CreatedBefore)
+	}
+	if domain := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(filter.EmailDomain), "@")); domain != "" {
+		query = query.Where("LOWER(email) LIKE ?", "%@"+escapeLike(domain))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultUserListLimit
+	}
+	if limit > maxUserListLimit {
+		limit = maxUserListLimit
+	}
+
+	var users []User
+	err := query.Order("created_at DESC").Limit(limit).Offset(filter.Offset).Find(&users).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	return users, total, nil
+}
+
+// GetUser повертає користувача незалежно від того, чи він активний
+func (s *userAdminService) GetUser(userID string) (*User, error) {
+	var user User
+	if err := s.db.Where("id = ?", userID).First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return &user, nil
+}
+
+// UpdateUser змінює дозволені поля користувача
+func (s *userAdminService) UpdateUser(adminID, userID string, updates map[string]interface{}) (*User, error) {
+	user, err := s.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]string, 0, len(updates))
+	for field := range updates {
+		if !adminEditableUserFields[field] {
+			return nil, fmt.Errorf("%w: %s", ErrFieldNotEditable, field)
+		}
+		fields = append(fields, field)
+	}
+	if len(fields) == 0 {
+		return user, nil
+	}
+
+	if email, ok := updates["email"].(string); ok && email != user.Email {
+		var taken int64
+		if err := s.db.Model(&User{}).Where("email = ? AND id <> ?", email, user.ID).Count(&taken).Error; err != nil {
+			return nil, fmt.Errorf("failed to check email: %w", err)
+		}
+		if taken > 0 {
+			return nil, ErrEmailAlreadyInUse
+		}
+
+		// Нову адресу ніхто не підтверджував, якщо адміністратор явно не вказав інше в тому ж запиті
+		if _, ok := updates["is_email_verified"]; !ok {
+			updates["is_email_verified"] = false
+		}
+	}
+
+	updates["updated_at"] = time.Now()
+	if err := s.db.Model(&User{}).Where("id = ?", user.ID).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	logSecurityEvent("admin_user_updated", logrus.Fields{
+		"admin_id": adminID,
+		"user_id":  user.ID,
+		"fields":   fields,
+	})
+	return s.GetUser(user.ID)
+}
+
+// DeactivateUser деактивує користувача і завершує всі його сесії
+func (s *userAdminService) DeactivateUser(adminID, userID string) error {
+	if adminID == userID {
+		return ErrCannotModifySelf
+	}
+
+	user, err := s.GetUser(userID)
+	if err != nil {
+		return err
+	}
+	if !user.IsActive {
+		return nil
+	}
+
+	// Відкликаємо токени поки користувач ще активний
+	if err := s.authService.RevokeUserSessions(user.ID); err != nil {
+		return err
+	}
+
+	if err := s.setActive(user.ID, false); err != nil {
+		return err
+	}
+
+	logSecurityEvent("admin_user_deactivated", logrus.Fields{
+		"admin_id": adminID,
+		"user_id":  user.ID,
+	})
+	return nil
+}
+
+// ReactivateUser повторно активує користувача
+func (s *userAdminService) ReactivateUser(adminID, userID string) error {
+	user, err := s.GetUser(userID)
+	if err != nil {
+		return err
+	}
+	if user.IsActive {
+		return nil
+	}
+
+	if err := s.setActive(user.ID, true); err != nil {
+		return err
+	}
+
+	logSecurityEvent("admin_user_reactivated", logrus.Fields{
+		"admin_id": adminID,
+		"user_id":  user.ID,
+	})
+	return nil
+}
+
+// ForceLogout завершує всі сесії користувача
+func (s *userAdminService) ForceLogout(adminID, userID string) error {
+	user, err := s.GetUser(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.authService.RevokeUserSessions(user.ID); err != nil {
+		return err
+	}
+
+	logSecurityEvent("admin_user_logged_out", logrus.Fields{
+		"admin_id": adminID,
+		"user_id":  user.ID,
+	})
+	return nil
+}
+
+// ForcePasswordReset вимагає від користувача встановити новий пароль і відправляє посилання для скидання
+func (s *userAdminService) ForcePasswordReset(adminID, userID string) error {
+	user, err := s.GetUser(userID)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Model(&User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+		"password_reset_required": true,
+		"updated_at":              time.Now(),
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to require password reset: %w", err)
+	}
+
+	if user.IsActive {
+		if err := s.authService.RevokeUserSessions(user.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.passwordResetService.SendReset(user); err != nil {
+		return err
+	}
+
+	logSecurityEvent("admin_password_reset_forced", logrus.Fields{
+		"admin_id": adminID,
+		"user_id":  user.ID,
+	})
+	return nil
+}
+
+// HardDeleteUser остаточно видаляє користувача разом з дружбами та токенами
+func (s *userAdminService) HardDeleteUser(adminID, userID string) error {
+	if adminID == userID {
+		return ErrCannotModifySelf
+	}
+
+	user, err := s.GetUser(userID)
+	if err != nil {
+		return err
+	}
+
+	if user.IsActive {
+		if err := s.authService.RevokeUserSessions(user.ID); err != nil {
+			return err
+		}
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM friendships WHERE user_id = ? OR friend_id = ?", user.ID, user.ID).Error; err != nil {
+			return fmt.Errorf("failed to delete friendships: %w", err)
+		}
+		if err := tx.Where("sender_id = ? OR receiver_id = ?", user.ID, user.ID).Delete(&FriendRequest{}).Error; err != nil {
+			return fmt.Errorf("failed to delete friend requests: %w", err)
+		}
+		if err := tx.Where("blocker_id = ? OR blocked_id = ?", user.ID, user.ID).Delete(&UserBlock{}).Error; err != nil {
+			return fmt.Errorf("failed to delete user blocks: %w", err)
+		}
+		if err := tx.Exec(`
+			DELETE FROM friend_group_members
+			WHERE friend_id = ? OR group_id IN (SELECT id FROM friend_groups WHERE owner_id = ?)
+		`, user.ID, user.ID).Error; err != nil {
+			return fmt.Errorf("failed to delete friend group members: %w", err)
+		}
+		if err := tx.Where("owner_id = ?", user.ID).Delete(&FriendGroup{}).Error; err != nil {
+			return fmt.Errorf("failed to delete friend groups: %w", err)
+		}
+		if err := tx.Where("follower_id = ? OR followee_id = ?", user.ID, user.ID).Delete(&Follow{}).Error; err != nil {
+			return fmt.Errorf("failed to delete follows: %w", err)
+		}
+		if err := tx.Where("user_id = ? OR actor_id = ?", user.ID, user.ID).Delete(&Notification{}).Error; err != nil {
+			return fmt.Errorf("failed to delete notifications: %w", err)
+		}
+		conversations := tx.Model(&Conversation{}).Select("id").Where("user_a_id = ? OR user_b_id = ?", user.ID, user.ID)
+		if err := tx.Unscoped().Where("conversation_id IN (?)", conversations).Delete(&Message{}).Error; err != nil {
+			return fmt.Errorf("failed to delete messages: %w", err)
+		}
+		if err := tx.Where("user_a_id = ? OR user_b_id = ?", user.ID, user.ID).Delete(&Conversation{}).Error; err != nil {
+			return fmt.Errorf("failed to delete conversations: %w", err)
+		}
+		for _, model := range []interface{}{
+			&EmailVerificationToken{},
+			&PasswordResetToken{},
+			&PersonalAccessToken{},
+			&UserRole{},
+		} {
+			if err := tx.Where("user_id = ?", user.ID).Delete(model).Error; err != nil {
+				return fmt.Errorf("failed to delete user data: %w", err)
+			}
+		}
+		return tx.Where("id = ?", user.ID).Delete(&User{}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	logSecurityEvent("admin_user_deleted", logrus.Fields{
+		"admin_id": adminID,
+		"user_id":  user.ID,
+		"email":    user.Email,
+	})
+	return nil
+}
+
+// setActive змінює прапорець is_active користувача
+func (s *userAdminService) setActive(userID string, active bool) error {
+	err := s.db.Model(&User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"is_active":  active,
+		"updated_at": time.Now(),
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update user status: %w", err)
+	}
+	return nil
+}
+
+// escapeLike екранує спецсимволи шаблону LIKE
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
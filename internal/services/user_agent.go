@@ -0,0 +1,84 @@
+package services
+
+import (
+	"strings"
+
+	"go-practice/internal/models"
+)
+
+// userAgentRule зіставляє фрагмент User-Agent з назвою
+type userAgentRule struct {
+	token string
+	name  string
+}
+
+// Порядок важливий: Edge та Opera містять "Chrome", а Chrome містить "Safari"
+var browserRules = []userAgentRule{
+	{"Edg/", "Edge"},
+	{"OPR/", "Opera"},
+	{"SamsungBrowser/", "Samsung Internet"},
+	{"Firefox/", "Firefox"},
+	{"FxiOS/", "Firefox"},
+	{"CriOS/", "Chrome"},
+	{"Chrome/", "Chrome"},
+	{"Safari/", "Safari"},
+	{"curl/", "curl"},
+	{"PostmanRuntime/", "Postman"},
+	{"okhttp/", "OkHttp"},
+	{"Go-http-client/", "Go HTTP client"},
+}
+
+// Android та iOS перевіряються раніше за Linux і macOS, бо їхні User-Agent містять ці назви
+var osRules = []userAgentRule{
+	{"Windows", "Windows"},
+	{"Android", "Android"},
+	{"iPhone", "iOS"},
+	{"iPad", "iPadOS"},
+	{"CrOS", "ChromeOS"},
+	{"Mac OS X", "macOS"},
+	{"Macintosh", "macOS"},
+	{"Linux", "Linux"},
+}
+
+// ParseUserAgent визначає браузер, ОС та тип пристрою з User-Agent.
+// Розбір евристичний і призначений лише для відображення користувачу.
+func ParseUserAgent(userAgent string) models.DeviceInfo {
+	device := models.DeviceInfo{
+		Browser: "Unknown",
+		OS:      "Unknown",
+		Type:    "unknown",
+	}
+	if userAgent == "" {
+		return device
+	}
+
+	for _, rule := range browserRules {
+		if strings.Contains(userAgent, rule.token) {
+			device.Browser = rule.name
+			break
+		}
+	}
+	for _, rule := range osRules {
+		if strings.Contains(userAgent, rule.token) {
+			device.OS = rule.name
+			break
+		}
+	}
+
+	lower := strings.ToLower(userAgent)
+	switch {
+	case strings.Contains(lower, "bot") || strings.Contains(lower, "crawler") || strings.Contains(lower, "spider"):
+		device.Type = "bot"
+	case strings.Contains(userAgent, "iPad") || strings.Contains(userAgent, "Tablet"):
+		device.Type = "tablet"
+	case strings.Contains(userAgent, "Mobi") || strings.Contains(userAgent, "iPhone"):
+		device.Type = "mobile"
+	case strings.Contains(userAgent, "Android"):
+		// Android без "Mobile" — планшет
+		device.Type = "tablet"
+	case device.OS != "Unknown":
+		device.Type = "desktop"
+	}
+
+	return device
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrCannotBlockSelf повертається при спробі заблокувати самого себе
+	ErrCannotBlockSelf = errors.New("cannot block yourself")
+	// ErrBlockNotFound повертається коли користувача не заблоковано
+	ErrBlockNotFound = errors.New("user is not blocked")
+)
+
+// UserBlock фіксує, що BlockerID заблокував BlockedID
+type UserBlock struct {
+	ID        uint64    `gorm:"primaryKey;autoIncrement" json:"id"`
+	BlockerID string    `gorm:"size:255;not null;uniqueIndex:idx_user_blocks_pair" json:"blocker_id"`
+	BlockedID string    `gorm:"size:255;not null;uniqueIndex:idx_user_blocks_pair;index" json:"blocked_id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Заповнюється у списку блокувань
+	Blocked *User `gorm:"foreignKey:BlockedID;-:migration" json:"blocked,omitempty"`
+}
+
+// TableName явно задає ім'я таблиці для GORM
+func (UserBlock) TableName() string {
+	return "user_blocks"
+}
+
+// Block блокує користувача: дружба і підписки між ними видаляються, а запити, що очікують відповіді, закриваються.
+// Повторне блокування повертає наявний запис.
+func (s *friendService) Block(blockerID, blockedID string) (*UserBlock, error) {
+	if blockerID == blockedID {
+		return nil, ErrCannotBlockSelf
+	}
+
+	if _, err := s.userService.GetUserByID(blockedID); err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	block := &UserBlock{
+		BlockerID: blockerID,
+		BlockedID: blockedID,
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(block).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("blocker_id = ? AND blocked_id = ?", blockerID, blockedID).First(block).Error; err != nil {
+			return err
+		}
+
+		if _, err := removeFriendship(tx, blockerID, blockedID); err != nil {
+			return err
+		}
+		if err := removeFollows(tx, blockerID, blockedID); err != nil {
+			return err
+		}
+
+		// Запит блокувальника вважається скасованим, запит заблокованого — відхиленим
+		now := time.Now()
+		return tx.Model(&FriendRequest{}).
+			Where("status = ? AND ((sender_id = ? AND receiver_id = ?) OR (sender_id = ? AND receiver_id = ?))",
+				FriendRequestPending, blockerID, blockedID, blockedID, blockerID).
+			Updates(map[string]interface{}{
+				"status":       gorm.Expr("CASE WHEN sender_id = ? THEN ? ELSE ? END", blockerID, FriendRequestCancelled, FriendRequestDeclined),
+				"responded_at": now,
+				"updated_at":   now,
+			}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to block user: %w", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"blocker_id": blockerID,
+		"blocked_id": blockedID,
+	}).Info("User blocked")
+
+	return block, nil
+}
+
+// Unblock знімає блокування; дружба, видалена при блокуванні, не відновлюється
+func (s *friendService) Unblock(blockerID, blockedID string) error {
+	result := s.db.Where("blocker_id = ? AND blocked_id = ?", blockerID, blockedID).Delete(&UserBlock{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to unblock user: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrBlockNotFound
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"blocker_id": blockerID,
+		"blocked_id": blockedID,
+	}).Info("User unblocked")
+
+	return nil
+}
+
+// ListBlocked повертає користувачів, заблокованих userID, від новіших до старіших
+func (s *friendService) ListBlocked(userID string) ([]UserBlock, error) {
+	var blocks []UserBlock
+	err := s.db.Preload("Blocked").
+		Where("blocker_id = ?", userID).
+		Order("created_at DESC").
+		Find(&blocks).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blocked users: %w", err)
+	}
+	return blocks, nil
+}
+
+// IsBlockedBetween перевіряє чи хтось із двох користувачів заблокував іншого
+func (s *friendService) IsBlockedBetween(userID, otherID string) (bool, error) {
+	return isBlockedBetween(s.db, userID, otherID)
+}
+
+// isBlockedBetween перевіряє блокування в обох напрямках; спільна для сервісів друзів і підписок
+func isBlockedBetween(db *gorm.DB, userID, otherID string) (bool, error) {
+	var exists bool
+	err := db.Raw(`
+		SELECT EXISTS (
+			SELECT 1 FROM user_blocks
+			WHERE (blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)
+		)
+	`, userID, otherID, otherID, userID).Scan(&exists).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check user blocks: %w", err)
+	}
+	return exists, nil
+}
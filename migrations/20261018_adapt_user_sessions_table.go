@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// AdaptUserSessionsTable приводить таблицю user_sessions зі scripts/db/init.sql до моделі SessionManager:
+// user_id стає текстовим і необов'язковим (сесії незавершеного OIDC входу ще не мають користувача),
+// ip_address — текстовим, додаються колонки state та scopes.
+func AdaptUserSessionsTable(tx *gorm.DB) error {
+	statements := []string{
+		`ALTER TABLE user_sessions DROP CONSTRAINT IF EXISTS user_sessions_user_id_fkey`,
+		`ALTER TABLE user_sessions ALTER COLUMN user_id TYPE VARCHAR(255) USING user_id::text`,
+		`ALTER TABLE user_sessions ALTER COLUMN user_id DROP NOT NULL`,
+		`DO $$
+		BEGIN
+			IF EXISTS (SELECT 1 FROM information_schema.columns
+				WHERE table_name = 'user_sessions' AND column_name = 'ip_address' AND data_type = 'inet') THEN
+				ALTER TABLE user_sessions ALTER COLUMN ip_address TYPE VARCHAR(64) USING host(ip_address);
+			END IF;
+		END $$`,
+		`ALTER TABLE user_sessions ADD COLUMN IF NOT EXISTS state VARCHAR(255)`,
+		`ALTER TABLE user_sessions ADD COLUMN IF NOT EXISTS scopes TEXT`,
+		`ALTER TABLE user_sessions ADD COLUMN IF NOT EXISTS last_accessed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP`,
+		`CREATE INDEX IF NOT EXISTS idx_user_sessions_expires_at ON user_sessions(expires_at)`,
+	}
+
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
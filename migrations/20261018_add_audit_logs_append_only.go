@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// AddAuditLogsAppendOnly забороняє змінювати записи audit_logs.
+// Видалення дозволене — ним користується очищення за терміном зберігання.
+func AddAuditLogsAppendOnly(tx *gorm.DB) error {
+	if err := tx.Exec(`
+		CREATE OR REPLACE FUNCTION audit_logs_reject_update() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'audit_logs is append-only';
+		END;
+		$$ LANGUAGE plpgsql`).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Exec(`DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs`).Error; err != nil {
+		return err
+	}
+
+	return tx.Exec(`
+		CREATE TRIGGER audit_logs_append_only
+		BEFORE UPDATE ON audit_logs
+		FOR EACH ROW EXECUTE FUNCTION audit_logs_reject_update()`).Error
+}
+
+// DropAuditLogsAppendOnly знімає заборону змін audit_logs
+func DropAuditLogsAppendOnly(tx *gorm.DB) error {
+	if err := tx.Exec(`DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs`).Error; err != nil {
+		return err
+	}
+	return tx.Exec(`DROP FUNCTION IF EXISTS audit_logs_reject_update()`).Error
+}
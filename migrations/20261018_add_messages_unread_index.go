@@ -0,0 +1,18 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// AddMessagesUnreadIndex додає частковий індекс непрочитаних повідомлень.
+// Він обслуговує лічильники непрочитаних у списку розмов та позначення прочитаним.
+func AddMessagesUnreadIndex(tx *gorm.DB) error {
+	return tx.Exec(`CREATE INDEX IF NOT EXISTS idx_messages_unread
+		ON messages (recipient_id, conversation_id)
+		WHERE read_at IS NULL AND deleted_at IS NULL`).Error
+}
+
+// DropMessagesUnreadIndex видаляє частковий індекс непрочитаних повідомлень
+func DropMessagesUnreadIndex(tx *gorm.DB) error {
+	return tx.Exec(`DROP INDEX IF EXISTS idx_messages_unread`).Error
+}
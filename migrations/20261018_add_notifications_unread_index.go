@@ -0,0 +1,18 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// AddNotificationsUnreadIndex додає частковий індекс непрочитаних сповіщень.
+// Він обслуговує лічильник непрочитаних і фільтр unread; повний список іде по idx_notifications_user_created.
+func AddNotificationsUnreadIndex(tx *gorm.DB) error {
+	return tx.Exec(`CREATE INDEX IF NOT EXISTS idx_notifications_unread
+		ON notifications (user_id, created_at DESC)
+		WHERE read_at IS NULL`).Error
+}
+
+// DropNotificationsUnreadIndex видаляє частковий індекс непрочитаних сповіщень
+func DropNotificationsUnreadIndex(tx *gorm.DB) error {
+	return tx.Exec(`DROP INDEX IF EXISTS idx_notifications_unread`).Error
+}
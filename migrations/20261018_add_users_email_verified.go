@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// AddUsersEmailVerified додає колонку is_email_verified до users.
+// Користувачі, створені до появи верифікації, позначаються як підтверджені. Колонку може
+// раніше створити AutoMigrate при старті сервера, тому зареєстровані після цього (вони вже
+// отримали лист підтвердження) не змінюються. Виконується один раз через RunOnce.
+func AddUsersEmailVerified(tx *gorm.DB) error {
+	if err := tx.Exec(`ALTER TABLE users ADD COLUMN IF NOT EXISTS is_email_verified BOOLEAN DEFAULT FALSE`).Error; err != nil {
+		return err
+	}
+	return tx.Exec(`
+		UPDATE users SET is_email_verified = TRUE
+		WHERE is_email_verified = FALSE
+			AND NOT EXISTS (SELECT 1 FROM email_verification_tokens t WHERE t.user_id = users.id)
+			AND created_at < COALESCE((SELECT MIN(created_at) FROM email_verification_tokens), NOW())`).Error
+}
+
+// DropUsersEmailVerified видаляє колонку is_email_verified
+func DropUsersEmailVerified(tx *gorm.DB) error {
+	return tx.Exec(`ALTER TABLE users DROP COLUMN IF EXISTS is_email_verified`).Error
+}
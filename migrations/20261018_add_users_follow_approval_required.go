@@ -0,0 +1,16 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// AddUsersFollowApprovalRequired додає колонку follow_approval_required до users.
+// Існуючі користувачі приймають підписки без схвалення.
+func AddUsersFollowApprovalRequired(tx *gorm.DB) error {
+	return tx.Exec(`ALTER TABLE users ADD COLUMN IF NOT EXISTS follow_approval_required BOOLEAN NOT NULL DEFAULT FALSE`).Error
+}
+
+// DropUsersFollowApprovalRequired видаляє колонку follow_approval_required
+func DropUsersFollowApprovalRequired(tx *gorm.DB) error {
+	return tx.Exec(`ALTER TABLE users DROP COLUMN IF EXISTS follow_approval_required`).Error
+}
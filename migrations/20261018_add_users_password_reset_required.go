@@ -0,0 +1,15 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// AddUsersPasswordResetRequired додає колонку password_reset_required до users
+func AddUsersPasswordResetRequired(tx *gorm.DB) error {
+	return tx.Exec(`ALTER TABLE users ADD COLUMN IF NOT EXISTS password_reset_required BOOLEAN DEFAULT FALSE`).Error
+}
+
+// DropUsersPasswordResetRequired видаляє колонку password_reset_required
+func DropUsersPasswordResetRequired(tx *gorm.DB) error {
+	return tx.Exec(`ALTER TABLE users DROP COLUMN IF EXISTS password_reset_required`).Error
+}
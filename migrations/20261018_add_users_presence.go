@@ -0,0 +1,18 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// AddUsersPresence додає до users колонки присутності: last_seen_at та hide_presence.
+// Існуючі користувачі показують статус друзям; last_seen_at заповниться з першим запитом.
+func AddUsersPresence(tx *gorm.DB) error {
+	return tx.Exec(`ALTER TABLE users
+		ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMPTZ,
+		ADD COLUMN IF NOT EXISTS hide_presence BOOLEAN NOT NULL DEFAULT FALSE`).Error
+}
+
+// DropUsersPresence видаляє колонки присутності
+func DropUsersPresence(tx *gorm.DB) error {
+	return tx.Exec(`ALTER TABLE users DROP COLUMN IF EXISTS last_seen_at, DROP COLUMN IF EXISTS hide_presence`).Error
+}
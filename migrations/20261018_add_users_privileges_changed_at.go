@@ -0,0 +1,16 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// AddUsersPrivilegesChangedAt додає колонку privileges_changed_at до users.
+// Cookie сесії, видані раніше цього моменту, отримують новий ID при наступному запиті.
+func AddUsersPrivilegesChangedAt(tx *gorm.DB) error {
+	return tx.Exec(`ALTER TABLE users ADD COLUMN IF NOT EXISTS privileges_changed_at TIMESTAMPTZ`).Error
+}
+
+// DropUsersPrivilegesChangedAt видаляє колонку privileges_changed_at
+func DropUsersPrivilegesChangedAt(tx *gorm.DB) error {
+	return tx.Exec(`ALTER TABLE users DROP COLUMN IF EXISTS privileges_changed_at`).Error
+}
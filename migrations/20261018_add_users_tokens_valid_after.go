@@ -0,0 +1,16 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// AddUsersTokensValidAfter додає колонку tokens_valid_after до users.
+// Токени, видані раніше цього моменту, вважаються відкликаними.
+func AddUsersTokensValidAfter(tx *gorm.DB) error {
+	return tx.Exec(`ALTER TABLE users ADD COLUMN IF NOT EXISTS tokens_valid_after TIMESTAMPTZ`).Error
+}
+
+// DropUsersTokensValidAfter видаляє колонку tokens_valid_after
+func DropUsersTokensValidAfter(tx *gorm.DB) error {
+	return tx.Exec(`ALTER TABLE users DROP COLUMN IF EXISTS tokens_valid_after`).Error
+}
@@ -0,0 +1,90 @@
+package migrations
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// normalizedUserID повертає SQL вираз, що додає префікс usr_ до ID, збережених без нього
+func normalizedUserID(column string) string {
+	return fmt.Sprintf(`CASE WHEN %[1]s LIKE 'usr\_%%' THEN %[1]s ELSE 'usr_' || %[1]s END`, column)
+}
+
+// ConvertFriendshipsToRequests переводить односторонні дружби на модель запитів у друзі.
+// ID нормалізуються до формату з префіксом usr_, взаємні пари лишаються дружбою,
+// а односторонні записи (додані без згоди другого користувача) стають запитами в статусі pending.
+func ConvertFriendshipsToRequests(tx *gorm.DB) error {
+	return tx.Transaction(func(tx *gorm.DB) error {
+		statements := []string{
+			// Дублікати, що збігаються після нормалізації префікса
+			`DELETE FROM friendships f USING friendships g
+			WHERE f.id > g.id
+				AND ` + normalizedUserID("f.user_id") + ` = ` + normalizedUserID("g.user_id") + `
+				AND ` + normalizedUserID("f.friend_id") + ` = ` + normalizedUserID("g.friend_id"),
+			`UPDATE friendships
+			SET user_id = ` + normalizedUserID("user_id") + `, friend_id = ` + normalizedUserID("friend_id") + `
+			WHERE user_id NOT LIKE 'usr\_%' OR friend_id NOT LIKE 'usr\_%'`,
+			// Дружба з самим собою та записи, що посилаються на видалених користувачів
+			`DELETE FROM friendships WHERE user_id = friend_id`,
+			`DELETE FROM friendships f
+			WHERE NOT EXISTS (SELECT 1 FROM users u WHERE u.id = f.user_id)
+				OR NOT EXISTS (SELECT 1 FROM users u WHERE u.id = f.friend_id)`,
+			// Односторонні записи стають запитами в друзі
+			`INSERT INTO friend_requests (sender_id, receiver_id, status, created_at, updated_at)
+			SELECT f.user_id, f.friend_id, 'pending', f.created_at, NOW()
+			FROM friendships f
+			WHERE NOT EXISTS (
+				SELECT 1 FROM friendships r WHERE r.user_id = f.friend_id AND r.friend_id = f.user_id
+			)
+			ON CONFLICT DO NOTHING`,
+			`DELETE FROM friendships f
+			WHERE NOT EXISTS (
+				SELECT 1 FROM friendships r WHERE r.user_id = f.friend_id AND r.friend_id = f.user_id
+			)`,
+		}
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// AddFriendRequestsPendingPairIndex гарантує не більше одного активного запиту між парою користувачів
+func AddFriendRequestsPendingPairIndex(tx *gorm.DB) error {
+	return tx.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_friend_requests_pending_pair
+		ON friend_requests (LEAST(sender_id, receiver_id), GREATEST(sender_id, receiver_id))
+		WHERE status = 'pending'
+	`).Error
+}
+
+// DropFriendRequestsPendingPairIndex видаляє індекс активних запитів пари користувачів
+func DropFriendRequestsPendingPairIndex(tx *gorm.DB) error {
+	return tx.Exec(`DROP INDEX IF EXISTS idx_friend_requests_pending_pair`).Error
+}
+
+// AddFriendRequestsListIndexes додає часткові індекси активних запитів для списків вхідних і вихідних запитів
+func AddFriendRequestsListIndexes(tx *gorm.DB) error {
+	statements := []string{
+		`CREATE INDEX IF NOT EXISTS idx_friend_requests_pending_receiver
+		ON friend_requests (receiver_id, created_at DESC)
+		WHERE status = 'pending'`,
+		`CREATE INDEX IF NOT EXISTS idx_friend_requests_pending_sender
+		ON friend_requests (sender_id, created_at DESC)
+		WHERE status = 'pending'`,
+	}
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DropFriendRequestsListIndexes видаляє індекси списків вхідних і вихідних запитів
+func DropFriendRequestsListIndexes(tx *gorm.DB) error {
+	return tx.Exec(`DROP INDEX IF EXISTS idx_friend_requests_pending_receiver, idx_friend_requests_pending_sender`).Error
+}
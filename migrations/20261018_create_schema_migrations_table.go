@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// RunOnce виконує одноразову міграцію name і фіксує її в schema_migrations.
+// Потрібна для міграцій даних, які не можна визначити за станом схеми:
+// AutoMigrate при старті сервера може створити колонку раніше, ніж відпрацює команда migrate.
+// Повертає false, якщо міграцію вже було виконано раніше.
+func RunOnce(db *gorm.DB, name string, migrate func(tx *gorm.DB) error) (bool, error) {
+	if err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			name VARCHAR(255) PRIMARY KEY,
+			applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`).Error; err != nil {
+		return false, err
+	}
+
+	applied := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Маркер вставляється першим: паралельний запуск migrate чекає на блокування рядка
+		result := tx.Exec(`INSERT INTO schema_migrations (name) VALUES (?) ON CONFLICT (name) DO NOTHING`, name)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		applied = true
+		return migrate(tx)
+	})
+	if err != nil {
+		return false, err
+	}
+	return applied, nil
+}